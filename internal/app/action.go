@@ -8,6 +8,7 @@ import (
 
 	"sdmm/internal/app/prefs"
 	"sdmm/internal/app/render"
+	"sdmm/internal/app/ui/cpwsarea"
 	"sdmm/internal/app/ui/cpwsarea/wsmap"
 	"sdmm/internal/app/ui/cpwsarea/wsmap/pmap"
 	"sdmm/internal/app/ui/cpwsarea/wsmap/pmap/editor"
@@ -17,9 +18,11 @@ import (
 	"sdmm/internal/app/command"
 	"sdmm/internal/dmapi/dm"
 	"sdmm/internal/dmapi/dmenv"
+	"sdmm/internal/dmapi/dmicon"
 	"sdmm/internal/dmapi/dmmap"
 	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
 	"sdmm/internal/dmapi/dmmclip"
+	"sdmm/internal/dmapi/dmmlint"
 
 	"github.com/SpaiR/imgui-go"
 	"github.com/rs/zerolog/log"
@@ -120,6 +123,17 @@ func (a *app) LoadedEnvironment() *dmenv.Dme {
 	return a.loadedEnvironment
 }
 
+// LintConfig returns the configuration to check maps for problems.
+func (a *app) LintConfig() dmmlint.Config {
+	cfg := dmmlint.Config{
+		Obsolete: a.obsoleteConfig(),
+	}
+	if a.projectPrefs != nil {
+		cfg.Disabled = a.projectPrefs.LintDisabledRules
+	}
+	return cfg
+}
+
 // HasLoadedEnvironment returns true if there is any loaded environment.
 func (a *app) HasLoadedEnvironment() bool {
 	return a.loadedEnvironment != nil
@@ -169,6 +183,7 @@ func (a *app) OnWorkspaceSwitched() {
 	} else {
 		a.layout.Search.Free()
 	}
+	a.layout.Problems.Free()
 
 	a.SyncVarEditor()
 }
@@ -221,6 +236,16 @@ func (a *app) AreaBordersRendering() bool {
 	return pmap.AreaBordersRendering
 }
 
+// LightingRendering returns true if a lighting preview rendering enabled.
+func (a *app) LightingRendering() bool {
+	return pmap.LightingRendering
+}
+
+// HeatmapMetric returns the name of the metric shown as a heatmap. Empty, if the heatmap is disabled.
+func (a *app) HeatmapMetric() string {
+	return pmap.HeatmapMetric
+}
+
 // MultiZRendering returns true if a multi-z rendering enabled.
 func (a *app) MultiZRendering() bool {
 	return render.MultiZRendering
@@ -231,9 +256,34 @@ func (a *app) MirrorCanvasCamera() bool {
 	return pmap.MirrorCanvasCamera
 }
 
-// Prefs returns current application preferences.
+// IconSmoothing returns true if the icon smoothing preview is enabled.
+func (a *app) IconSmoothing() bool {
+	return dmmap.SmoothingPreview
+}
+
+// HasIconSmoothing returns true if the loaded project configures icon smoothing.
+func (a *app) HasIconSmoothing() bool {
+	return dmmap.Smoothing.Enabled()
+}
+
+// IconsAnimation returns true if icon states with several frames are animated.
+func (a *app) IconsAnimation() bool {
+	return dmicon.AnimationEnabled
+}
+
+// IconsAnimationPaused returns true if icons animation is stopped at the current frame.
+func (a *app) IconsAnimationPaused() bool {
+	return dmicon.AnimationPaused()
+}
+
+// IconsLoading returns the number of icons loaded in the background at the moment.
+func (a *app) IconsLoading() int {
+	return dmicon.Cache.Loading()
+}
+
+// Prefs returns current application preferences, overridden by the project preferences.
 func (a *app) Prefs() prefs.Prefs {
-	return a.preferencesConfig().Prefs
+	return a.projectPrefs.Apply(a.preferencesConfig().Prefs)
 }
 
 // FocusApplicationWindow explicitly moves an OS focus to the current application window.
@@ -243,9 +293,7 @@ func (a *app) FocusApplicationWindow() {
 
 func (a *app) activeWsMap() (*wsmap.WsMap, bool) {
 	if wsMapActive := a.layout.WsArea.ActiveWorkspace(); wsMapActive != nil {
-		if activeWs, ok := wsMapActive.Content().(*wsmap.WsMap); ok {
-			return activeWs, ok
-		}
+		return cpwsarea.MapContent(wsMapActive)
 	}
 	return nil, false
 }
@@ -9,6 +9,7 @@ import (
 	"sdmm/internal/app/ui/cpwsarea/wsmap/pmap"
 	"sdmm/internal/app/ui/layout/lnode"
 	"sdmm/internal/app/window"
+	"sdmm/internal/dmapi/dmicon"
 	"sdmm/internal/dmapi/dmmap"
 	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
 	"sdmm/internal/dmapi/dmmap/dmminstance"
@@ -141,8 +142,8 @@ func (a *app) DoCloseAll() {
 // DoSave saves current active map.
 func (a *app) DoSave() {
 	log.Print("do save")
-	if ws, ok := a.activeWsMap(); ok {
-		ws.Save()
+	if a.HasActiveMap() {
+		a.layout.WsArea.ActiveWorkspace().Save()
 	}
 }
 
@@ -157,7 +158,7 @@ func (a *app) DoSaveAll() {
 // DoOpenPreferences opens preferences tab.
 func (a *app) DoOpenPreferences() {
 	log.Print("open preferences")
-	a.layout.WsArea.OpenPreferences(prefs.Make(a, &a.preferencesConfig().Prefs))
+	a.layout.WsArea.OpenPreferences(prefs.Make(a, &a.preferencesConfig().Prefs, a.projectPrefs))
 }
 
 // DoSelectPrefab globally selects provided prefab in the app.
@@ -315,12 +316,48 @@ func (a *app) DoSearch() {
 	a.ShowLayout(lnode.NameSearch, true)
 }
 
+// DoCheckProblems checks the active map for problems and shows them.
+func (a *app) DoCheckProblems() {
+	log.Print("do check problems")
+	a.layout.Problems.Check()
+	a.ShowLayout(lnode.NameProblems, true)
+}
+
+// DoApplyPathsFilterPreset shows all paths except hidden by the preset.
+func (a *app) DoApplyPathsFilterPreset(preset prefs.PathsFilterPreset) {
+	log.Print("do apply paths filter preset:", preset.Name)
+	a.pathsFilter.Clear()
+	for _, path := range preset.Hidden {
+		if _, ok := a.loadedEnvironment.Objects[path]; ok {
+			a.pathsFilter.HidePath(path)
+		} else {
+			log.Print("unknown preset path:", path)
+		}
+	}
+}
+
 // DoAreaBorders toggles area borders rendering.
 func (a *app) DoAreaBorders() {
 	pmap.AreaBordersRendering = !pmap.AreaBordersRendering
 	log.Print("do area borders:", pmap.AreaBordersRendering)
 }
 
+// DoLighting toggles lighting preview rendering.
+func (a *app) DoLighting() {
+	pmap.LightingRendering = !pmap.LightingRendering
+	log.Print("do lighting:", pmap.LightingRendering)
+}
+
+// DoHeatmap toggles the heatmap of the provided metric. Only a single metric could be shown at once.
+func (a *app) DoHeatmap(metric string) {
+	if pmap.HeatmapMetric == metric {
+		pmap.HeatmapMetric = ""
+	} else {
+		pmap.HeatmapMetric = metric
+	}
+	log.Print("do heatmap:", pmap.HeatmapMetric)
+}
+
 // DoMultiZRendering toggles multi-z rendering.
 func (a *app) DoMultiZRendering() {
 	render.MultiZRendering = !render.MultiZRendering
@@ -333,6 +370,33 @@ func (a *app) DoMirrorCanvasCamera() {
 	log.Print("do mirror canvas camera:", pmap.MirrorCanvasCamera)
 }
 
+// DoIconsAnimation toggles animation of icon states.
+func (a *app) DoIconsAnimation() {
+	dmicon.AnimationEnabled = !dmicon.AnimationEnabled
+	log.Print("do icons animation:", dmicon.AnimationEnabled)
+}
+
+// DoPauseIconsAnimation stops icons animation at the current frame or resumes it.
+func (a *app) DoPauseIconsAnimation() {
+	dmicon.SetAnimationPaused(!dmicon.AnimationPaused())
+	log.Print("do pause icons animation:", dmicon.AnimationPaused())
+}
+
+// DoIconSmoothing toggles the icon smoothing preview.
+func (a *app) DoIconSmoothing() {
+	dmmap.SmoothingPreview = !dmmap.SmoothingPreview
+	log.Print("do icon smoothing:", dmmap.SmoothingPreview)
+	for _, mapEditor := range a.layout.WsArea.MapEditors() {
+		mapEditor.UpdateCanvas()
+	}
+}
+
+// DoReloadIcons loads all used icons from the disk again.
+func (a *app) DoReloadIcons() {
+	log.Print("do reload icons")
+	a.reloadIcons(dmicon.Cache.Icons())
+}
+
 // DoSelfUpdate starts the process of a self update.
 func (a *app) DoSelfUpdate() {
 	log.Print("do self update")
@@ -383,3 +447,38 @@ func (a *app) DoOpenJumpWindow() {
 		},
 	})
 }
+
+// DoCompareWithFile compares the active map with the map file, which user need to select in file dialog.
+func (a *app) DoCompareWithFile() {
+	log.Print("do compare with file")
+
+	if file, err := dialog.
+		File().
+		Title("Compare with File").
+		Filter("Map", "dmm").
+		SetStartDir(a.loadedEnvironment.RootDir).
+		Load(); err == nil {
+		a.FocusApplicationWindow() // After a system dialog has been opened we need to return the focus.
+		a.compareWithFile(file)
+	}
+}
+
+// DoCompareWithRevision opens a window where the user can input a git revision to compare the active map with.
+func (a *app) DoCompareWithRevision() {
+	log.Print("do compare with revision")
+	revision := "HEAD"
+	dial.Open(dial.TypeCustom{
+		Title:       "Compare with Git Revision",
+		CloseButton: true,
+		Layout: w.Layout{
+			w.AlignTextToFramePadding(),
+			w.InputTextWithHint("##revision", "Revision", &revision),
+			w.Button("Compare", func() {
+				if len(revision) != 0 {
+					a.compareWithRevision(revision)
+					imgui.CloseCurrentPopup()
+				}
+			}),
+		},
+	})
+}
@@ -10,6 +10,7 @@ import (
 
 	"sdmm/internal/app/command"
 	"sdmm/internal/app/config"
+	"sdmm/internal/app/prefs"
 	"sdmm/internal/app/render/brush"
 	"sdmm/internal/app/ui/dialog"
 	"sdmm/internal/app/ui/layout"
@@ -18,8 +19,11 @@ import (
 	"sdmm/internal/app/window"
 	"sdmm/internal/dmapi/dm"
 	"sdmm/internal/dmapi/dmenv"
+	"sdmm/internal/dmapi/dmicon"
 	"sdmm/internal/dmapi/dmmclip"
+	"sdmm/internal/dmapi/dmmmigrate"
 	"sdmm/internal/env"
+	"sdmm/internal/util/fswatch"
 
 	"github.com/SpaiR/imgui-go"
 	"github.com/matishsiao/goInfo"
@@ -28,6 +32,7 @@ import (
 const (
 	ttlDaysLogs    = 14
 	ttlDaysBackups = 3
+	ttlDaysHistory = 30
 )
 
 func Start() {
@@ -52,6 +57,9 @@ func Start() {
 		internalDir: internalDir,
 		logDir:      logDir,
 		backupDir:   filepath.FromSlash(internalDir + "/backup"),
+		historyDir:  filepath.FromSlash(internalDir + "/history"),
+		journalDir:  filepath.FromSlash(internalDir + "/journal"),
+		cacheDir:    filepath.FromSlash(internalDir + "/cache"),
 		configDir:   filepath.FromSlash(internalDir + "/config"),
 	}
 
@@ -76,6 +84,9 @@ type app struct {
 	internalDir string
 	logDir      string
 	backupDir   string
+	historyDir  string
+	journalDir  string
+	cacheDir    string
 	configDir   string
 
 	tmpShouldClose bool
@@ -90,6 +101,13 @@ type app struct {
 
 	loadedEnvironment *dmenv.Dme
 	pathsFilter       *dm.PathsFilter
+	projectPrefs      *prefs.Project // Could be nil, if the environment has no project preferences.
+	migrationRules    dmmmigrate.Rules
+	envWatcher        *fswatch.Watcher
+	iconsWatcher      *fswatch.Watcher
+
+	// Maps saved while the user is asked to approve save hooks. Hooks run for them after the approval.
+	pendingSaveHooks []string
 
 	configs map[string]config.Config
 
@@ -103,6 +121,7 @@ type app struct {
 func (a *app) initialize() {
 	a.deleteOldLogs()
 	a.deleteOldBackups()
+	a.deleteOldHistory()
 
 	a.loadConfig()
 	a.loadProjectConfig()
@@ -119,6 +138,9 @@ func (a *app) initialize() {
 	a.menu = menu.New(a)
 	a.layout = layout.New(a)
 
+	// Icons are decoded in the background, so the first render of a big map doesn't freeze the editor.
+	dmicon.Cache.SetAsync(runtime.NumCPU(), a.refreshIcons)
+
 	a.updateScale()
 	a.updateLayoutState()
 
@@ -250,6 +272,15 @@ func (a *app) deleteOldBackups() {
 	}
 }
 
+func (a *app) deleteOldHistory() {
+	historyCount := deleteOldFiles(a.historyDir, ttlDaysHistory)
+	if historyCount > 0 {
+		log.Print("old history deleted:", historyCount)
+	} else {
+		log.Print("no old history to delete")
+	}
+}
+
 func deleteOldFiles(dir string, ttlDays float64) (deletedFiles int) {
 	_ = filepath.Walk(dir, func(path string, info os.FileInfo, _ error) error {
 		if info != nil && !info.IsDir() && time.Since(info.ModTime()).Hours()/24 > ttlDays {
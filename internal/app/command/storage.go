@@ -50,13 +50,60 @@ func (s *Storage) DisposeStack(id string) {
 	}
 }
 
+// Restore fills the stack with commands restored from the previous session.
+// The undo list is ordered from the oldest command, the redo list is ordered from the newest one, like stacks are.
+// The restored state is considered as not modified.
+func (s *Storage) Restore(id string, undo, redo []Command) {
+	if id == NullSpaceStackId {
+		log.Print("skipping restore for:", id)
+		return
+	}
+
+	stack, ok := s.commandStacks[id]
+	if !ok {
+		stack = &commandStack{id: id}
+		s.commandStacks[id] = stack
+		log.Print("created stack:", id)
+	}
+
+	logStackAction(stack, "restore")
+	stack.undo = undo
+	stack.redo = redo
+	stack.balance = 0
+	stack.balanceCommandId = stack.appliedCommandId()
+	stack.modified = false
+}
+
+// MarkModified marks the stack as modified until it's forcefully balanced.
+// Used when the content is changed without commands, like a map migrated on load.
+func (s *Storage) MarkModified(id string) {
+	if id == NullSpaceStackId {
+		log.Print("skipping mark modified for:", id)
+		return
+	}
+
+	stack, ok := s.commandStacks[id]
+	if !ok {
+		stack = &commandStack{id: id}
+		s.commandStacks[id] = stack
+		log.Print("created stack:", id)
+	}
+
+	logStackAction(stack, "mark modified")
+	stack.modified = true
+}
+
 func (s *Storage) Push(command Command) {
-	if s.currentStackId == NullSpaceStackId {
-		log.Print("skip pushing for:", s.currentStackId)
+	s.PushV(s.currentStackId, command)
+}
+
+func (s *Storage) PushV(id string, command Command) {
+	if id == NullSpaceStackId {
+		log.Print("skip pushing for:", id)
 		return
 	}
 
-	if stack, ok := s.commandStacks[s.currentStackId]; ok {
+	if stack, ok := s.commandStacks[id]; ok {
 		logStackAction(stack, "push command: "+command.name)
 		stack.undo = append(stack.undo, command)
 		stack.redo = stack.redo[:0]
@@ -142,7 +189,7 @@ func (s *Storage) HasRedoV(id string) bool {
 
 func (s *Storage) IsModified(id string) bool {
 	if stack, ok := s.commandStacks[id]; ok {
-		return stack.balance != 0 || stack.appliedCommandId() != stack.balanceCommandId
+		return stack.modified || stack.balance != 0 || stack.appliedCommandId() != stack.balanceCommandId
 	}
 	return false
 }
@@ -157,6 +204,7 @@ func (s *Storage) ForceBalance(id string) {
 		logStackAction(stack, "force balance")
 		stack.balance = 0
 		stack.balanceCommandId = stack.appliedCommandId()
+		stack.modified = false
 	}
 }
 
@@ -197,6 +245,8 @@ type commandStack struct {
 
 	// Field stores a command id at the moment when the stack was forcefully balanced.
 	balanceCommandId uint64
+	// The stack content is changed without commands, so it's modified until the stack is forcefully balanced.
+	modified bool
 }
 
 func (c commandStack) appliedCommandId() uint64 {
@@ -0,0 +1,90 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Makes commands which write their actions to the list.
+// Commands in the redo list are already applied once, so their actions are swapped.
+func makeTestCommands(calls *[]string) (undoCmd, redoCmd func(name string) Command) {
+	actions := func(name string) (undo, redo func()) {
+		return func() { *calls = append(*calls, "undo "+name) }, func() { *calls = append(*calls, "redo "+name) }
+	}
+	undoCmd = func(name string) Command {
+		undo, redo := actions(name)
+		return Make(name, undo, redo)
+	}
+	redoCmd = func(name string) Command {
+		undo, redo := actions(name)
+		return Make(name, redo, undo)
+	}
+	return undoCmd, redoCmd
+}
+
+func TestStorage_Restore(t *testing.T) {
+	var calls []string
+	undoCmd, redoCmd := makeTestCommands(&calls)
+
+	s := NewStorage()
+	s.SetStack("map")
+	s.Restore("map", []Command{undoCmd("1"), undoCmd("2")}, []Command{redoCmd("4"), redoCmd("3")})
+
+	assert.False(t, s.IsModified("map"))
+	assert.True(t, s.HasUndo())
+	assert.True(t, s.HasRedo())
+
+	s.Redo()
+	s.Redo()
+	assert.False(t, s.HasRedo())
+	assert.True(t, s.IsModified("map"))
+
+	for s.HasUndo() {
+		s.Undo()
+	}
+	s.Redo()
+	assert.Equal(t, []string{"redo 3", "redo 4", "undo 4", "undo 3", "undo 2", "undo 1", "redo 1"}, calls)
+}
+
+func TestStorage_RestoreModified(t *testing.T) {
+	var calls []string
+	undoCmd, redoCmd := makeTestCommands(&calls)
+
+	s := NewStorage()
+	s.Restore("map", []Command{undoCmd("1")}, []Command{redoCmd("2")})
+
+	s.UndoV("map")
+	assert.True(t, s.IsModified("map"))
+	s.RedoV("map")
+	assert.False(t, s.IsModified("map"))
+	assert.Equal(t, []string{"undo 1", "redo 1"}, calls)
+}
+
+func TestStorage_RestoreNullSpace(t *testing.T) {
+	var calls []string
+	undoCmd, _ := makeTestCommands(&calls)
+
+	s := NewStorage()
+	s.Restore(NullSpaceStackId, []Command{undoCmd("1")}, nil)
+	assert.False(t, s.HasUndo())
+}
+
+func TestStorage_MarkModified(t *testing.T) {
+	var calls []string
+	undoCmd, _ := makeTestCommands(&calls)
+
+	s := NewStorage()
+	s.MarkModified("map")
+	assert.True(t, s.IsModified("map"))
+
+	s.PushV("map", undoCmd("1"))
+	s.UndoV("map")
+	assert.True(t, s.IsModified("map"), "should stay modified without commands")
+
+	s.ForceBalance("map")
+	assert.False(t, s.IsModified("map"))
+
+	s.MarkModified(NullSpaceStackId)
+	assert.False(t, s.IsModified(NullSpaceStackId))
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+
+	"sdmm/internal/app/ui/dialog"
+	"sdmm/internal/dmapi/dmmap/dmmdata"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (a *app) compareWithFile(path string) {
+	data, err := dmmdata.New(path)
+	if err != nil {
+		log.Printf("unable to open map to compare [%s]: %v", path, err)
+		dialog.Open(dialog.TypeInformation{
+			Title:       "Error: Unable to compare",
+			Information: fmt.Sprintf("Error while parsing the map:\n - %s\n - %s", path, err),
+		})
+		return
+	}
+
+	if e := a.CurrentEditor(); e != nil {
+		e.CompareWith(path, data)
+	}
+}
+
+// Compares the active map with its version from the git revision.
+// The git executable is expected to be available in the PATH.
+func (a *app) compareWithRevision(revision string) {
+	e := a.CurrentEditor()
+	if e == nil {
+		return
+	}
+
+	mapPath := e.Dmm().Path.Absolute
+	spec := revision + ":./" + filepath.Base(mapPath)
+
+	log.Print("reading map from git:", spec)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", "show", spec)
+	cmd.Dir = filepath.Dir(mapPath)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		log.Printf("unable to read map from git [%s]: %v, %s", spec, err, stderr.String())
+		dialog.Open(dialog.TypeInformation{
+			Title:       "Error: Unable to compare",
+			Information: fmt.Sprintf("Error while reading the map from git:\n - %s\n - %s", spec, stderr.String()),
+		})
+		return
+	}
+
+	data, err := dmmdata.Parse(mapPath, stdout.Bytes())
+	if err != nil {
+		log.Printf("unable to parse map from git [%s]: %v", spec, err)
+		dialog.Open(dialog.TypeInformation{
+			Title:       "Error: Unable to compare",
+			Information: fmt.Sprintf("Error while parsing the map:\n - %s\n - %s", spec, err),
+		})
+		return
+	}
+
+	e.CompareWith(revision, data)
+}
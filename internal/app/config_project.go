@@ -19,6 +19,15 @@ type projectConfig struct {
 
 	Projects []string
 	Maps     []string
+
+	// SaveHooks stores user answers to run save hooks by environment paths.
+	SaveHooks map[string]saveHooksApproval
+}
+
+// Hooks come from the project repository, so the answer is valid only for the exact list of hooks.
+type saveHooksApproval struct {
+	Hash     string
+	Approved bool
 }
 
 func (projectConfig) Name() string {
@@ -77,6 +86,24 @@ func (cfg *projectConfig) RemoveMap(mapPath string) {
 	log.Print("removed map:", mapPath)
 }
 
+// SaveHooksApproval returns the user answer to run save hooks of the environment.
+// The answered is false, if the user wasn't asked about hooks with the provided hash yet.
+func (cfg *projectConfig) SaveHooksApproval(envPath, hash string) (approved, answered bool) {
+	approval, ok := cfg.SaveHooks[envPath]
+	if !ok || approval.Hash != hash {
+		return false, false
+	}
+	return approval.Approved, true
+}
+
+func (cfg *projectConfig) SetSaveHooksApproval(envPath, hash string, approved bool) {
+	if cfg.SaveHooks == nil {
+		cfg.SaveHooks = make(map[string]saveHooksApproval)
+	}
+	cfg.SaveHooks[envPath] = saveHooksApproval{Hash: hash, Approved: approved}
+	log.Printf("save hooks approval set [%s]: %t", envPath, approved)
+}
+
 func (a *app) loadProjectConfig() {
 	config := &projectConfig{
 		Version: projectConfigVersion,
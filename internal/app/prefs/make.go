@@ -11,9 +11,15 @@ type App interface {
 	UpdateScale()
 }
 
-func Make(app App, prefs *Prefs) wsprefs.Prefs {
+// Make creates preferences to show in the editor.
+// Values set in the project are shown as read only, project could be nil if there is no one.
+func Make(app App, prefs *Prefs, project *Project) wsprefs.Prefs {
 	p := wsprefs.MakePrefs()
 
+	if project == nil {
+		project = &Project{}
+	}
+
 	var preferencesPrefabs = map[wsprefs.PrefGroup][]prefPrefab{
 		wsprefs.GPEditor: {
 			optionPrefPrefab{
@@ -23,6 +29,7 @@ func Make(app App, prefs *Prefs) wsprefs.Prefs {
 				value:   &prefs.Editor.SaveFormat,
 				options: SaveFormats,
 				help:    SaveFormatHelp,
+				project: project.SaveFormat,
 			},
 			optionPrefPrefab{
 				name:    "Code Editor",
@@ -33,10 +40,11 @@ func Make(app App, prefs *Prefs) wsprefs.Prefs {
 				help:    CodeEditorHelp,
 			},
 			boolPrefPrefab{
-				name:  "Sanitize Variables",
-				desc:  "Enables sanitizing for variables which are declared on the map, but has the same value as initial.",
-				label: "##sanitize_variables",
-				value: &prefs.Editor.SanitizeVariables,
+				name:    "Sanitize Variables",
+				desc:    "Enables sanitizing for variables which are declared on the map, but has the same value as initial.",
+				label:   "##sanitize_variables",
+				value:   &prefs.Editor.SanitizeVariables,
+				project: project.SanitizeVariables,
 			},
 			optionPrefPrefab{
 				name:    "Nudge Mode",
@@ -46,22 +54,25 @@ func Make(app App, prefs *Prefs) wsprefs.Prefs {
 				options: SaveNudgeModes,
 			},
 			stringPrefPrefab{
-				name:  "Obsolete Object Path",
-				desc:  "Type path to use when replacing missing /obj and /mob types. Leave empty to discard.",
-				label: "##obsolete_obj_path",
-				value: &prefs.Editor.ObsoleteObjectPath,
+				name:    "Obsolete Object Path",
+				desc:    "Type path to use when replacing missing /obj and /mob types. Leave empty to discard.",
+				label:   "##obsolete_obj_path",
+				value:   &prefs.Editor.ObsoleteObjectPath,
+				project: project.ObsoleteObjectPath,
 			},
 			stringPrefPrefab{
-				name:  "Obsolete Turf Path",
-				desc:  "Type path to use when replacing missing /turf types. Leave empty to discard.",
-				label: "##obsolete_turf_path",
-				value: &prefs.Editor.ObsoleteTurfPath,
+				name:    "Obsolete Turf Path",
+				desc:    "Type path to use when replacing missing /turf types. Leave empty to discard.",
+				label:   "##obsolete_turf_path",
+				value:   &prefs.Editor.ObsoleteTurfPath,
+				project: project.ObsoleteTurfPath,
 			},
 			stringPrefPrefab{
-				name:  "Obsolete Area Path",
-				desc:  "Type path to use when replacing missing /area types. Leave empty to discard.",
-				label: "##obsolete_area_path",
-				value: &prefs.Editor.ObsoleteAreaPath,
+				name:    "Obsolete Area Path",
+				desc:    "Type path to use when replacing missing /area types. Leave empty to discard.",
+				label:   "##obsolete_area_path",
+				value:   &prefs.Editor.ObsoleteAreaPath,
+				project: project.ObsoleteAreaPath,
 			},
 		},
 
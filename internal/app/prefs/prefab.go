@@ -10,6 +10,9 @@ type prefPrefab interface {
 	make() any
 }
 
+// Preferences set by the project are shown with the project value and can't be changed in the editor.
+const projectNote = "Set by the project in the " + ProjectFileName + " file."
+
 type intPrefPrefab struct {
 	name  string
 	desc  string
@@ -50,6 +53,7 @@ type optionPrefPrefab struct {
 	post    func(string)
 	options []string
 	help    string
+	project *string
 }
 
 func (p optionPrefPrefab) make() any {
@@ -72,15 +76,25 @@ func (p optionPrefPrefab) make() any {
 	pref.Options = p.options
 	pref.Help = p.help
 
+	if p.project != nil {
+		pref.FGet = func() string {
+			return *p.project
+		}
+		pref.FSet = func(string) {}
+		pref.ReadOnly = true
+		pref.Note = projectNote
+	}
+
 	return pref
 }
 
 type boolPrefPrefab struct {
-	name  string
-	desc  string
-	label string
-	value *bool
-	post  func(bool)
+	name    string
+	desc    string
+	label   string
+	value   *bool
+	post    func(bool)
+	project *bool
 }
 
 func (p boolPrefPrefab) make() any {
@@ -100,15 +114,25 @@ func (p boolPrefPrefab) make() any {
 		}
 	}
 
+	if p.project != nil {
+		pref.FGet = func() bool {
+			return *p.project
+		}
+		pref.FSet = func(bool) {}
+		pref.ReadOnly = true
+		pref.Note = projectNote
+	}
+
 	return pref
 }
 
 type stringPrefPrefab struct {
-	name  string
-	desc  string
-	label string
-	value *string
-	post  func(string)
+	name    string
+	desc    string
+	label   string
+	value   *string
+	post    func(string)
+	project *string
 }
 
 func (p stringPrefPrefab) make() any {
@@ -128,5 +152,14 @@ func (p stringPrefPrefab) make() any {
 		}
 	}
 
+	if p.project != nil {
+		pref.FGet = func() string {
+			return *p.project
+		}
+		pref.FSet = func(string) {}
+		pref.ReadOnly = true
+		pref.Note = projectNote
+	}
+
 	return pref
 }
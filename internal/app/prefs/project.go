@@ -0,0 +1,135 @@
+package prefs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sdmm/internal/dmapi/dmmsmooth"
+	"sdmm/internal/util/slice"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ProjectFileName is a name of the project preferences file, which is placed next to the environment file.
+const ProjectFileName = ".reddmm.json"
+
+// Project stores preferences shared by all mappers of the project.
+// The file is meant to be checked into the repository, and every set value overrides the user preference.
+type Project struct {
+	SaveFormat         *string `json:"saveFormat,omitempty"`
+	SanitizeVariables  *bool   `json:"sanitizeVariables,omitempty"`
+	ObsoleteObjectPath *string `json:"obsoleteObjectPath,omitempty"`
+	ObsoleteTurfPath   *string `json:"obsoleteTurfPath,omitempty"`
+	ObsoleteAreaPath   *string `json:"obsoleteAreaPath,omitempty"`
+
+	// LintDisabledRules contains names of map checking rules which are not used for the project.
+	LintDisabledRules []string `json:"lintDisabledRules,omitempty"`
+	// PathsFilterPresets are shown in the View menu. The default one is applied when the environment is opened.
+	PathsFilterPresets []PathsFilterPreset `json:"pathsFilterPresets,omitempty"`
+	// SaveHooks are commands executed in the environment directory after the map is saved.
+	// Every hook is a program with its arguments. The saved map path is appended as the last argument.
+	SaveHooks [][]string `json:"saveHooks,omitempty"`
+	// MigrationRules are files or directories with path migration rules applied to maps on load.
+	// Paths are relative to the environment directory.
+	MigrationRules []string `json:"migrationRules,omitempty"`
+	// Smoothing configures the icon smoothing preview. The preview isn't available without it.
+	Smoothing *dmmsmooth.Config `json:"smoothing,omitempty"`
+}
+
+type PathsFilterPreset struct {
+	Name    string   `json:"name"`
+	Hidden  []string `json:"hidden"`
+	Default bool     `json:"default,omitempty"`
+}
+
+// ProjectFilePath returns a path of the project preferences file for the environment.
+func ProjectFilePath(dmePath string) string {
+	return filepath.Join(filepath.Dir(dmePath), ProjectFileName)
+}
+
+// LoadProject reads project preferences of the environment.
+// Returns nil without an error if the environment has no project preferences file.
+func LoadProject(dmePath string) (*Project, error) {
+	path := ProjectFilePath(dmePath)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Print("no project preferences:", path)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var project Project
+	if err = json.Unmarshal(data, &project); err != nil {
+		return nil, err
+	}
+	if project.SaveFormat != nil && !slice.StrContains(SaveFormats, *project.SaveFormat) {
+		return nil, fmt.Errorf("unknown save format [%s], expected one of: %v", *project.SaveFormat, SaveFormats)
+	}
+	for _, hook := range project.SaveHooks {
+		if len(hook) == 0 {
+			return nil, errors.New("save hook without a command")
+		}
+	}
+
+	if project.Smoothing != nil {
+		if err = project.Smoothing.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Print("project preferences loaded:", path)
+	return &project, nil
+}
+
+// SaveHooksHash returns a hash of save hooks. Any change of hook commands changes the hash.
+func (p *Project) SaveHooksHash() string {
+	data, _ := json.Marshal(p.SaveHooks)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// MigrationRulesPaths returns absolute paths of migration rules files of the environment.
+func (p *Project) MigrationRulesPaths(dmePath string) []string {
+	if p == nil {
+		return nil
+	}
+	paths := make([]string, 0, len(p.MigrationRules))
+	for _, path := range p.MigrationRules {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(dmePath), path)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// Apply returns user preferences with values overridden by the project.
+func (p *Project) Apply(prefs Prefs) Prefs {
+	if p == nil {
+		return prefs
+	}
+	if p.SaveFormat != nil {
+		prefs.Editor.SaveFormat = *p.SaveFormat
+	}
+	if p.SanitizeVariables != nil {
+		prefs.Editor.SanitizeVariables = *p.SanitizeVariables
+	}
+	if p.ObsoleteObjectPath != nil {
+		prefs.Editor.ObsoleteObjectPath = *p.ObsoleteObjectPath
+	}
+	if p.ObsoleteTurfPath != nil {
+		prefs.Editor.ObsoleteTurfPath = *p.ObsoleteTurfPath
+	}
+	if p.ObsoleteAreaPath != nil {
+		prefs.Editor.ObsoleteAreaPath = *p.ObsoleteAreaPath
+	}
+	return prefs
+}
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -21,6 +22,7 @@ import (
 	"sdmm/internal/dmapi/dmicon"
 	"sdmm/internal/dmapi/dmmap"
 	"sdmm/internal/dmapi/dmmap/dmmdata"
+	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
 	"sdmm/internal/util"
 
 	"github.com/SpaiR/imgui-go"
@@ -74,27 +76,7 @@ func (a *app) loadResourceV(path string, ws *workspace.Workspace) {
 
 // Goes through all parents starting from the current file location and look for a ".dme" file.
 func findEnvironmentFileFromBase(path string) (string, error) {
-	for {
-		dir := filepath.Dir(path)
-
-		if dir == path {
-			return "", fmt.Errorf("unable to find environment")
-		}
-
-		files, err := os.ReadDir(dir)
-		if err != nil {
-			log.Print("unable to read dir while looking for environment:", err)
-			return "", fmt.Errorf("unable to read dir: "+dir, err)
-		}
-
-		for _, file := range files {
-			if filepath.Ext(file.Name()) == ".dme" {
-				return filepath.Join(dir, file.Name()), nil
-			}
-		}
-
-		path = filepath.Dir(path)
-	}
+	return dmenv.FindFromBase(path)
 }
 
 func (a *app) loadEnvironment(path string) {
@@ -117,7 +99,8 @@ func (a *app) forceLoadEnvironment(path string, callback func()) {
 
 		a.projectConfig().AddProject(path)
 		a.loadedEnvironment = env
-		a.pathsFilter = newPathsFilter(env)
+		a.pathsFilter = a.newPathsFilter()
+		a.loadProjectPrefs(path)
 
 		dmicon.Cache.SetRootDirPath(env.RootDir)
 		dmmap.Init(env)
@@ -129,6 +112,10 @@ func (a *app) forceLoadEnvironment(path string, callback func()) {
 
 		log.Print("environment opened:", path)
 
+		a.checkMapJournals()
+		a.startEnvironmentWatcher(env)
+		a.startIconsWatcher()
+
 		if callback != nil {
 			callback()
 		}
@@ -142,7 +129,7 @@ func (a *app) forceLoadEnvironment(path string, callback func()) {
 		start := time.Now()
 		log.Printf("parsing environment: [%s]...", path)
 
-		env, err := dmenv.New(path)
+		env, cached, err := dmenv.NewCached(path, a.cacheDir)
 
 		if err != nil {
 			log.Print("unable to open environment by path:", path, err)
@@ -166,14 +153,35 @@ func (a *app) forceLoadEnvironment(path string, callback func()) {
 			return
 		}
 
-		log.Printf("environment [%s] parsed in [%d] ms", path, time.Since(start).Milliseconds())
+		log.Printf("environment [%s] parsed in [%d] ms, cached: [%t]", path, time.Since(start).Milliseconds(), cached)
 
 		window.RunLater(func() {
 			afterLoad(env)
+			if cached {
+				go a.confirmEnvironmentCache(path)
+			}
 		})
 	}()
 }
 
+// Parses the environment taken from the cache to ensure that the cache was actual.
+// Sources hash doesn't consider everything (e.g. files outside the environment directory),
+// so the environment is reloaded when the parsed one is different.
+func (a *app) confirmEnvironmentCache(path string) {
+	env, err := dmenv.UpdateCache(path, a.cacheDir)
+	if err != nil {
+		log.Printf("unable to confirm environment cache [%s]: %v", path, err)
+		return
+	}
+	if env == nil {
+		return
+	}
+
+	window.RunLater(func() {
+		a.reloadEnvironment(env)
+	})
+}
+
 func makeLoadingDialog(path string) dialog.Type {
 	start := time.Now()
 	return dialog.TypeCustom{
@@ -193,10 +201,17 @@ func makeLoadingDialog(path string) dialog.Type {
 	}
 }
 
-// Configure paths filter to access a newly opened environment.
-func newPathsFilter(env *dmenv.Dme) *dm.PathsFilter {
+// Configure paths filter to access the opened environment.
+// The environment is taken on every call, since it could be reloaded.
+func (a *app) newPathsFilter() *dm.PathsFilter {
 	return dm.NewPathsFilter(func(path string) []string {
-		return env.Objects[path].DirectChildren
+		if a.loadedEnvironment == nil {
+			return nil
+		}
+		if obj, ok := a.loadedEnvironment.Objects[path]; ok {
+			return obj.DirectChildren
+		}
+		return nil
 	})
 }
 
@@ -206,6 +221,11 @@ func (a *app) loadMap(path string, workspace *workspace.Workspace) {
 	start := time.Now()
 	log.Printf("parsing map: [%s]...", path)
 	data, err := dmmdata.New(path)
+	if errors.Is(err, dmmdata.ErrConflictMarkers) {
+		log.Printf("map [%s] has git conflict markers", path)
+		a.loadConflictedMap(path, workspace)
+		return
+	}
 	if err != nil {
 		log.Printf("unable to open map by path [%s]: %v", path, err)
 		dialog.Open(dialog.TypeInformation{
@@ -217,6 +237,28 @@ func (a *app) loadMap(path string, workspace *workspace.Workspace) {
 	elapsed := time.Since(start).Milliseconds()
 	log.Printf("map [%s] parsed in [%d] ms", path, elapsed)
 
+	a.addMapToRecent(path)
+
+	obsConfig := a.obsoleteConfig()
+
+	dmm, unknownPrefabs, migratedPaths := dmmap.NewWithObsoleteConfig(a.loadedEnvironment, data, a.backupMap(path), obsConfig)
+	if a.layout.WsArea.OpenMap(dmm, workspace) {
+		// Migrated prefabs differ from the map file, so the map should be saved to keep them.
+		if len(migratedPaths) != 0 {
+			a.CommandStorage().MarkModified(dmm.Path.Absolute)
+		}
+		a.layout.Prefabs.Sync()
+		showUnknownPrefabs(dmm, unknownPrefabs, migratedPaths, obsConfig)
+	}
+	a.layout.Search.Free()
+	a.layout.Problems.Free()
+
+	runtime.GC()
+
+	log.Print("map opened:", path)
+}
+
+func (a *app) addMapToRecent(path string) {
 	// Add map to the recent only if it is a part of the currently opened environment.
 	if slice.StrContains(a.AvailableMaps(), path) {
 		log.Print("adding map path to the recent:", path)
@@ -225,64 +267,81 @@ func (a *app) loadMap(path string, workspace *workspace.Workspace) {
 	} else {
 		log.Print("ignoring map path add to the recent, since it's an outside resource")
 	}
+}
 
-	// Create obsolete config from preferences
-	obsConfig := dmmap.ObsoleteConfig{
-		ObjectPath: a.preferenceConfig.Prefs.Editor.ObsoleteObjectPath,
-		TurfPath:   a.preferenceConfig.Prefs.Editor.ObsoleteTurfPath,
-		AreaPath:   a.preferenceConfig.Prefs.Editor.ObsoleteAreaPath,
+// Create obsolete config from preferences
+func (a *app) obsoleteConfig() dmmap.ObsoleteConfig {
+	return dmmap.ObsoleteConfig{
+		ObjectPath: a.Prefs().Editor.ObsoleteObjectPath,
+		TurfPath:   a.Prefs().Editor.ObsoleteTurfPath,
+		AreaPath:   a.Prefs().Editor.ObsoleteAreaPath,
+		Migrations: a.migrationRules,
 	}
+}
 
-	dmm, unknownPrefabs := dmmap.NewWithObsoleteConfig(a.loadedEnvironment, data, a.backupMap(path), obsConfig)
-	if a.layout.WsArea.OpenMap(dmm, workspace) {
-		a.layout.Prefabs.Sync()
+// Show info about unknown prefabs that were replaced or discarded and prefabs changed by migration rules
+func showUnknownPrefabs(dmm *dmmap.Dmm, unknownPrefabs map[string]*dmmprefab.Prefab, migratedPaths []string, obsConfig dmmap.ObsoleteConfig) {
+	if len(unknownPrefabs) == 0 && len(migratedPaths) == 0 {
+		return
+	}
 
-		// Show info about unknown prefabs that were replaced or discarded
-		if len(unknownPrefabs) != 0 {
-			// Collect keys
-			var prefabPaths []string
-			for path := range unknownPrefabs {
-				prefabPaths = append(prefabPaths, path)
-			}
+	// Collect keys
+	var prefabPaths []string
+	for path := range unknownPrefabs {
+		prefabPaths = append(prefabPaths, path)
+	}
 
-			// Sort them alphabetically
-			sort.Strings(prefabPaths)
+	// Sort them alphabetically
+	sort.Strings(prefabPaths)
 
-			// Build the string
-			var prefabsNames string
-			for _, path := range prefabPaths {
-				prefabsNames += " - " + path + "\n"
-			}
+	// Build the string
+	var prefabsNames string
+	for _, path := range prefabPaths {
+		prefabsNames += " - " + path + "\n"
+	}
 
-			// Check if obsolete replacement is configured
-			hasReplacement := obsConfig.ObjectPath != "" || obsConfig.TurfPath != "" || obsConfig.AreaPath != ""
-			var infoMsg string
-			if hasReplacement {
-				infoMsg = fmt.Sprintf(
-					"Unknown types on the map: %s\n"+
-						"Types below have been replaced with obsolete placeholders:\n"+
-						"%s\n"+
-						"Use the 'View Obsolete' and 'Replace Obsolete' tools to inspect and fix them.", dmm.Name, prefabsNames,
-				)
-			} else {
-				infoMsg = fmt.Sprintf(
-					"There are unknown types on the map: %s\n"+
-						"Types below will be discarded on save:\n"+
-						"%s", dmm.Name, prefabsNames,
-				)
-			}
+	// Check if obsolete replacement is configured
+	hasReplacement := obsConfig.ObjectPath != "" || obsConfig.TurfPath != "" || obsConfig.AreaPath != ""
+	var infoMsg string
+	if len(prefabPaths) != 0 && hasReplacement {
+		infoMsg = fmt.Sprintf(
+			"Unknown types on the map: %s\n"+
+				"Types below have been replaced with obsolete placeholders:\n"+
+				"%s\n"+
+				"Use the 'View Obsolete' and 'Replace Obsolete' tools to inspect and fix them.", dmm.Name, prefabsNames,
+		)
+	} else if len(prefabPaths) != 0 {
+		infoMsg = fmt.Sprintf(
+			"There are unknown types on the map: %s\n"+
+				"Types below will be discarded on save:\n"+
+				"%s", dmm.Name, prefabsNames,
+		)
+	}
 
-			dialog.Open(dialog.TypeInformation{
-				Title:       "Unknown Types",
-				Information: infoMsg,
-			})
+	title := "Unknown Types"
+	if len(migratedPaths) != 0 {
+		if len(prefabPaths) == 0 {
+			title = "Migrated Types"
+		} else {
+			infoMsg += "\n\n"
 		}
-	}
-	a.layout.Search.Free()
 
-	runtime.GC()
+		var migratedNames string
+		for _, path := range migratedPaths {
+			migratedNames += " - " + path + "\n"
+		}
+		infoMsg += fmt.Sprintf(
+			"Migrated types on the map: %s\n"+
+				"Types below have been updated by migration rules:\n"+
+				"%s\n"+
+				"The map is marked as modified, save it to keep the changes.", dmm.Name, migratedNames,
+		)
+	}
 
-	log.Print("map opened:", path)
+	dialog.Open(dialog.TypeInformation{
+		Title:       title,
+		Information: infoMsg,
+	})
 }
 
 func (a *app) closeEnvironment(callback func(bool)) {
@@ -299,10 +358,16 @@ func (a *app) closeEnvironment(callback func(bool)) {
 func (a *app) freeEnvironmentResources() {
 	log.Print("free environment resources...")
 
+	a.stopEnvironmentWatcher()
+	a.stopIconsWatcher()
+
 	a.pathsFilter = dm.NewPathsFilterEmpty()
+	a.projectPrefs = nil
+	a.migrationRules = nil
 
 	a.layout.Prefabs.Free()
 	a.layout.Search.Free()
+	a.layout.Problems.Free()
 	a.layout.Environment.Free()
 	a.layout.WsArea.Free()
 	a.layout.VarEditor.Free()
@@ -336,14 +401,7 @@ func (a *app) backupMap(path string) string {
 		os.Exit(1)
 	}
 
-	// format: backup/environment.dme/map.dmm/time.dmm
-	dst := filepath.FromSlash(a.backupDir + "/" +
-		a.environmentName() + "/" +
-		filepath.Base(path) + "/" +
-		time.Now().Format(util.TimeFormat) + ".dmm",
-	)
-
-	_ = os.MkdirAll(filepath.Dir(dst), os.ModePerm)
+	dst := a.mapBackupPath(path)
 
 	err = os.WriteFile(dst, data, os.ModePerm)
 	if err != nil {
@@ -355,3 +413,28 @@ func (a *app) backupMap(path string) string {
 
 	return dst
 }
+
+// MapHistoryPath returns a path to store the undo history of the map between sessions.
+func (a *app) MapHistoryPath(path string) string {
+	// format: history/environment.dme/map.dmm_hash.json
+	return filepath.FromSlash(fmt.Sprintf("%s/%s/%s_%x.json",
+		a.historyDir,
+		a.environmentName(),
+		filepath.Base(path),
+		util.Djb2(path),
+	))
+}
+
+// Returns a path to store a new backup of the map. Directories for the path are created as well.
+func (a *app) mapBackupPath(path string) string {
+	// format: backup/environment.dme/map.dmm/time.dmm
+	dst := filepath.FromSlash(a.backupDir + "/" +
+		a.environmentName() + "/" +
+		filepath.Base(path) + "/" +
+		time.Now().Format(util.TimeFormat) + ".dmm",
+	)
+
+	_ = os.MkdirAll(filepath.Dir(dst), os.ModePerm)
+
+	return dst
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+
+	"sdmm/internal/app/ui/cpwsarea/workspace"
+	"sdmm/internal/app/ui/dialog"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/dmapi/dmmap/dmmdata"
+	"sdmm/internal/dmapi/dmmmerge"
+	"sdmm/internal/util/slice"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Opens the map with git conflict markers in a workspace to resolve conflicts.
+// Versions of the map are restored from the file and merged, so only tiles changed by both sides are conflicts.
+func (a *app) loadConflictedMap(path string, workspace *workspace.Workspace) {
+	conflicted, err := dmmdata.NewConflicted(path)
+	if err != nil {
+		log.Printf("unable to restore conflicted map [%s]: %v", path, err)
+		dialog.Open(dialog.TypeInformation{
+			Title:       "Error: Unable to open map",
+			Information: fmt.Sprintf("Error while restoring the map from git conflict markers:\n - %s\n - %s", path, err),
+		})
+		return
+	}
+
+	result, err := dmmmerge.Merge(conflicted.Base, conflicted.Ours, conflicted.Theirs)
+	if err != nil {
+		log.Printf("unable to merge conflicted map [%s]: %v", path, err)
+		dialog.Open(dialog.TypeInformation{
+			Title:       "Error: Unable to open map",
+			Information: fmt.Sprintf("Error while merging versions of the map:\n - %s\n - %s", path, err),
+		})
+		return
+	}
+
+	log.Printf("map [%s] restored with [%d] conflicts", path, len(result.Conflicts))
+
+	// The file itself can't be used as a backup, since it's not a valid map. Our version is used instead.
+	backup := *conflicted.Ours
+	backup.Filepath = a.mapBackupPath(path)
+	if err = backup.Save(); err != nil {
+		log.Printf("unable to write map backup [%s]: %v", backup.Filepath, err)
+		dialog.Open(dialog.TypeInformation{
+			Title:       "Error: Unable to open map",
+			Information: fmt.Sprintf("Error while creating the map backup:\n - %s\n - %s", backup.Filepath, err),
+		})
+		return
+	}
+
+	a.addMapToRecent(path)
+
+	obsConfig := a.obsoleteConfig()
+
+	ours, unknownPrefabs, migratedPaths := dmmap.NewWithObsoleteConfig(a.loadedEnvironment, conflicted.Ours, backup.Filepath, obsConfig)
+	theirs, theirsUnknownPrefabs, theirsMigratedPaths := dmmap.NewWithObsoleteConfig(a.loadedEnvironment, conflicted.Theirs, backup.Filepath, obsConfig)
+	for unknownPath, prefab := range theirsUnknownPrefabs {
+		unknownPrefabs[unknownPath] = prefab
+	}
+	for _, migratedPath := range theirsMigratedPaths {
+		if !slice.StrContains(migratedPaths, migratedPath) {
+			migratedPaths = append(migratedPaths, migratedPath)
+		}
+	}
+	sort.Strings(migratedPaths)
+
+	// The merged map starts from our version with non-conflicting changes of their version.
+	merged := ours.Copy()
+	dmm := &merged
+	if dmm.MaxX != result.MaxX || dmm.MaxY != result.MaxY || dmm.MaxZ != result.MaxZ {
+		dmm.SetMapSize(result.MaxX, result.MaxY, result.MaxZ)
+	}
+	for coord, prefabs := range result.Tiles {
+		ourPrefabs := conflicted.Ours.Dictionary[conflicted.Ours.Grid[coord]]
+		if !prefabs.Equals(ourPrefabs) && theirs.HasTile(coord) {
+			dmm.GetTile(coord).InstancesSet(theirs.GetTile(coord).Instances().Prefabs())
+		}
+	}
+
+	if a.layout.WsArea.OpenConflict(dmm, ours, theirs, result.Conflicts, workspace) {
+		a.layout.Prefabs.Sync()
+		showUnknownPrefabs(dmm, unknownPrefabs, migratedPaths, obsConfig)
+	}
+	a.layout.Search.Free()
+	a.layout.Problems.Free()
+
+	runtime.GC()
+
+	log.Print("conflicted map opened:", path)
+}
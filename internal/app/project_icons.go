@@ -0,0 +1,70 @@
+package app
+
+import (
+	"time"
+
+	"sdmm/internal/app/window"
+	"sdmm/internal/dmapi/dmicon"
+	"sdmm/internal/util/fswatch"
+
+	"github.com/rs/zerolog/log"
+)
+
+const iconsWatchInterval = time.Second
+
+// Only icons requested from the cache are watched, since there is no need to reload icons which aren't used.
+// Icons which failed to load are watched too, so they are loaded when fixed or created, if they were missing.
+// Icons are added to the cache when they are requested, which isn't a change of their files, so it's not reported.
+func (a *app) startIconsWatcher() {
+	a.stopIconsWatcher()
+
+	a.iconsWatcher = fswatch.NewV(iconsWatchInterval, dmicon.Cache.IconPaths, func(changed []string) {
+		var icons []string
+		for _, path := range changed {
+			if icon, ok := dmicon.Cache.IconByPath(path); ok {
+				icons = append(icons, icon)
+			}
+		}
+
+		log.Printf("icons changed: %v", icons)
+
+		window.RunLater(func() {
+			a.reloadIcons(icons)
+		})
+	}, false)
+
+	log.Print("icons watcher started")
+}
+
+func (a *app) stopIconsWatcher() {
+	if a.iconsWatcher != nil {
+		a.iconsWatcher.Stop()
+		a.iconsWatcher = nil
+		log.Print("icons watcher stopped")
+	}
+}
+
+// Loads icons again and updates everything which shows them.
+// Should be called in the main thread, so old textures aren't used after they are deleted.
+func (a *app) reloadIcons(icons []string) {
+	if len(icons) == 0 || !a.HasLoadedEnvironment() {
+		return
+	}
+
+	log.Printf("reloading [%d] icons...", len(icons))
+
+	dmicon.Cache.Reload(icons)
+	a.refreshIcons(icons)
+
+	log.Print("icons reloaded")
+}
+
+// Updates everything which shows the provided icons, so actual sprites are taken from the cache.
+func (a *app) refreshIcons(icons []string) {
+	for _, mapEditor := range a.layout.WsArea.MapEditors() {
+		mapEditor.UpdateCanvasByIcons(icons)
+	}
+
+	a.layout.Environment.ResetIcons()
+	a.layout.Prefabs.Sync()
+}
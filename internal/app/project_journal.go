@@ -0,0 +1,123 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sdmm/internal/app/ui/dialog"
+	"sdmm/internal/dmapi/dmmsnap"
+	"sdmm/internal/util"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	journalExt  = ".jsonl"
+	recoveryExt = ".recover"
+)
+
+// MapJournalPath returns a path of the journal, where changes of the opened map are written.
+// Every editor process has its own journal, since the same map could be opened by a few editors.
+func (a *app) MapJournalPath(path string) string {
+	// format: journal/environment.dme/map.dmm_hash_pid.jsonl
+	return filepath.FromSlash(fmt.Sprintf("%s/%s/%s_%x_%d%s",
+		a.journalDir,
+		a.environmentName(),
+		filepath.Base(path),
+		util.Djb2(path),
+		os.Getpid(),
+		journalExt,
+	))
+}
+
+// Journals of maps are deleted when maps are closed.
+// So if there are journals for the opened environment, then the editor wasn't closed properly.
+// Such journals are kept aside as recovery files, so they don't get in the way of new journals.
+// Journals locked by editors, which are still running with the same environment, are left to them.
+func (a *app) checkMapJournals() {
+	envJournalDir := filepath.Join(a.journalDir, a.environmentName())
+
+	journals, _ := filepath.Glob(filepath.Join(envJournalDir, "*"+journalExt))
+	for _, journal := range journals {
+		if dmmsnap.JournalInUse(journal) {
+			log.Print("journal is used by the running editor:", journal)
+			continue
+		}
+
+		recovery := strings.TrimSuffix(journal, journalExt) + recoveryExt
+		if err := os.Rename(journal, recovery); err != nil {
+			log.Printf("unable to move journal [%s]: %v", journal, err)
+		}
+	}
+
+	type recoverable struct {
+		path, mapPath string
+	}
+
+	var (
+		toRecover []recoverable
+		mapsNames string
+	)
+
+	recoveries, _ := filepath.Glob(filepath.Join(envJournalDir, "*"+recoveryExt))
+	for _, recovery := range recoveries {
+		mapPath, changes, err := dmmsnap.JournalInfo(recovery)
+		if err != nil || changes == 0 {
+			log.Printf("nothing to recover [%s]: %v", recovery, err)
+			_ = os.Remove(recovery)
+			continue
+		}
+		toRecover = append(toRecover, recoverable{path: recovery, mapPath: mapPath})
+		mapsNames += fmt.Sprintf(" - %s (changes: %d)\n", mapPath, changes)
+	}
+
+	if len(toRecover) == 0 {
+		return
+	}
+
+	log.Print("unsaved changes to recover:", len(toRecover))
+
+	dialog.Open(dialog.TypeConfirmation{
+		Title: "Recover Unsaved Changes?",
+		Question: fmt.Sprintf("The editor wasn't closed properly. Maps below have unsaved changes:\n%s"+
+			"Restore them?", mapsNames),
+		ActionYes: func() {
+			for _, r := range toRecover {
+				a.recoverMap(r.path, r.mapPath)
+			}
+		},
+		ActionNo: func() {
+			for _, r := range toRecover {
+				_ = os.Remove(r.path)
+			}
+		},
+	})
+}
+
+// Opens the map and replays changes from the recovery journal on top of it.
+func (a *app) recoverMap(recoveryPath, mapPath string) {
+	log.Printf("recovering map [%s] from [%s]...", mapPath, recoveryPath)
+
+	defer os.Remove(recoveryPath)
+
+	a.loadMap(mapPath, nil)
+
+	ed, ok := a.layout.WsArea.FindMapEditor(mapPath)
+	if !ok {
+		log.Print("unable to recover not opened map:", mapPath)
+		return
+	}
+
+	if err := ed.RecoverJournal(recoveryPath); err != nil {
+		log.Printf("unable to recover map [%s]: %v", mapPath, err)
+		dialog.Open(dialog.TypeInformation{
+			Title:       "Error: Unable to recover unsaved changes",
+			Information: fmt.Sprintf("Error while recovering unsaved changes:\n - %s\n - %s", mapPath, err),
+		})
+		return
+	}
+
+	log.Print("map recovered:", mapPath)
+}
@@ -0,0 +1,172 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sdmm/internal/app/prefs"
+	"sdmm/internal/app/ui/dialog"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/dmapi/dmmmigrate"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Reads project preferences placed next to the environment file.
+// Broken preferences are reported to the user and ignored, so the environment could still be opened.
+func (a *app) loadProjectPrefs(dmePath string) {
+	project, err := prefs.LoadProject(dmePath)
+	if err != nil {
+		log.Printf("unable to load project preferences [%s]: %v", dmePath, err)
+		dialog.Open(dialog.TypeInformation{
+			Title:       "Error: Unable to load project preferences",
+			Information: fmt.Sprintf("Error while reading project preferences:\n - %s\n - %s", prefs.ProjectFilePath(dmePath), err),
+		})
+	}
+
+	a.projectPrefs = project
+	a.loadMigrationRules(dmePath)
+
+	if project != nil && project.Smoothing != nil {
+		dmmap.Smoothing = *project.Smoothing
+	}
+
+	for _, preset := range a.PathsFilterPresets() {
+		if preset.Default {
+			a.DoApplyPathsFilterPreset(preset)
+			break
+		}
+	}
+}
+
+// Reads path migration rules listed in the project preferences.
+func (a *app) loadMigrationRules(dmePath string) {
+	a.migrationRules = nil
+
+	paths := a.projectPrefs.MigrationRulesPaths(dmePath)
+	if len(paths) == 0 {
+		return
+	}
+
+	rules, err := dmmmigrate.Load(paths...)
+	if err != nil {
+		log.Printf("unable to load migration rules %v: %v", paths, err)
+		dialog.Open(dialog.TypeInformation{
+			Title:       "Error: Unable to load migration rules",
+			Information: fmt.Sprintf("Error while reading migration rules:\n - %s\n - %s", strings.Join(paths, ", "), err),
+		})
+		return
+	}
+
+	a.migrationRules = rules
+	log.Printf("migration rules loaded: %d", len(rules))
+}
+
+// PathsFilterPresets returns presets of the paths filter from the project preferences.
+func (a *app) PathsFilterPresets() []prefs.PathsFilterPreset {
+	if a.projectPrefs == nil {
+		return nil
+	}
+	return a.projectPrefs.PathsFilterPresets
+}
+
+// RunSaveHooks runs commands from the project preferences after the map is saved.
+// Hooks come from the project repository, so they run only when the user approved the exact list of them.
+func (a *app) RunSaveHooks(mapPath string) {
+	if a.projectPrefs == nil || len(a.projectPrefs.SaveHooks) == 0 {
+		return
+	}
+
+	envPath := a.loadedEnvironment.RootFile
+	hash := a.projectPrefs.SaveHooksHash()
+
+	approved, answered := a.projectConfig().SaveHooksApproval(envPath, hash)
+	if !answered {
+		a.askSaveHooksApproval(envPath, hash, mapPath)
+		return
+	}
+	if !approved {
+		log.Printf("save hooks skipped, not approved for the environment: %s", envPath)
+		return
+	}
+
+	a.runSaveHooks(a.projectPrefs.SaveHooks, a.loadedEnvironment.RootDir, mapPath)
+}
+
+// Asks the user once for every list of hooks. Maps saved while the question is open wait for the answer.
+func (a *app) askSaveHooksApproval(envPath, hash, mapPath string) {
+	a.pendingSaveHooks = append(a.pendingSaveHooks, mapPath)
+	if len(a.pendingSaveHooks) > 1 {
+		return
+	}
+
+	hooks, dir := a.projectPrefs.SaveHooks, a.loadedEnvironment.RootDir
+
+	commands := make([]string, 0, len(hooks))
+	for _, hook := range hooks {
+		commands = append(commands, " - "+saveHookCommandLine(hook))
+	}
+
+	log.Printf("asking for save hooks approval [%s]: %v", envPath, hooks)
+
+	dialog.Open(dialog.TypeConfirmation{
+		Title: "Save Hooks",
+		Question: fmt.Sprintf("The project preferences run commands after the map is saved:\n%s\n\n"+
+			"Commands are executed in [%s] with the saved map path as the last argument.\n"+
+			"Allow to run them? The answer is remembered until the commands are changed.",
+			strings.Join(commands, "\n"), dir),
+		ActionYes: func() {
+			a.projectConfig().SetSaveHooksApproval(envPath, hash, true)
+			for _, path := range a.pendingSaveHooks {
+				a.runSaveHooks(hooks, dir, path)
+			}
+			a.pendingSaveHooks = nil
+		},
+		ActionNo: func() {
+			a.projectConfig().SetSaveHooksApproval(envPath, hash, false)
+			log.Printf("save hooks skipped, not approved for the environment: %s", envPath)
+			a.pendingSaveHooks = nil
+		},
+	})
+}
+
+// Hooks are executed one by one in the provided directory and stop on the first failure.
+func (a *app) runSaveHooks(hooks [][]string, dir, mapPath string) {
+	for _, hook := range hooks {
+		log.Printf("running save hook: %v", hook)
+
+		var output bytes.Buffer
+		cmd := exec.Command(hook[0], append(hook[1:], mapPath)...)
+		cmd.Dir = dir
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+
+		if err := cmd.Run(); err != nil {
+			log.Printf("save hook failed [%v]: %v, %s", hook, err, output.String())
+			dialog.Open(dialog.TypeInformation{
+				Title: "Error: Save hook failed",
+				Information: fmt.Sprintf("Error while running the save hook:\n - %s\n - %s\n%s",
+					saveHookCommandLine(hook), err, output.String()),
+			})
+			return
+		}
+	}
+
+	log.Printf("save hooks finished: %s", filepath.Base(mapPath))
+}
+
+// Returns the hook as it would be typed in the shell. Arguments with spaces or quotes are quoted.
+func saveHookCommandLine(hook []string) string {
+	args := make([]string, 0, len(hook))
+	for _, arg := range hook {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'") {
+			arg = strconv.Quote(arg)
+		}
+		args = append(args, arg)
+	}
+	return strings.Join(args, " ")
+}
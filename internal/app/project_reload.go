@@ -0,0 +1,93 @@
+package app
+
+import (
+	"time"
+
+	"sdmm/internal/app/window"
+	"sdmm/internal/dmapi/dmenv"
+	"sdmm/internal/dmapi/dmicon"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/util/fswatch"
+
+	"github.com/rs/zerolog/log"
+)
+
+const envWatchInterval = 2 * time.Second
+
+// Code files of the opened environment are watched, so the environment is reloaded when they are changed.
+// Opened maps are kept with their undo history, only types removed from the code are replaced on them.
+func (a *app) startEnvironmentWatcher(env *dmenv.Dme) {
+	a.stopEnvironmentWatcher()
+
+	files := func() []string {
+		paths, err := dmenv.SourceFiles(env.RootDir)
+		if err != nil {
+			log.Printf("unable to collect environment sources [%s]: %v", env.RootDir, err)
+		}
+		return paths
+	}
+
+	// The callback is called from the watcher goroutine, so the parsing doesn't block the editor.
+	// Next changes won't be reported until the current parsing is finished.
+	a.envWatcher = fswatch.New(envWatchInterval, files, func(changed []string) {
+		log.Printf("environment sources changed: %v", changed)
+		a.parseChangedEnvironment(env.RootFile)
+	})
+
+	log.Print("environment watcher started:", env.RootDir)
+}
+
+func (a *app) stopEnvironmentWatcher() {
+	if a.envWatcher != nil {
+		a.envWatcher.Stop()
+		a.envWatcher = nil
+		log.Print("environment watcher stopped")
+	}
+}
+
+func (a *app) parseChangedEnvironment(path string) {
+	start := time.Now()
+	log.Printf("parsing changed environment: [%s]...", path)
+
+	// Sources could be saved in the middle of editing, so parser errors are expected and only logged.
+	env, _, err := dmenv.NewCached(path, a.cacheDir)
+	if err != nil {
+		log.Printf("unable to parse changed environment [%s]: %v", path, err)
+		return
+	}
+
+	log.Printf("changed environment [%s] parsed in [%d] ms", path, time.Since(start).Milliseconds())
+
+	window.RunLater(func() {
+		a.reloadEnvironment(env)
+	})
+}
+
+// Swaps the opened environment with the provided one in place.
+// Does nothing if the environment was closed or replaced with a different one while it was parsed.
+func (a *app) reloadEnvironment(env *dmenv.Dme) {
+	if a.loadedEnvironment == nil || a.loadedEnvironment.RootFile != env.RootFile {
+		log.Print("skipping reload of not opened environment:", env.RootFile)
+		return
+	}
+
+	log.Print("reloading environment:", env.RootFile)
+
+	a.loadedEnvironment = env
+
+	dmicon.Cache.SetRootDirPath(env.RootDir)
+	dmmap.Reload(env)
+
+	obsConfig := a.obsoleteConfig()
+	for _, mapEditor := range a.layout.WsArea.MapEditors() {
+		mapEditor.ReloadEnvironment(env, obsConfig)
+	}
+
+	a.layout.Environment.Free()
+	a.layout.Search.Free()
+	a.layout.Problems.Free()
+	a.layout.Prefabs.Sync()
+	a.layout.VarEditor.Sync()
+
+	log.Print("environment reloaded:", env.RootFile)
+}
@@ -1,5 +1,7 @@
 package brush
 
+import "sdmm/internal/dmapi/dm"
+
 type modeType int
 
 const (
@@ -16,23 +18,29 @@ type Batching struct {
 	idx     uint32
 	indices []uint32
 
-	texture uint32
-	len     int32
-	offset  int
+	texture     uint32
+	smooth      bool
+	colorMatrix *dm.ColorMatrix
+	len         int32
+	offset      int
 }
 
 func (b *Batching) flush() {
 	if b.len != 0 && len(b.indices) > 0 {
 		b.calls = append(b.calls, batchCall{
-			texture: b.texture,
-			len:     b.len,
-			offset:  b.offset,
-			mode:    b.mode,
+			texture:     b.texture,
+			smooth:      b.smooth,
+			colorMatrix: b.colorMatrix,
+			len:         b.len,
+			offset:      b.offset,
+			mode:        b.mode,
 		})
 
 		b.offset += int(b.len) * 4 // 32 bits = 4 bytes; Offset is number of bytes per buffer.
 		b.len = 0
 		b.texture = 0
+		b.smooth = false
+		b.colorMatrix = nil
 	}
 }
 
@@ -46,6 +54,8 @@ func (b *Batching) clear() {
 	b.indices = b.indices[:0]
 
 	b.texture = 0
+	b.smooth = false
+	b.colorMatrix = nil
 	b.offset = 0
 	b.len = 0
 }
@@ -57,8 +67,10 @@ func init() {
 }
 
 type batchCall struct {
-	texture uint32
-	len     int32
-	offset  int
-	mode    modeType
+	texture     uint32
+	smooth      bool
+	colorMatrix *dm.ColorMatrix
+	len         int32
+	offset      int
+	mode        modeType
 }
@@ -18,8 +18,13 @@ var (
 	vbo uint32
 	ebo uint32
 
-	uniformLocationTransform  int32
-	uniformLocationHasTexture int32
+	smoothSampler uint32
+
+	uniformLocationTransform      int32
+	uniformLocationHasTexture     int32
+	uniformLocationHasColorMatrix int32
+	uniformLocationColorMatrix    int32
+	uniformLocationColorOffset    int32
 )
 
 func TryInit() {
@@ -50,6 +55,7 @@ func TryInit() {
 		initShader(vertexShader(), fragmentShader())
 		initBuffers()
 		initAttributes()
+		initSampler()
 
 		log.Print("initialized")
 	}
@@ -60,6 +66,7 @@ func Dispose() {
 	gl.DeleteVertexArrays(1, &vao)
 	gl.DeleteBuffers(1, &vbo)
 	gl.DeleteBuffers(1, &ebo)
+	gl.DeleteSamplers(1, &smoothSampler)
 	log.Print("disposed")
 }
 
@@ -72,6 +79,9 @@ func initShader(vertex, fragment string) {
 
 	uniformLocationTransform = gl.GetUniformLocation(program, gl.Str("Transform\x00"))
 	uniformLocationHasTexture = gl.GetUniformLocation(program, gl.Str("HasTexture\x00"))
+	uniformLocationHasColorMatrix = gl.GetUniformLocation(program, gl.Str("HasColorMatrix\x00"))
+	uniformLocationColorMatrix = gl.GetUniformLocation(program, gl.Str("ColorMatrix\x00"))
+	uniformLocationColorOffset = gl.GetUniformLocation(program, gl.Str("ColorOffset\x00"))
 
 	log.Print("shader initialized")
 }
@@ -98,3 +108,11 @@ func initAttributes() {
 	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
 	gl.BindVertexArray(0)
 }
+
+func initSampler() {
+	gl.GenSamplers(1, &smoothSampler)
+	gl.SamplerParameteri(smoothSampler, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.SamplerParameteri(smoothSampler, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.SamplerParameteri(smoothSampler, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	gl.SamplerParameteri(smoothSampler, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+}
@@ -35,6 +35,22 @@ func Draw(w, h, x, y, z float32) {
 			gl.Uniform1i(uniformLocationHasTexture, 0)
 		}
 
+		// Rows of the color matrix are columns of the shader matrix, so it's passed as is.
+		if c.colorMatrix != nil {
+			gl.Uniform1i(uniformLocationHasColorMatrix, 1)
+			gl.UniformMatrix4fv(uniformLocationColorMatrix, 1, false, &c.colorMatrix[0][0])
+			gl.Uniform4fv(uniformLocationColorOffset, 1, &c.colorMatrix[4][0])
+		} else {
+			gl.Uniform1i(uniformLocationHasColorMatrix, 0)
+		}
+
+		// The sampler overrides filtering parameters of the bound texture.
+		if c.smooth {
+			gl.BindSampler(0, smoothSampler)
+		} else {
+			gl.BindSampler(0, 0)
+		}
+
 		switch c.mode {
 		case mtRect:
 			gl.DrawElementsWithOffset(gl.TRIANGLES, c.len, gl.UNSIGNED_INT, uintptr(c.offset))
@@ -43,6 +59,7 @@ func Draw(w, h, x, y, z float32) {
 		}
 	}
 
+	gl.BindSampler(0, 0)
 	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
 	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
 	gl.BindVertexArray(0)
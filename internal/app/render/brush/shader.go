@@ -28,6 +28,11 @@ func fragmentShader() string {
 uniform sampler2D Texture;
 uniform bool HasTexture;
 
+// The color matrix is applied to the texture color: rgba' = ColorMatrix * rgba + ColorOffset.
+uniform bool HasColorMatrix;
+uniform mat4 ColorMatrix;
+uniform vec4 ColorOffset;
+
 in vec2 frag_texture_uv;
 in vec4 frag_color;
 
@@ -35,7 +40,11 @@ out vec4 outputColor;
 
 void main() {
 	if (HasTexture) {
-		outputColor = frag_color * texture(Texture, frag_texture_uv);
+		vec4 textureColor = texture(Texture, frag_texture_uv);
+		if (HasColorMatrix) {
+			textureColor = clamp(ColorMatrix * textureColor + ColorOffset, 0.0, 1.0);
+		}
+		outputColor = frag_color * textureColor;
 	} else {
 		outputColor = frag_color;
 	}
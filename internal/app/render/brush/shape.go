@@ -1,6 +1,7 @@
 package brush
 
 import (
+	"sdmm/internal/dmapi/dm"
 	"sdmm/internal/dmapi/dmicon"
 	"sdmm/internal/util"
 )
@@ -18,12 +19,7 @@ func RectTextured(x1, y1, x2, y2 float32, col util.Color, texture uint32, u1, v1
 }
 
 func RectTexturedV(x1, y1, x2, y2, r, g, b, a float32, texture uint32, u1, v1, u2, v2 float32) {
-	if batching.mode != mtRect || batching.texture != texture {
-		batching.flush()
-	}
-
-	batching.texture = texture
-	batching.mode = mtRect
+	batchRect(texture, false)
 
 	batching.data = append(batching.data,
 		x1, y1, r, g, b, a, u1, v2, // bottom-left
@@ -32,6 +28,60 @@ func RectTexturedV(x1, y1, x2, y2, r, g, b, a float32, texture uint32, u1, v1, u
 		x2, y2, r, g, b, a, u2, v1, // top-right
 	)
 
+	batchRectIndices()
+}
+
+// RectTransformedV draws a textured rect transformed by the matrix around the rect center.
+// Smooth rects are drawn with the linear texture filtering instead of the nearest one.
+func RectTransformedV(x1, y1, x2, y2 float32, m dm.Matrix, smooth bool, r, g, b, a float32, texture uint32, u1, v1, u2, v2 float32) {
+	batchRect(texture, smooth)
+
+	centerX, centerY := (x1+x2)/2, (y1+y2)/2
+	vertex := func(x, y float32) (float32, float32) {
+		x, y = m.Apply(x-centerX, y-centerY)
+		return x + centerX, y + centerY
+	}
+
+	blX, blY := vertex(x1, y1)
+	brX, brY := vertex(x2, y1)
+	tlX, tlY := vertex(x1, y2)
+	trX, trY := vertex(x2, y2)
+
+	batching.data = append(batching.data,
+		blX, blY, r, g, b, a, u1, v2, // bottom-left
+		brX, brY, r, g, b, a, u2, v2, // bottom-right
+		tlX, tlY, r, g, b, a, u1, v1, // top-left
+		trX, trY, r, g, b, a, u2, v1, // top-right
+	)
+
+	batchRectIndices()
+}
+
+// Color matrix applied to textures of next rects.
+var colorMatrix *dm.ColorMatrix
+
+// SetColorMatrix sets the color matrix applied to textures of next rects before their vertex color.
+// Nil removes the matrix.
+func SetColorMatrix(m *dm.ColorMatrix) {
+	colorMatrix = m
+}
+
+func batchRect(texture uint32, smooth bool) {
+	if batching.mode != mtRect || batching.texture != texture || batching.smooth != smooth || !sameColorMatrix(batching.colorMatrix, colorMatrix) {
+		batching.flush()
+	}
+
+	batching.texture = texture
+	batching.smooth = smooth
+	batching.colorMatrix = colorMatrix
+	batching.mode = mtRect
+}
+
+func sameColorMatrix(m1, m2 *dm.ColorMatrix) bool {
+	return m1 == m2 || (m1 != nil && m2 != nil && *m1 == *m2)
+}
+
+func batchRectIndices() {
 	batching.indices = append(batching.indices,
 		batching.idx+0, batching.idx+1, batching.idx+2, // bottom-left triangle
 		batching.idx+1, batching.idx+3, batching.idx+2, // top-right triangle
@@ -56,12 +56,7 @@ func (r *Render) batchLevel(level int, viewBounds util.Bounds, withUnitHighlight
 					continue
 				}
 
-				brush.RectTexturedV(
-					u.ViewBounds().X1, u.ViewBounds().Y1, u.ViewBounds().X2, u.ViewBounds().Y2,
-					u.R(), u.G(), u.B(), u.A(),
-					u.Sprite().Texture(),
-					u.Sprite().U1, u.Sprite().V1, u.Sprite().U2, u.Sprite().V2,
-				)
+				batchUnitSprite(u, u.R(), u.G(), u.B(), u.A())
 
 				if withUnitHighlight {
 					r.batchUnitHighlight(u)
@@ -77,11 +72,31 @@ func (r *Render) batchUnitHighlight(u unit.Unit) {
 	}
 	if highlight := r.overlay.Units()[u.Instance().Id()]; highlight != nil {
 		r, g, b, a := highlight.Color().RGBA()
-		brush.RectTexturedV(
-			u.ViewBounds().X1, u.ViewBounds().Y1, u.ViewBounds().X2, u.ViewBounds().Y2,
+		batchUnitSprite(u, r, g, b, a)
+	}
+}
+
+func batchUnitSprite(u unit.Unit, r, g, b, a float32) {
+	if m, ok := u.ColorMatrix(); ok {
+		brush.SetColorMatrix(&m)
+		defer brush.SetColorMatrix(nil)
+	}
+
+	sp := u.Sprite()
+	if m, bounds, ok := u.Transform(); ok {
+		brush.RectTransformedV(
+			bounds.X1, bounds.Y1, bounds.X2, bounds.Y2,
+			m, u.Smooth(),
 			r, g, b, a,
-			u.Sprite().Texture(),
-			u.Sprite().U1, u.Sprite().V1, u.Sprite().U2, u.Sprite().V2,
+			sp.Texture(),
+			sp.U1, sp.V1, sp.U2, sp.V2,
 		)
+		return
 	}
+	brush.RectTexturedV(
+		u.ViewBounds().X1, u.ViewBounds().Y1, u.ViewBounds().X2, u.ViewBounds().Y2,
+		r, g, b, a,
+		sp.Texture(),
+		sp.U1, sp.V1, sp.U2, sp.V2,
+	)
 }
@@ -30,6 +30,21 @@ func (b *Bucket) UpdateLevel(dmm *dmmap.Dmm, levelValue int, tilesToUpdate []uti
 	log.Print("bucket updated")
 }
 
+// UpdateIcons updates chunks with units using the provided icons on all existing levels.
+// Needed when icons were reloaded, so units don't refer to sprites of old icons.
+func (b *Bucket) UpdateIcons(dmm *dmmap.Dmm, icons []string) {
+	iconsSet := make(map[string]bool, len(icons))
+	for _, icon := range icons {
+		iconsSet[icon] = true
+	}
+
+	var updated int
+	for _, l := range b.levels {
+		updated += l.UpdateWithIcons(dmm, iconsSet)
+	}
+	log.Printf("bucket icons updated with [%s], chunks: [%d]", dmm.Path.Readable, updated)
+}
+
 // Level returns a specific level of the bucket or nil if it's not exist.
 func (b *Bucket) Level(level int) *level.Level {
 	return b.levels[level]
@@ -3,6 +3,7 @@ package chunk
 import (
 	"sdmm/internal/app/render/bucket/level/chunk/unit"
 	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/dmapi/dmmap/dmmproj"
 	"sdmm/internal/util"
 
 	"github.com/rs/zerolog/log"
@@ -18,17 +19,12 @@ const Size = 24
 type Chunk struct {
 	ViewBounds, MapBounds util.Bounds
 
-	UnitsByLayers map[float32][]unit.Unit
+	UnitsByLayers map[float64][]unit.Unit
 }
 
-func New(x1, y1, x2, y2, iconSize float32) *Chunk {
+func New(x1, y1, x2, y2 float32, proj dmmproj.Projection) *Chunk {
 	return &Chunk{
-		ViewBounds: util.Bounds{
-			X1: (x1 - 1) * iconSize,
-			Y1: (y1 - 1) * iconSize,
-			X2: x2 * iconSize,
-			Y2: y2 * iconSize,
-		},
+		ViewBounds: proj.AreaBounds(int(x1), int(y1), int(x2), int(y2)),
 		MapBounds: util.Bounds{
 			X1: x1,
 			Y1: y1,
@@ -43,7 +39,7 @@ func New(x1, y1, x2, y2, iconSize float32) *Chunk {
 func (c *Chunk) Update(dmm *dmmap.Dmm, level int) {
 	// Create a storage for our units by Layers with initial capacity.
 	// Inner slices are created with initial capacity as well.
-	unitsByLayers := make(map[float32][]unit.Unit, len(c.UnitsByLayers))
+	unitsByLayers := make(map[float64][]unit.Unit, len(c.UnitsByLayers))
 	for layer := range c.UnitsByLayers {
 		unitsByLayers[layer] = make([]unit.Unit, 0, len(c.UnitsByLayers[layer]))
 	}
@@ -52,7 +48,7 @@ func (c *Chunk) Update(dmm *dmmap.Dmm, level int) {
 		for y := c.MapBounds.Y1; y <= c.MapBounds.Y2; y++ {
 			x, y := int(x), int(y)
 			for _, i := range dmm.GetTile(util.Point{X: x, Y: y, Z: level}).Instances() {
-				u := unit.Make(x, y, i, dmmap.WorldIconSize)
+				u := unit.Make(dmm, x, y, i, dmmap.WorldProjection())
 				unitsByLayers[u.Layer()] = append(unitsByLayers[u.Layer()], u)
 			}
 		}
@@ -61,3 +57,15 @@ func (c *Chunk) Update(dmm *dmmap.Dmm, level int) {
 	c.UnitsByLayers = unitsByLayers
 	log.Printf("chunk level [%d] updated: %v", level, c.MapBounds)
 }
+
+// HasIcon returns true if the chunk has a unit with any of the provided icons.
+func (c *Chunk) HasIcon(icons map[string]bool) bool {
+	for _, units := range c.UnitsByLayers {
+		for _, u := range units {
+			if icon, _ := u.Instance().Prefab().Vars().Text("icon"); icons[icon] {
+				return true
+			}
+		}
+	}
+	return false
+}
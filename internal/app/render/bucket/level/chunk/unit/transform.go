@@ -0,0 +1,100 @@
+package unit
+
+import (
+	"sdmm/internal/dmapi/dm"
+	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
+	"sdmm/internal/util"
+)
+
+// The PIXEL_SCALE appearance flag: transformed icons are drawn without smoothing.
+//
+// Other appearance flags which affect the look, like RESET_COLOR, RESET_ALPHA or RESET_TRANSFORM,
+// make the atom ignore values inherited from its parent appearance.
+// Atoms on the map have no parent appearance, so they are always drawn with their own values.
+const appearancePixelScale = 512
+
+// transform is stored only for units with a non-identity transform matrix, to keep units small.
+type transform struct {
+	matrix     dm.Matrix
+	inverse    dm.Matrix
+	invertible bool        // False, if the unit is flattened into a line or a point.
+	bounds     util.Bounds // Bounds of the unit before the transformation.
+	smooth     bool
+}
+
+// Transform returns the transform matrix of the unit, which is applied around the center of returned bounds.
+// The last value is false, if the unit isn't transformed.
+func (u Unit) Transform() (matrix dm.Matrix, bounds util.Bounds, ok bool) {
+	if u.transform == nil {
+		return dm.MatrixIdentity(), u.viewBounds, false
+	}
+	return u.transform.matrix, u.transform.bounds, true
+}
+
+// Smooth returns true, if the transformed unit should be drawn with the texture smoothing.
+func (u Unit) Smooth() bool {
+	return u.transform != nil && u.transform.smooth
+}
+
+// SpritePixel returns the position of the pixel in the sprite image, which is drawn at the provided point.
+// The last value is false, if the unit isn't drawn at the point.
+func (u Unit) SpritePixel(x, y float32) (px, py int, ok bool) {
+	bounds := u.viewBounds
+	if u.transform != nil {
+		if !u.transform.invertible {
+			return 0, 0, false
+		}
+		bounds = u.transform.bounds
+		centerX, centerY := boundsCenter(bounds)
+		x, y = u.transform.inverse.Apply(x-centerX, y-centerY)
+		x, y = x+centerX, y+centerY
+	}
+
+	if x < bounds.X1 || x >= bounds.X2 || y < bounds.Y1 || y >= bounds.Y2 {
+		return 0, 0, false
+	}
+
+	// The map is rendered with the Y-axis going up, while images have it going down.
+	sp := u.Sprite()
+	return sp.X1 + int(x-bounds.X1), sp.Y1 + sp.IconHeight() - 1 - int(y-bounds.Y1), true
+}
+
+// Returns nil for units without a transformation.
+func makeTransform(p *dmmprefab.Prefab, bounds util.Bounds) *transform {
+	value, ok := p.Vars().Value("transform")
+	if !ok {
+		return nil
+	}
+	matrix, ok := dm.ParseMatrix(value)
+	if !ok || matrix.IsIdentity() {
+		return nil
+	}
+
+	t := &transform{matrix: matrix, bounds: bounds}
+	t.inverse, t.invertible = matrix.Invert()
+
+	appearanceFlags, _ := p.Vars().Int("appearance_flags")
+	t.smooth = appearanceFlags&appearancePixelScale == 0
+	return t
+}
+
+// Returns bounds which contain the transformed bounds.
+func (t *transform) viewBounds() util.Bounds {
+	centerX, centerY := boundsCenter(t.bounds)
+
+	result := util.Bounds{X1: centerX, Y1: centerY, X2: centerX, Y2: centerY}
+	for _, corner := range [][2]float32{
+		{t.bounds.X1, t.bounds.Y1}, {t.bounds.X2, t.bounds.Y1},
+		{t.bounds.X1, t.bounds.Y2}, {t.bounds.X2, t.bounds.Y2},
+	} {
+		x, y := t.matrix.Apply(corner[0]-centerX, corner[1]-centerY)
+		x, y = x+centerX, y+centerY
+		result.X1, result.Y1 = min(result.X1, x), min(result.Y1, y)
+		result.X2, result.Y2 = max(result.X2, x), max(result.Y2, y)
+	}
+	return result
+}
+
+func boundsCenter(b util.Bounds) (x, y float32) {
+	return (b.X1 + b.X2) / 2, (b.Y1 + b.Y2) / 2
+}
@@ -1,25 +1,37 @@
 package unit
 
 import (
+	"strings"
+
 	"sdmm/internal/dmapi/dm"
 	"sdmm/internal/dmapi/dmicon"
+	"sdmm/internal/dmapi/dmmap"
 	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
 	"sdmm/internal/dmapi/dmmap/dmminstance"
+	"sdmm/internal/dmapi/dmmap/dmmproj"
 	"sdmm/internal/util"
 )
 
 // Unit stores render information about specific object prefab on the map.
 type Unit struct {
 	sprite   *dmicon.Sprite
+	state    *dmicon.State // Could be nil, if the icon state doesn't exist.
+	dir      int
 	instance *dmminstance.Instance
 
-	layer      float32
+	layer      float64
 	viewBounds util.Bounds
+	transform  *transform // Nil, if the unit isn't transformed.
 
-	r, g, b, a float32
+	r, g, b, a  float32
+	colorMatrix *dm.ColorMatrix // Nil, if the color isn't a matrix.
 }
 
+// Sprite returns the sprite to render. For animated icon states it's the sprite of the current frame.
 func (u Unit) Sprite() *dmicon.Sprite {
+	if u.state != nil && u.state.Frames > 1 {
+		return u.state.SpriteAnimatedV(u.dir)
+	}
 	return u.sprite
 }
 
@@ -27,7 +39,7 @@ func (u Unit) Instance() *dmminstance.Instance {
 	return u.instance
 }
 
-func (u Unit) Layer() float32 {
+func (u Unit) Layer() float64 {
 	return u.layer
 }
 
@@ -51,7 +63,16 @@ func (u Unit) A() float32 {
 	return u.a
 }
 
-func Make(x, y int, i *dmminstance.Instance, iconSize int) Unit {
+// ColorMatrix returns the matrix to apply to the sprite colors before the unit color.
+// The last value is false, if the unit color isn't a matrix.
+func (u Unit) ColorMatrix() (dm.ColorMatrix, bool) {
+	if u.colorMatrix == nil {
+		return dm.ColorMatrix{}, false
+	}
+	return *u.colorMatrix, true
+}
+
+func Make(dmm *dmmap.Dmm, x, y int, i *dmminstance.Instance, proj dmmproj.Projection) Unit {
 	// All vars below are built-in and expected to exist.
 	icon, _ := i.Prefab().Vars().Text("icon")
 	iconState, _ := i.Prefab().Vars().Text("icon_state")
@@ -63,52 +84,112 @@ func Make(x, y int, i *dmminstance.Instance, iconSize int) Unit {
 	pixelW, _ := i.Prefab().Vars().Int("pixel_w")
 	pixelZ, _ := i.Prefab().Vars().Int("pixel_z")
 
-	sp := dmicon.Cache.GetSpriteOrPlaceholderV(icon, iconState, dir)
-	x1 := float32((x-1)*iconSize + pixelX + stepX + pixelW)
-	y1 := float32((y-1)*iconSize + pixelY + stepY + pixelZ)
+	sp := dmicon.SpritePlaceholder()
+	state, err := getState(dmm, i, icon, iconState)
+	if err == nil {
+		sp = state.SpriteV(dir)
+	}
+	posX, posY := proj.TilePos(x, y)
+	x1 := posX + float32(pixelX+stepX+pixelW)
+	y1 := posY + float32(pixelY+stepY+pixelZ)
 	x2 := x1 + float32(sp.IconWidth())
 	y2 := y1 + float32(sp.IconHeight())
-	r, g, b, a := parseColor(i.Prefab())
+	r, g, b, a, colorMatrix := parseColor(i.Prefab())
+
+	viewBounds := util.Bounds{X1: x1, Y1: y1, X2: x2, Y2: y2}
+	t := makeTransform(i.Prefab(), viewBounds)
+	if t != nil {
+		viewBounds = t.viewBounds()
+	}
 
 	return Unit{
-		sp, i, countLayer(i.Prefab()),
-		util.Bounds{X1: x1, Y1: y1, X2: x2, Y2: y2},
-		r, g, b, a,
+		sp, state, dir, i, countLayer(i.Prefab(), proj.Format, proj.Depth(x, y)),
+		viewBounds, t,
+		r, g, b, a, colorMatrix,
+	}
+}
+
+// Smoothed icon states are used only if they exist, so atoms without a state for the junction keep the base one.
+func getState(dmm *dmmap.Dmm, i *dmminstance.Instance, icon, iconState string) (*dmicon.State, error) {
+	if smoothedState, ok := dmm.SmoothedIconState(i); ok {
+		if state, err := dmicon.Cache.GetState(icon, smoothedState); err == nil {
+			return state, nil
+		}
 	}
+	return dmicon.Cache.GetState(icon, iconState)
 }
 
-func parseColor(p *dmmprefab.Prefab) (r, g, b, a float32) {
-	// Default rgba is white.
-	r, g, b, a = 1, 1, 1, 1
-	if color, _ := p.Vars().Text("color"); color != "" {
-		r, g, b, _ = util.ParseColor(color).RGBA()
-		alpha := p.Vars().FloatV("alpha", 255)
-		a = alpha / 255 // Color = RGB from color variable + alpha variable.
+// The color variable could be a color string or a color matrix list.
+// Lists which aren't valid matrices are ignored, so the unit is white.
+// Alpha of the color string is combined with the alpha variable.
+func parseColor(p *dmmprefab.Prefab) (r, g, b, a float32, colorMatrix *dm.ColorMatrix) {
+	// Default rgb is white.
+	r, g, b = 1, 1, 1
+	a = p.Vars().FloatV("alpha", 255) / 255
+
+	value, _ := p.Vars().Value("color")
+	if strings.HasPrefix(value, "list(") {
+		if m, ok := dm.ParseColorMatrix(value); ok {
+			colorMatrix = &m
+		}
+	} else if color, _ := p.Vars().Text("color"); color != "" {
+		var colorA float32
+		r, g, b, colorA = util.ParseColor(color).RGBA()
+		a *= colorA
 	}
-	return r, g, b, a
+	return r, g, b, a, colorMatrix
 }
 
 // countLayer returns the value of combined prefab vars: plane + Layer.
-func countLayer(p *dmmprefab.Prefab) float32 {
-	plane, _ := p.Vars().Float("plane")
-	layer, _ := p.Vars().Float("layer")
+// On side and isometric maps the depth of the tile is combined as well, so closer tiles are drawn above.
+func countLayer(p *dmmprefab.Prefab, mapFormat, depth int) float64 {
+	planeVar, _ := p.Vars().Float("plane")
+	layerVar, _ := p.Vars().Float("layer")
+	plane, layer := float64(planeVar), float64(layerVar)
 
 	// Layers can have essentially effect values added onto them
 	// We should clip them off to reduce the max possible layer to like 4999 (likely far lower)
 	const backgroundLayer = 20_000
 	const topdownLayer = 10_000
 	const effectsLayer = 5000
+
+	// Outside topdown maps objects are drawn in groups: background, then sorted by the depth, then effects and topdown.
+	const (
+		groupBackground = iota
+		groupRegular
+		groupEffects
+		groupTopdown
+	)
+	group := groupRegular
+
 	if layer > backgroundLayer {
 		layer -= backgroundLayer
+		group = groupBackground
 	}
 	if layer > topdownLayer {
 		layer -= topdownLayer
+		if group != groupBackground {
+			group = groupTopdown
+		}
 	}
 	if layer > effectsLayer {
 		layer -= effectsLayer
+		if group == groupRegular {
+			group = groupEffects
+		}
 	}
 
-	layer = plane*10_000 + layer*1000
+	if mapFormat == dm.MapFormatTopdown {
+		layer = plane*10_000 + layer*1000
+	} else {
+		// Scales are picked to keep every part in its own range: |layer*1000| < 1e6, |depth| < 1e4.
+		// Values out of ranges are clamped, so they don't overlap with other parts.
+		const maxLayer, maxDepth = 999, 9999
+		layer = plane*1e11 + float64(group)*1e10 + max(-maxLayer, min(layer, maxLayer))*1000
+		if group == groupRegular {
+			layer += float64(max(-maxDepth, min(depth, maxDepth))) * 1e6
+		}
+	}
 
 	// When mobs are on the same Layer with object they are always rendered above them (BYOND specific stuff).
 	if dm.IsPath(p.Path(), "/obj") {
@@ -4,6 +4,7 @@ import (
 	"math"
 
 	"sdmm/internal/app/render/bucket/level/chunk"
+	"sdmm/internal/dmapi/dmmap/dmmproj"
 	"sdmm/internal/util"
 
 	"github.com/rs/zerolog/log"
@@ -13,7 +14,7 @@ import (
 // Keys contain a bottom-left bound of the value chunk.
 // Generation is made by creating square areas. Each area has a limited number of tiles to store.
 // Method won't fill chunks with actual data. It's meant to be done in the future.
-func generateChunks(maxX, maxY, iconSize int) map[util.Point]*chunk.Chunk {
+func generateChunks(maxX, maxY int, proj dmmproj.Projection) map[util.Point]*chunk.Chunk {
 	chunks := make(map[util.Point]*chunk.Chunk)
 
 	// Helps to track if there is tiles to create chunks.
@@ -25,7 +26,7 @@ func generateChunks(maxX, maxY, iconSize int) map[util.Point]*chunk.Chunk {
 	var nextX, nextY int
 
 	createChunk := func(x1, y1, x2, y2 float32) *chunk.Chunk {
-		c := chunk.New(x1, y1, x2, y2, float32(iconSize))
+		c := chunk.New(x1, y1, x2, y2, proj)
 		nextX = int(c.MapBounds.X2) + 1
 		nextY = int(c.MapBounds.Y2) + 1
 		return c
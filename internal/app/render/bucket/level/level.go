@@ -15,21 +15,26 @@ type Level struct {
 	// Chunks is a slice of all chunks on the level.
 	Chunks map[util.Point]*chunk.Chunk
 	// Layers stores all available layers for the level.
-	Layers []float32
+	Layers []float64
 	// ChunksByLayers is the map which helps to find chunks with units on the specific layer.
-	ChunksByLayers map[float32][]*chunk.Chunk
+	ChunksByLayers map[float64][]*chunk.Chunk
 }
 
 func New(dmm *dmmap.Dmm, level int) *Level {
 	return &Level{
 		value:  level,
-		Chunks: generateChunks(dmm.MaxX, dmm.MaxY, dmmap.WorldIconSize),
+		Chunks: generateChunks(dmm.MaxX, dmm.MaxY, dmmap.WorldProjection()),
 	}
 }
 
 // Update updates current level chunks data.
 // If tilesToUpdate is not nil, then only chunks with provided tiles will be updated.
 func (l *Level) Update(dmm *dmmap.Dmm, tilesToUpdate []util.Point) {
+	// Smoothed icons depend on neighbors, so they are updated as well.
+	if tilesToUpdate != nil && dmmap.SmoothingEnabled() {
+		tilesToUpdate = dmm.TilesWithNeighbors(tilesToUpdate)
+	}
+
 	if tilesToUpdate != nil {
 		// Store a slice of updated chunks to avoid multiple updates for the same chunk area.
 		var updatedChunks []*chunk.Chunk
@@ -59,6 +64,24 @@ func (l *Level) Update(dmm *dmmap.Dmm, tilesToUpdate []util.Point) {
 	l.createChunksLayers()
 }
 
+// UpdateWithIcons updates only chunks which have units with the provided icons.
+// Returns the number of updated chunks.
+func (l *Level) UpdateWithIcons(dmm *dmmap.Dmm, icons map[string]bool) int {
+	var updated int
+	for _, c := range l.Chunks {
+		if c.HasIcon(icons) {
+			c.Update(dmm, l.value)
+			updated++
+		}
+	}
+
+	if updated > 0 {
+		l.createChunksLayers()
+	}
+
+	return updated
+}
+
 func findChunkBounds(x, y int) util.Point {
 	return util.Point{X: findChunkBound(x), Y: findChunkBound(y)}
 }
@@ -75,7 +98,7 @@ func findChunkBound(value int) int {
 
 // Method collects layers for every unit in every chunk.
 func (l *Level) createChunksLayers() {
-	chunksByLayers := make(map[float32][]*chunk.Chunk, len(l.ChunksByLayers))
+	chunksByLayers := make(map[float64][]*chunk.Chunk, len(l.ChunksByLayers))
 	for _, c := range l.Chunks {
 		for chunkLayer := range c.UnitsByLayers {
 			chunksByLayers[chunkLayer] = append(chunksByLayers[chunkLayer], c)
@@ -83,7 +106,7 @@ func (l *Level) createChunksLayers() {
 	}
 
 	// Sort layers to do a proper rendering later.
-	layers := make([]float32, 0, len(chunksByLayers))
+	layers := make([]float64, 0, len(chunksByLayers))
 	for layer := range chunksByLayers {
 		if len(chunksByLayers[layer]) > 0 {
 			layers = append(layers, layer)
@@ -0,0 +1,199 @@
+// Package minimap renders a map level as an image with a single pixel per tile.
+// The pixel color is made by blending average colors of all sprites on the tile, the same way as they are drawn.
+package minimap
+
+import (
+	"image"
+	"image/color"
+	"sort"
+
+	"sdmm/internal/app/render/bucket/level/chunk/unit"
+	"sdmm/internal/dmapi/dm"
+	"sdmm/internal/dmapi/dmicon"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/platform"
+	"sdmm/internal/util"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/rs/zerolog/log"
+)
+
+// Minimap stores the image of a single level.
+// The image is rebuilt lazily, so a full update is cheap until the minimap is shown.
+type Minimap struct {
+	level      int
+	maxX, maxY int
+
+	img     *image.NRGBA
+	texture uint32
+
+	stale bool // The whole image should be rebuilt.
+	dirty bool // The image was changed since the last texture upload.
+
+	// Hidden paths aren't drawn, the same way as they aren't drawn on the canvas. Nil, if nothing is filtered.
+	filter        *dm.PathsFilter
+	filterVersion uint64
+
+	spriteColors map[*dmicon.Sprite]rgba
+}
+
+type rgba struct {
+	r, g, b, a float32
+}
+
+func New(dmm *dmmap.Dmm, level int) *Minimap {
+	return &Minimap{
+		level:        level,
+		maxX:         dmm.MaxX,
+		maxY:         dmm.MaxY,
+		img:          image.NewNRGBA(image.Rect(0, 0, dmm.MaxX, dmm.MaxY)),
+		stale:        true,
+		spriteColors: make(map[*dmicon.Sprite]rgba),
+	}
+}
+
+// Fits returns true if the minimap is made for the provided map size and level.
+func (m *Minimap) Fits(dmm *dmmap.Dmm, level int) bool {
+	return m.level == level && m.maxX == dmm.MaxX && m.maxY == dmm.MaxY
+}
+
+// Invalidate marks the whole minimap to be rebuilt, when it's requested next time.
+func (m *Minimap) Invalidate() {
+	m.stale = true
+}
+
+// SetFilter sets the filter of paths, which aren't drawn. The minimap is rebuilt, when the filter is changed.
+func (m *Minimap) SetFilter(filter *dm.PathsFilter) {
+	if m.filter != filter || m.filterVersion != filter.Version() {
+		m.filter, m.filterVersion = filter, filter.Version()
+		m.stale = true
+	}
+}
+
+// Update redraws the provided tiles. Tiles from other levels are ignored.
+// Smoothed icons depend on their neighbors, so neighbors are redrawn as well.
+func (m *Minimap) Update(dmm *dmmap.Dmm, tiles []util.Point) {
+	if m.stale {
+		return // Will be redrawn anyway.
+	}
+	if dmmap.SmoothingEnabled() {
+		tiles = dmm.TilesWithNeighbors(tiles)
+	}
+	for _, coord := range tiles {
+		if coord.Z == m.level && dmm.HasTile(coord) {
+			m.drawTile(dmm, coord)
+		}
+	}
+	m.dirty = true
+}
+
+// Texture returns the texture with the minimap image. Rebuilds the image and uploads changes, if needed.
+// The texture is upside down: the first row is the top one.
+func (m *Minimap) Texture(dmm *dmmap.Dmm) uint32 {
+	if m.stale {
+		m.rebuild(dmm)
+	}
+
+	if m.texture == 0 {
+		m.texture = platform.CreateEmptyTexture(m.maxX, m.maxY)
+		m.dirty = true
+	}
+	if m.dirty {
+		platform.UpdateTexture(m.texture, 0, 0, m.img)
+		platform.GenerateMipmap(m.texture)
+		m.dirty = false
+	}
+
+	return m.texture
+}
+
+func (m *Minimap) Dispose() {
+	if m.texture != 0 {
+		gl.DeleteTextures(1, &m.texture)
+		m.texture = 0
+	}
+}
+
+func (m *Minimap) rebuild(dmm *dmmap.Dmm) {
+	log.Printf("rebuilding minimap of level [%d]...", m.level)
+
+	// Sprites could be reloaded, so old colors aren't reused.
+	m.spriteColors = make(map[*dmicon.Sprite]rgba, len(m.spriteColors))
+
+	for x := 1; x <= m.maxX; x++ {
+		for y := 1; y <= m.maxY; y++ {
+			m.drawTile(dmm, util.Point{X: x, Y: y, Z: m.level})
+		}
+	}
+
+	m.stale = false
+	m.dirty = true
+	log.Print("minimap rebuilt")
+}
+
+func (m *Minimap) drawTile(dmm *dmmap.Dmm, coord util.Point) {
+	instances := dmm.GetTile(coord).Instances()
+	units := make([]unit.Unit, 0, len(instances))
+	for _, i := range instances {
+		if m.filter != nil && m.filter.IsHiddenPath(i.Prefab().Path()) {
+			continue
+		}
+		units = append(units, unit.Make(dmm, coord.X, coord.Y, i, dmmap.WorldProjection()))
+	}
+	sort.SliceStable(units, func(i, j int) bool {
+		return units[i].Layer() < units[j].Layer()
+	})
+
+	// Colors are blended premultiplied, so the transparent start doesn't darken the result.
+	var r, g, b, a float32
+	for _, u := range units {
+		c := m.spriteColor(u.Sprite())
+		if colorMatrix, ok := u.ColorMatrix(); ok {
+			c.r, c.g, c.b, c.a = colorMatrix.Apply(c.r, c.g, c.b, c.a)
+		}
+		ur, ug, ub, ua := c.r*u.R(), c.g*u.G(), c.b*u.B(), c.a*u.A()
+
+		r = ur*ua + r*(1-ua)
+		g = ug*ua + g*(1-ua)
+		b = ub*ua + b*(1-ua)
+		a = ua + a*(1-ua)
+	}
+
+	var c color.NRGBA
+	if a > 0 {
+		c = color.NRGBA{R: toByte(r / a), G: toByte(g / a), B: toByte(b / a), A: toByte(a)}
+	}
+	m.img.SetNRGBA(coord.X-1, m.maxY-coord.Y, c)
+}
+
+// Returns the average color of the sprite. Colors of pixels are weighted by their alpha.
+func (m *Minimap) spriteColor(sp *dmicon.Sprite) rgba {
+	if c, ok := m.spriteColors[sp]; ok {
+		return c
+	}
+
+	var c rgba
+	if img := sp.Image(); img != nil && sp.X2 > sp.X1 && sp.Y2 > sp.Y1 {
+		var r, g, b, a float32
+		for y := sp.Y1; y < sp.Y2; y++ {
+			for x := sp.X1; x < sp.X2; x++ {
+				px := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+				pa := float32(px.A) / 255
+				r += float32(px.R) / 255 * pa
+				g += float32(px.G) / 255 * pa
+				b += float32(px.B) / 255 * pa
+				a += pa
+			}
+		}
+		if a > 0 {
+			c = rgba{r / a, g / a, b / a, a / float32((sp.X2-sp.X1)*(sp.Y2-sp.Y1))}
+		}
+	}
+
+	m.spriteColors[sp] = c
+	return c
+}
+
+func toByte(v float32) uint8 {
+	return uint8(min(255, max(0, v*255+.5)))
+}
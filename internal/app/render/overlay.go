@@ -53,9 +53,14 @@ func (r *Render) batchOverlayAreas() {
 		return
 	}
 
+	// Areas could be pushed for every visible tile, so transparent parts are skipped.
 	for _, a := range r.overlay.Areas() {
-		brush.RectFilled(a.Bounds().X1, a.Bounds().Y1, a.Bounds().X2, a.Bounds().Y2, a.FillColor())
-		brush.Rect(a.Bounds().X1, a.Bounds().Y1, a.Bounds().X2, a.Bounds().Y2, a.BorderColor())
+		if a.FillColor().A() != 0 {
+			brush.RectFilled(a.Bounds().X1, a.Bounds().Y1, a.Bounds().X2, a.Bounds().Y2, a.FillColor())
+		}
+		if a.BorderColor().A() != 0 {
+			brush.Rect(a.Bounds().X1, a.Bounds().Y1, a.Bounds().X2, a.Bounds().Y2, a.BorderColor())
+		}
 	}
 
 	r.overlay.FlushAreas()
@@ -3,6 +3,7 @@ package render
 import (
 	"sdmm/internal/app/render/brush"
 	"sdmm/internal/app/render/bucket"
+	"sdmm/internal/app/render/minimap"
 	"sdmm/internal/dmapi/dmmap"
 	"sdmm/internal/util"
 
@@ -12,7 +13,8 @@ import (
 type Render struct {
 	Camera *Camera
 
-	bucket *bucket.Bucket
+	bucket  *bucket.Bucket
+	minimap *minimap.Minimap // Nil, until requested.
 
 	overlay       overlay
 	unitProcessor unitProcessor
@@ -44,6 +46,14 @@ func (r *Render) SetActiveLevel(dmm *dmmap.Dmm, activeLevel int) {
 // UpdateBucketV will update the bucket data by the provided level.
 func (r *Render) UpdateBucketV(dmm *dmmap.Dmm, level int, tilesToUpdate []util.Point) {
 	r.bucket.UpdateLevel(dmm, level, tilesToUpdate)
+
+	if r.minimap != nil && r.minimap.Fits(dmm, level) {
+		if tilesToUpdate == nil {
+			r.minimap.Invalidate()
+		} else {
+			r.minimap.Update(dmm, tilesToUpdate)
+		}
+	}
 }
 
 // UpdateBucket will ensure that the bucket has data by the provided level.
@@ -51,6 +61,43 @@ func (r *Render) UpdateBucket(dmm *dmmap.Dmm, level int) {
 	r.UpdateBucketV(dmm, level, nil)
 }
 
+// ResetBucket will recreate all levels of the bucket from scratch.
+// Needed when the world projection is changed, since chunks store positions of their units.
+func (r *Render) ResetBucket(dmm *dmmap.Dmm) {
+	levels := r.bucket.Levels
+	r.bucket = bucket.New()
+	for _, level := range levels {
+		r.UpdateBucket(dmm, level)
+	}
+}
+
+// UpdateBucketIcons will update the bucket data which uses the provided icons.
+func (r *Render) UpdateBucketIcons(dmm *dmmap.Dmm, icons []string) {
+	r.bucket.UpdateIcons(dmm, icons)
+
+	if r.minimap != nil {
+		r.minimap.Invalidate()
+	}
+}
+
+// Minimap returns the minimap of the provided level. It's kept in sync with the bucket.
+// Only a single level has the minimap, so the previous one is dropped, when another level is requested.
+func (r *Render) Minimap(dmm *dmmap.Dmm, level int) *minimap.Minimap {
+	if r.minimap == nil || !r.minimap.Fits(dmm, level) {
+		r.Dispose()
+		r.minimap = minimap.New(dmm, level)
+	}
+	return r.minimap
+}
+
+// Dispose frees OpenGL resources of the render.
+func (r *Render) Dispose() {
+	if r.minimap != nil {
+		r.minimap.Dispose()
+		r.minimap = nil
+	}
+}
+
 func (r *Render) Draw(width, height float32) {
 	r.prepare()
 	r.draw(width, height)
@@ -66,7 +113,7 @@ func (r *Render) prepare() {
 }
 
 func (r *Render) draw(width, height float32) {
-	r.batchBucketUnits(r.viewportBounds(width, height))
+	r.batchBucketUnits(r.ViewportBounds(width, height))
 	//r.batchChunksVisuals()
 	r.batchOverlayAreasBorders()
 	r.batchOverlayAreas()
@@ -78,7 +125,8 @@ func (r *Render) cleanup() {
 	gl.Disable(gl.BLEND)
 }
 
-func (r *Render) viewportBounds(width, height float32) util.Bounds {
+// ViewportBounds returns bounds of the map part visible with the provided canvas size.
+func (r *Render) ViewportBounds(width, height float32) util.Bounds {
 	// Get transformed bounds of the map, so we can ignore out of bounds units.
 	w := width / r.Camera.Scale
 	h := height / r.Camera.Scale
@@ -0,0 +1,166 @@
+// Package softrender composes map images on the CPU, without any GL context.
+// Units are created and ordered the same way as for the editor render, so the result image looks like the map in the editor.
+package softrender
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"sdmm/internal/app/render/bucket/level/chunk/unit"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/util"
+
+	"github.com/rs/zerolog/log"
+)
+
+// overlapMargin is an amount of tiles around the rendered region to take units from.
+// Big icons and pixel offsets make units visible outside of their tiles.
+const overlapMargin = 3
+
+type Config struct {
+	// Z is a map level to render.
+	Z int
+	// Region contains inclusive tile coordinates to render. The whole level is rendered if it's empty.
+	Region util.Bounds
+	// Scale is a multiplier of the result image size. The nearest neighbour scaling is used.
+	Scale float32
+	// PathFilter returns true for paths which should be rendered. All paths are rendered if it's nil.
+	PathFilter func(path string) bool
+}
+
+// Render composes the map level into an image.
+// Icons are taken from the dmicon.Cache, so its root dir should be set before the call.
+func Render(dmm *dmmap.Dmm, cfg Config) (*image.RGBA, error) {
+	if cfg.Z < 1 || cfg.Z > dmm.MaxZ {
+		return nil, fmt.Errorf("z-level [%d] is out of the map bounds [1, %d]", cfg.Z, dmm.MaxZ)
+	}
+
+	region := cfg.Region
+	if region.IsEmpty() {
+		region = util.Bounds{X1: 1, Y1: 1, X2: float32(dmm.MaxX), Y2: float32(dmm.MaxY)}
+	}
+	region.X1 = max(1, region.X1)
+	region.Y1 = max(1, region.Y1)
+	region.X2 = min(float32(dmm.MaxX), region.X2)
+	region.Y2 = min(float32(dmm.MaxY), region.Y2)
+	if region.X1 > region.X2 || region.Y1 > region.Y2 {
+		return nil, fmt.Errorf("region [%v] is out of the map bounds", cfg.Region)
+	}
+
+	viewBounds := dmmap.WorldProjection().AreaBounds(int(region.X1), int(region.Y1), int(region.X2), int(region.Y2))
+
+	img := image.NewRGBA(image.Rect(0, 0, int(viewBounds.X2-viewBounds.X1), int(viewBounds.Y2-viewBounds.Y1)))
+	units := collectUnits(dmm, cfg, region)
+	for _, u := range units {
+		drawUnit(img, u, viewBounds)
+	}
+
+	log.Printf("level [%d] rendered: [%v], units: [%d]", cfg.Z, region, len(units))
+
+	if cfg.Scale > 0 && cfg.Scale != 1 {
+		return scale(img, cfg.Scale), nil
+	}
+	return img, nil
+}
+
+// collectUnits returns units of the region sorted in the rendering order.
+// Units with the same layer keep the order of their tiles, like in a single chunk of the editor render.
+// The editor draws such units chunk by chunk, so the order could differ only for units overlapping chunks.
+func collectUnits(dmm *dmmap.Dmm, cfg Config, region util.Bounds) []unit.Unit {
+	x1 := max(1, int(region.X1)-overlapMargin)
+	y1 := max(1, int(region.Y1)-overlapMargin)
+	x2 := min(dmm.MaxX, int(region.X2)+overlapMargin)
+	y2 := min(dmm.MaxY, int(region.Y2)+overlapMargin)
+
+	var units []unit.Unit
+	for x := x1; x <= x2; x++ {
+		for y := y1; y <= y2; y++ {
+			for _, i := range dmm.GetTile(util.Point{X: x, Y: y, Z: cfg.Z}).Instances() {
+				if cfg.PathFilter != nil && !cfg.PathFilter(i.Prefab().Path()) {
+					continue
+				}
+				units = append(units, unit.Make(dmm, x, y, i, dmmap.WorldProjection()))
+			}
+		}
+	}
+
+	sort.SliceStable(units, func(i, j int) bool {
+		return units[i].Layer() < units[j].Layer()
+	})
+
+	return units
+}
+
+// drawUnit blends the unit sprite over the image.
+// Sprite colors are transformed by the unit color matrix and multiplied by the unit color, the same way as the editor shader does.
+// Every image pixel covered by the unit takes the sprite pixel drawn at its center, so transformed units are supported.
+func drawUnit(img *image.RGBA, u unit.Unit, viewBounds util.Bounds) {
+	src := u.Sprite().Image()
+	srcNrgba, _ := src.(*image.NRGBA)
+
+	// The map is rendered with the Y-axis going up, while images have it going down.
+	dstX1 := max(0, int(u.ViewBounds().X1-viewBounds.X1))
+	dstX2 := min(img.Rect.Dx(), int(math.Ceil(float64(u.ViewBounds().X2-viewBounds.X1))))
+	dstY1 := max(0, int(viewBounds.Y2-u.ViewBounds().Y2))
+	dstY2 := min(img.Rect.Dy(), int(math.Ceil(float64(viewBounds.Y2-u.ViewBounds().Y1))))
+
+	r, g, b, a := u.R(), u.G(), u.B(), u.A()
+	colorMatrix, hasColorMatrix := u.ColorMatrix()
+
+	for y := dstY1; y < dstY2; y++ {
+		for x := dstX1; x < dstX2; x++ {
+			sx, sy, ok := u.SpritePixel(viewBounds.X1+float32(x)+.5, viewBounds.Y2-float32(y)-.5)
+			if !ok {
+				continue
+			}
+
+			var c color.NRGBA
+			if srcNrgba != nil {
+				c = srcNrgba.NRGBAAt(sx, sy)
+			} else {
+				c = color.NRGBAModel.Convert(src.At(sx, sy)).(color.NRGBA)
+			}
+			sr, sg, sb, sa := float32(c.R)/255, float32(c.G)/255, float32(c.B)/255, float32(c.A)/255
+			if hasColorMatrix {
+				sr, sg, sb, sa = colorMatrix.Apply(sr, sg, sb, sa)
+			}
+			if sa == 0 {
+				continue
+			}
+
+			blend(img, x, y, sr*r, sg*g, sb*b, sa*a)
+		}
+	}
+}
+
+// blend puts the straight alpha color over the premultiplied image pixel.
+func blend(img *image.RGBA, x, y int, r, g, b, a float32) {
+	idx := img.PixOffset(x, y)
+	pix := img.Pix[idx : idx+4 : idx+4]
+	inv := 1 - a
+	pix[0] = toByte(r*a + float32(pix[0])/255*inv)
+	pix[1] = toByte(g*a + float32(pix[1])/255*inv)
+	pix[2] = toByte(b*a + float32(pix[2])/255*inv)
+	pix[3] = toByte(a + float32(pix[3])/255*inv)
+}
+
+func toByte(v float32) uint8 {
+	return uint8(min(255, max(0, v*255+.5)))
+}
+
+func scale(img *image.RGBA, factor float32) *image.RGBA {
+	width := max(1, int(float32(img.Rect.Dx())*factor))
+	height := max(1, int(float32(img.Rect.Dy())*factor))
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		srcY := min(img.Rect.Dy()-1, int(float32(y)/factor))
+		for x := 0; x < width; x++ {
+			srcX := min(img.Rect.Dx()-1, int(float32(x)/factor))
+			copy(scaled.Pix[scaled.PixOffset(x, y):], img.Pix[img.PixOffset(srcX, srcY):img.PixOffset(srcX, srcY)+4])
+		}
+	}
+	return scaled
+}
@@ -0,0 +1,174 @@
+package softrender
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"sdmm/internal/app/render/bucket/level/chunk"
+	"sdmm/internal/dmapi/dm"
+	"sdmm/internal/dmapi/dmenv"
+	"sdmm/internal/dmapi/dmicon"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/dmapi/dmmtest"
+	"sdmm/internal/dmapi/dmvars"
+	"sdmm/internal/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	colorRed    = color.RGBA{R: 255, A: 255}
+	colorGreen  = color.RGBA{G: 255, A: 255}
+	colorBlue   = color.RGBA{B: 255, A: 255}
+	colorYellow = color.RGBA{R: 255, G: 255, A: 255}
+)
+
+// Writes the DMI file with 32x32 icon states filled with provided colors.
+func writeTestDmi(t *testing.T, path string, states []string, colors []color.RGBA) {
+	img := image.NewRGBA(image.Rect(0, 0, 32*len(states), 32))
+	description := "# BEGIN DMI\nversion = 4.0\n\twidth = 32\n\theight = 32\n"
+	for idx, state := range states {
+		description += fmt.Sprintf("state = %q\n\tdirs = 1\n\tframes = 1\n", state)
+		for y := 0; y < 32; y++ {
+			for x := idx * 32; x < (idx+1)*32; x++ {
+				img.Set(x, y, colors[idx])
+			}
+		}
+	}
+	description += "# END DMI\n"
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	// The description chunk goes right after the IHDR chunk.
+	chunkData := []byte("Description\x00" + description)
+	textChunk := binary.BigEndian.AppendUint32(nil, uint32(len(chunkData)))
+	textChunk = append(append(textChunk, "tEXt"...), chunkData...)
+	textChunk = binary.BigEndian.AppendUint32(textChunk, crc32.ChecksumIEEE(textChunk[4:]))
+
+	const ihdrEnd = 8 + 8 + 13 + 4
+	data := append(append(append([]byte{}, buf.Bytes()[:ihdrEnd]...), textChunk...), buf.Bytes()[ihdrEnd:]...)
+	require.NoError(t, os.WriteFile(path, data, os.ModePerm))
+}
+
+func setupTestEnv(t *testing.T) *dmenv.Dme {
+	rootDir := t.TempDir()
+	writeTestDmi(t, filepath.Join(rootDir, "test.dmi"),
+		[]string{"red", "green", "blue", "yellow"},
+		[]color.RGBA{colorRed, colorGreen, colorBlue, colorYellow},
+	)
+
+	dmicon.Headless = true
+	dmicon.Cache.SetRootDirPath(rootDir)
+	dmmap.WorldIconWidth, dmmap.WorldIconHeight, dmmap.WorldMapFormat = 32, 32, dm.MapFormatTopdown
+	t.Cleanup(func() {
+		dmicon.Cache.Free()
+		dmmap.Free()
+	})
+
+	object := func(iconState, layer string) *dmenv.Object {
+		vars := &dmvars.Variables{}
+		for name, value := range map[string]string{
+			"icon":       "'test.dmi'",
+			"icon_state": fmt.Sprintf("%q", iconState),
+			"layer":      layer,
+			"plane":      "0",
+			"dir":        "2",
+		} {
+			vars = dmvars.Set(vars, name, value)
+		}
+		return &dmenv.Object{Vars: vars}
+	}
+
+	return dmmtest.NewEnv(rootDir, map[string]*dmenv.Object{
+		"/area/space":     object("none", "1"),
+		"/turf/red":       object("red", "2"),
+		"/obj/blue":       object("blue", "3"),
+		"/obj/low/green":  object("green", "2.5"),
+		"/obj/low/yellow": object("yellow", "2.5"),
+	})
+}
+
+// The objects are listed before the turf, but drawn above it because of their layers.
+// Objects on the same layer are drawn in their order on the tile.
+const testMapContent = `"a" = (/obj/blue,/turf/red,/area/space)
+"b" = (/obj/low/green,/obj/low/yellow,/turf/red,/area/space)
+"c" = (/obj/low/yellow,/obj/low/green,/turf/red,/area/space)
+"d" = (/turf/red,/area/space)
+(1,1,1) = {"
+cd
+ab
+"}`
+
+func TestRender(t *testing.T) {
+	env := setupTestEnv(t)
+	_, dmm := dmmtest.ParseMap(t, env, testMapContent, dmmap.ObsoleteConfig{})
+
+	img, err := Render(dmm, Config{Z: 1, PathFilter: func(path string) bool {
+		return !dm.IsPath(path, "/area")
+	}})
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 64, 64), img.Rect)
+
+	// Images have the Y-axis going down, so the first row of the map is at the bottom.
+	assert.Equal(t, colorBlue, img.RGBAAt(16, 48), "(1, 1)")
+	assert.Equal(t, colorYellow, img.RGBAAt(48, 48), "(2, 1)")
+	assert.Equal(t, colorGreen, img.RGBAAt(16, 16), "(1, 2)")
+	assert.Equal(t, colorRed, img.RGBAAt(48, 16), "(2, 2)")
+}
+
+func TestRender_Region(t *testing.T) {
+	env := setupTestEnv(t)
+	_, dmm := dmmtest.ParseMap(t, env, testMapContent, dmmap.ObsoleteConfig{})
+
+	img, err := Render(dmm, Config{Z: 1, Region: util.Bounds{X1: 2, Y1: 1, X2: 2, Y2: 1}, Scale: 2, PathFilter: func(path string) bool {
+		return dm.IsPath(path, "/turf")
+	}})
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 64, 64), img.Rect)
+	assert.Equal(t, colorRed, img.RGBAAt(32, 32))
+
+	_, err = Render(dmm, Config{Z: 2})
+	assert.Error(t, err)
+	_, err = Render(dmm, Config{Z: 1, Region: util.Bounds{X1: 5, Y1: 5, X2: 6, Y2: 6}})
+	assert.Error(t, err)
+}
+
+// Units are drawn in the same order as the editor draws a single chunk: by layers, then by tiles.
+func TestCollectUnits_EditorOrder(t *testing.T) {
+	env := setupTestEnv(t)
+	_, dmm := dmmtest.ParseMap(t, env, testMapContent, dmmap.ObsoleteConfig{})
+
+	c := chunk.New(1, 1, float32(dmm.MaxX), float32(dmm.MaxY), dmmap.WorldProjection())
+	c.Update(dmm, 1)
+
+	layers := make([]float64, 0, len(c.UnitsByLayers))
+	for layer := range c.UnitsByLayers {
+		layers = append(layers, layer)
+	}
+	sort.Float64s(layers)
+
+	var expected []uint64
+	for _, layer := range layers {
+		for _, u := range c.UnitsByLayers[layer] {
+			expected = append(expected, u.Instance().Id())
+		}
+	}
+
+	var actual []uint64
+	for _, u := range collectUnits(dmm, Config{Z: 1}, util.Bounds{X1: 1, Y1: 1, X2: 2, Y2: 2}) {
+		actual = append(actual, u.Instance().Id())
+	}
+
+	assert.Equal(t, expected, actual)
+}
@@ -77,6 +77,14 @@ func (e *Environment) Free() {
 	log.Print("environment panel free")
 }
 
+// ResetIcons drops created tree nodes, so they are created again with actual icon sprites.
+// Unlike the Free method, the filter and the selected path are kept.
+func (e *Environment) ResetIcons() {
+	e.treeNodes = make(map[string]*treeNode)
+	e.tmpDoRepeatFilter = true
+	log.Print("environment panel icons reset")
+}
+
 func (e *Environment) process() {
 	e.tmpNewTreeNodesCount = 0
 
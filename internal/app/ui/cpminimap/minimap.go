@@ -0,0 +1,22 @@
+package cpminimap
+
+import (
+	"sdmm/internal/app/ui/component"
+	"sdmm/internal/app/ui/cpwsarea/wsmap/pmap/editor"
+)
+
+type App interface {
+	CurrentEditor() *editor.Editor
+}
+
+// Minimap shows the downscaled active level of the opened map with the visible part of it.
+// Clicking or dragging over the minimap moves the camera.
+type Minimap struct {
+	component.Component
+
+	app App
+}
+
+func (m *Minimap) Init(app App) {
+	m.app = app
+}
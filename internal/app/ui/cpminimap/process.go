@@ -0,0 +1,67 @@
+package cpminimap
+
+import (
+	"sdmm/internal/app/ui/cpwsarea/wsmap/pmap/editor"
+	"sdmm/internal/imguiext/style"
+	"sdmm/internal/util"
+
+	"github.com/SpaiR/imgui-go"
+)
+
+var viewportColor = imgui.PackedColorFromVec4(style.ColorGold)
+
+func (m *Minimap) Process(int32) {
+	ed := m.app.CurrentEditor()
+	if ed == nil {
+		imgui.TextDisabled("No map opened")
+		return
+	}
+
+	maxX, maxY := float32(ed.Dmm().MaxX), float32(ed.Dmm().MaxY)
+
+	// Fit the minimap into the window, keeping its aspect ratio.
+	avail := imgui.ContentRegionAvail()
+	scale := min(avail.X/maxX, avail.Y/maxY)
+	if scale <= 0 {
+		return
+	}
+	size := imgui.Vec2{X: maxX * scale, Y: maxY * scale}
+
+	pos := imgui.CursorScreenPos()
+	pos.X += (avail.X - size.X) / 2
+	imgui.SetCursorScreenPos(pos)
+
+	imgui.InvisibleButton("minimap", size)
+	m.processInput(ed, pos, scale)
+
+	drawList := imgui.WindowDrawList()
+	drawList.AddImage(imgui.TextureID(ed.MinimapTexture()), pos, pos.Plus(size))
+
+	// Tiles go from the bottom to the top, while the minimap is drawn from the top.
+	view := ed.ViewportTiles()
+	drawList.PushClipRect(pos, pos.Plus(size))
+	drawList.AddRect(
+		imgui.Vec2{X: pos.X + (view.X1-1)*scale, Y: pos.Y + (maxY-view.Y2)*scale},
+		imgui.Vec2{X: pos.X + view.X2*scale, Y: pos.Y + (maxY-view.Y1+1)*scale},
+		viewportColor,
+	)
+	drawList.PopClipRect()
+}
+
+func (m *Minimap) processInput(ed *editor.Editor, pos imgui.Vec2, scale float32) {
+	if !imgui.IsItemActive() {
+		return
+	}
+
+	mouse := imgui.MousePos()
+	coord := util.Point{
+		X: int((mouse.X-pos.X)/scale) + 1,
+		Y: ed.Dmm().MaxY - int((mouse.Y-pos.Y)/scale),
+		Z: ed.ActiveLevel(),
+	}
+	coord.X = min(max(coord.X, 1), ed.Dmm().MaxX)
+	coord.Y = min(max(coord.Y, 1), ed.Dmm().MaxY)
+
+	// The tile is flicked only on click, so dragging doesn't blink all over the map.
+	ed.FocusCameraOnPositionV(coord, imgui.IsItemActivated())
+}
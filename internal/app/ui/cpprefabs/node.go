@@ -19,6 +19,8 @@ type prefabNode struct {
 	name      string
 	orig      *dmmprefab.Prefab
 	sprite    *dmicon.Sprite
+	state     *dmicon.State // Could be nil, if the icon state doesn't exist.
+	dir       int
 	color     imgui.Vec4
 	visHeight float32
 }
@@ -74,10 +76,21 @@ func newPrefabNodeV(prefab *dmmprefab.Prefab, name string) *prefabNode {
 	iconState, _ := prefab.Vars().Text("icon_state")
 	dir, _ := prefab.Vars().Int("dir")
 	r, g, b, _ := util.ParseColor(prefab.Vars().TextV("color", dmvars.NullValue)).RGBA()
+	state, _ := dmicon.Cache.GetState(icon, iconState)
 	return &prefabNode{
 		name:   name,
 		orig:   prefab,
 		sprite: dmicon.Cache.GetSpriteOrPlaceholderV(icon, iconState, dir),
+		state:  state,
+		dir:    dir,
 		color:  imgui.Vec4{X: r, Y: g, Z: b, W: 1},
 	}
 }
+
+// Returns the sprite of the current animation frame.
+func (n *prefabNode) animatedSprite() *dmicon.Sprite {
+	if n.state != nil {
+		return n.state.SpriteAnimatedV(n.dir)
+	}
+	return n.sprite
+}
@@ -40,16 +40,18 @@ func (p *Prefabs) Process(int32) {
 
 		imgui.SetCursorPos(cursor)
 
+		sprite := node.animatedSprite()
+
 		imgui.BeginGroup()
-		w.Image(imgui.TextureID(node.sprite.Texture()), p.iconSize(), p.iconSize()).
+		w.Image(imgui.TextureID(sprite.Texture()), p.iconSize(), p.iconSize()).
 			Uv(
 				imgui.Vec2{
-					X: node.sprite.U1,
-					Y: node.sprite.V1,
+					X: sprite.U1,
+					Y: sprite.V1,
 				},
 				imgui.Vec2{
-					X: node.sprite.U2,
-					Y: node.sprite.V2,
+					X: sprite.U2,
+					Y: sprite.V2,
 				},
 			).
 			TintColor(node.color).
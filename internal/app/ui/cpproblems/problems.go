@@ -0,0 +1,54 @@
+package cpproblems
+
+import (
+	"sdmm/internal/app/ui/component"
+	"sdmm/internal/app/ui/cpwsarea/wsmap/pmap/editor"
+	"sdmm/internal/dmapi/dmenv"
+	"sdmm/internal/dmapi/dmmlint"
+
+	"github.com/rs/zerolog/log"
+)
+
+type App interface {
+	CurrentEditor() *editor.Editor
+	LoadedEnvironment() *dmenv.Dme
+	LintConfig() dmmlint.Config
+}
+
+// Problems shows results of the map checking made with the dmmlint package.
+// Results are not updated on map changes, the check should be done again to see actual problems.
+type Problems struct {
+	component.Component
+
+	app App
+
+	checked  bool
+	problems []dmmlint.Problem
+
+	selectedIdx int
+}
+
+func (p *Problems) Init(app App) {
+	p.app = app
+	p.selectedIdx = -1
+}
+
+func (p *Problems) Free() {
+	p.checked = false
+	p.problems = nil
+	p.selectedIdx = -1
+	log.Print("problems free")
+}
+
+// Check checks the currently opened map for problems.
+func (p *Problems) Check() {
+	p.Free()
+
+	ed := p.app.CurrentEditor()
+	if ed == nil || p.app.LoadedEnvironment() == nil {
+		return
+	}
+
+	p.problems = dmmlint.Lint(p.app.LoadedEnvironment(), ed.Dmm(), p.app.LintConfig())
+	p.checked = true
+}
@@ -0,0 +1,100 @@
+package cpproblems
+
+import (
+	"fmt"
+
+	"sdmm/internal/imguiext/icon"
+	"sdmm/internal/imguiext/style"
+	w "sdmm/internal/imguiext/widget"
+
+	"github.com/SpaiR/imgui-go"
+	"github.com/rs/zerolog/log"
+)
+
+func (p *Problems) Process(int32) {
+	if p.app.CurrentEditor() == nil {
+		imgui.TextDisabled("No map opened")
+		return
+	}
+
+	p.showControls()
+
+	imgui.Separator()
+
+	if imgui.BeginChild("problems") {
+		p.showProblems()
+	}
+	imgui.EndChild()
+}
+
+func (p *Problems) showControls() {
+	var status string
+	if p.checked {
+		status = fmt.Sprintf("Problems: %d", len(p.problems))
+	} else {
+		status = "Not checked"
+	}
+
+	w.Layout{
+		w.Button("Check", p.Check).
+			Tooltip("Check the map for problems"),
+		w.SameLine(),
+		w.AlignTextToFramePadding(),
+		w.TextDisabled(status),
+	}.Build()
+}
+
+const problemsTableFlags = imgui.TableFlagsBordersInner | imgui.TableFlagsResizable | imgui.TableFlagsNoSavedSettings
+
+func (p *Problems) showProblems() {
+	if p.checked && len(p.problems) == 0 {
+		imgui.TextDisabled("No problems found")
+		return
+	}
+
+	if imgui.BeginTableV("problems_list", 3, problemsTableFlags, imgui.Vec2{}, 0) {
+		imgui.TableSetupColumnV("jump", imgui.TableColumnFlagsWidthFixed, 0, 0)
+		imgui.TableSetupColumnV("coord", imgui.TableColumnFlagsWidthFixed, 0, 0)
+		imgui.TableSetupColumnV("message", imgui.TableColumnFlagsWidthStretch, 0, 0)
+
+		for idx, problem := range p.problems {
+			imgui.TableNextColumn()
+			w.Button(fmt.Sprint(icon.Search+"##jump_to_", idx), func() {
+				p.jumpTo(idx)
+			}).Round(true).Tooltip("Jump To").Build()
+
+			imgui.TableNextColumn()
+			selected := idx == p.selectedIdx
+			if selected {
+				imgui.PushStyleColor(imgui.StyleColorText, style.ColorGold)
+			}
+			imgui.AlignTextToFramePadding()
+			imgui.Text(fmt.Sprintf("X:%03d Y:%03d Z:%d", problem.X, problem.Y, problem.Z))
+			if selected {
+				imgui.PopStyleColor()
+			}
+
+			imgui.TableNextColumn()
+			imgui.AlignTextToFramePadding()
+			imgui.TextDisabled(problem.Rule)
+			imgui.SameLine()
+			imgui.Text(problem.Message)
+		}
+
+		imgui.EndTable()
+	}
+}
+
+func (p *Problems) jumpTo(idx int) {
+	log.Print("do jump to problem:", idx)
+
+	problem := p.problems[idx]
+	ed := p.app.CurrentEditor()
+
+	ed.FocusCameraOnPosition(problem.Coord())
+	if instance := problem.Instance(); instance != nil && ed.Dmm().IsInstanceExist(instance.Id()) {
+		ed.OverlaySetInstanceFlick(instance)
+	}
+
+	p.selectedIdx = idx
+}
@@ -7,9 +7,11 @@ import (
 	"sdmm/internal/app/ui/component"
 	"sdmm/internal/app/ui/cpwsarea/workspace"
 	"sdmm/internal/app/ui/cpwsarea/wschangelog"
+	"sdmm/internal/app/ui/cpwsarea/wsconflict"
 	"sdmm/internal/app/ui/cpwsarea/wscreatemap"
 	"sdmm/internal/app/ui/cpwsarea/wsempty"
 	"sdmm/internal/app/ui/cpwsarea/wsmap"
+	"sdmm/internal/app/ui/cpwsarea/wsmap/pmap/editor"
 	"sdmm/internal/app/ui/cpwsarea/wsprefs"
 	"sdmm/internal/app/ui/dialog"
 	"sdmm/internal/rsc"
@@ -24,6 +26,7 @@ import (
 type App interface {
 	wsempty.App
 	wsmap.App
+	wsconflict.App
 	wscreatemap.App
 	wschangelog.App
 
@@ -117,6 +120,25 @@ func (w *WsArea) OpenMap(dmm *dmmap.Dmm, ws *workspace.Workspace) bool {
 	return true
 }
 
+// OpenConflict opens a workspace to resolve conflicts of the map restored from the file with git conflict markers.
+func (w *WsArea) OpenConflict(dmm, ours, theirs *dmmap.Dmm, conflicts []util.Point, ws *workspace.Workspace) bool {
+	if wsMap, ok := w.findMapWorkspace(dmm.Path); ok {
+		wsMap.SetTriggerFocus(true)
+		return false
+	}
+
+	wsCnt := wsconflict.New(w.app, dmm, ours, theirs, conflicts)
+	if ws != nil {
+		ws.SetContent(wsCnt)
+	} else {
+		ws = workspace.New(wsCnt)
+		w.addWorkspace(ws)
+	}
+	ws.SetTriggerFocus(true)
+
+	return true
+}
+
 func (w *WsArea) Close() {
 	if w.activeWs != nil {
 		w.closeWorkspaceGently(w.activeWs)
@@ -313,7 +335,7 @@ func (w *WsArea) findWorkspaceIdx(ws *workspace.Workspace) int {
 
 func (w *WsArea) findMapWorkspace(path dmmap.DmmPath) (*workspace.Workspace, bool) {
 	for _, ws := range w.workspaces {
-		if wsCnt, ok := ws.Content().(*wsmap.WsMap); ok {
+		if wsCnt, ok := MapContent(ws); ok {
 			if wsCnt.Map().Dmm().Path == path {
 				return ws, true
 			}
@@ -322,10 +344,31 @@ func (w *WsArea) findMapWorkspace(path dmmap.DmmPath) (*workspace.Workspace, boo
 	return nil, false
 }
 
+// FindMapEditor returns the editor of the opened map with the provided absolute path.
+func (w *WsArea) FindMapEditor(path string) (*editor.Editor, bool) {
+	for _, ws := range w.workspaces {
+		if wsCnt, ok := MapContent(ws); ok && wsCnt.Map().Dmm().Path.Absolute == path {
+			return wsCnt.Map().Editor(), true
+		}
+	}
+	return nil, false
+}
+
+// MapEditors returns editors of all opened maps.
+func (w *WsArea) MapEditors() []*editor.Editor {
+	var editors []*editor.Editor
+	for _, ws := range w.findMapWorkspaces() {
+		if wsCnt, ok := MapContent(ws); ok {
+			editors = append(editors, wsCnt.Map().Editor())
+		}
+	}
+	return editors
+}
+
 func (w *WsArea) findMapWorkspaces() []*workspace.Workspace {
 	var workspaces []*workspace.Workspace
 	for _, ws := range w.workspaces {
-		if _, ok := ws.Content().(*wsmap.WsMap); ok {
+		if _, ok := MapContent(ws); ok {
 			workspaces = append(workspaces, ws)
 		}
 	}
@@ -408,3 +451,15 @@ func (w *WsArea) isChangelogHashModified() bool {
 	}
 	return false
 }
+
+// MapContent returns the map content of the workspace, if it has any.
+// Workspaces to resolve map conflicts are map workspaces as well.
+func MapContent(ws *workspace.Workspace) (*wsmap.WsMap, bool) {
+	switch cnt := ws.Content().(type) {
+	case *wsmap.WsMap:
+		return cnt, true
+	case *wsconflict.WsConflict:
+		return cnt.WsMap, true
+	}
+	return nil, false
+}
@@ -0,0 +1,172 @@
+package wsconflict
+
+import (
+	"fmt"
+
+	"sdmm/internal/app/ui/cpwsarea/wsmap/tools"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/imguiext/icon"
+	"sdmm/internal/imguiext/style"
+	w "sdmm/internal/imguiext/widget"
+	"sdmm/internal/util"
+
+	"github.com/SpaiR/imgui-go"
+	"github.com/rs/zerolog/log"
+)
+
+const panelWidth = 250
+
+type resolution int
+
+const (
+	resolveOurs resolution = iota
+	resolveTheirs
+	resolveCurrent // The current content is kept, e.g. when the tile was edited manually.
+)
+
+func (r resolution) String() string {
+	switch r {
+	case resolveOurs:
+		return "ours"
+	case resolveTheirs:
+		return "theirs"
+	case resolveCurrent:
+		return "current"
+	}
+	return "unknown"
+}
+
+const conflictsTableFlags = imgui.TableFlagsBordersInner | imgui.TableFlagsNoSavedSettings
+
+func (ws *WsConflict) showPanel() {
+	w.Layout{
+		w.Text(fmt.Sprintf("Unresolved: %d/%d", ws.unresolvedCount(), len(ws.conflicts))),
+		w.TextDisabled("Selection:"),
+		ws.resolveButtons("selection", ws.selectedConflicts),
+		w.TextDisabled("All:"),
+		ws.resolveButtons("all", ws.unresolvedConflicts),
+	}.Build()
+
+	imgui.Separator()
+
+	if imgui.BeginChild("conflicts_list") {
+		ws.showConflicts()
+	}
+	imgui.EndChild()
+}
+
+func (ws *WsConflict) resolveButtons(id string, coords func() []util.Point) w.Layout {
+	return w.Layout{
+		w.Line(
+			w.Button("Ours##"+id, func() {
+				ws.resolve(coords(), resolveOurs)
+			}).Small(true).Tooltip("Take our version of tiles"),
+			w.Button("Theirs##"+id, func() {
+				ws.resolve(coords(), resolveTheirs)
+			}).Small(true).Tooltip("Take their version of tiles"),
+			w.Button("Current##"+id, func() {
+				ws.resolve(coords(), resolveCurrent)
+			}).Small(true).Tooltip("Keep the current content of tiles, e.g. after a manual edit"),
+		),
+	}
+}
+
+func (ws *WsConflict) showConflicts() {
+	if !imgui.BeginTableV("conflicts", 2, conflictsTableFlags, imgui.Vec2{}, 0) {
+		return
+	}
+
+	for _, coord := range ws.conflicts {
+		imgui.TableNextColumn()
+
+		imgui.AlignTextToFramePadding()
+		coordText := fmt.Sprintf("X:%03d Y:%03d Z:%d", coord.X, coord.Y, coord.Z)
+		if ws.resolved[coord] {
+			imgui.TextDisabled(coordText)
+		} else {
+			imgui.TextColored(style.ColorGold, coordText)
+		}
+
+		imgui.TableNextColumn()
+
+		coord := coord
+		w.Layout{
+			w.Line(
+				w.Button(fmt.Sprint(icon.Search+"##jump_to_", coord), func() {
+					ws.jumpTo(coord)
+				}).Round(true).Tooltip("Jump To"),
+				w.Button(fmt.Sprint("O##ours_", coord), func() {
+					ws.resolve([]util.Point{coord}, resolveOurs)
+				}).Round(true).Tooltip("Take Ours"),
+				w.Button(fmt.Sprint("T##theirs_", coord), func() {
+					ws.resolve([]util.Point{coord}, resolveTheirs)
+				}).Round(true).Tooltip("Take Theirs"),
+			),
+		}.Build()
+	}
+
+	imgui.EndTable()
+}
+
+func (ws *WsConflict) jumpTo(coord util.Point) {
+	ws.Map().Editor().FocusCameraOnPosition(coord)
+}
+
+// Returns conflicting tiles from the selected area. If there is no selected area, the last hovered tile is used.
+func (ws *WsConflict) selectedConflicts() []util.Point {
+	selected := make(map[util.Point]bool)
+	for _, coord := range tools.SelectedTiles() {
+		selected[coord] = true
+	}
+	// Buttons are pressed outside the canvas, so the currently hovered tile is never on the map.
+	if len(selected) == 0 {
+		selected[ws.Map().CanvasState().LastHoveredTile()] = true
+	}
+
+	var coords []util.Point
+	for _, coord := range ws.conflicts {
+		if selected[coord] {
+			coords = append(coords, coord)
+		}
+	}
+	return coords
+}
+
+func (ws *WsConflict) unresolvedConflicts() []util.Point {
+	var coords []util.Point
+	for _, coord := range ws.conflicts {
+		if !ws.resolved[coord] {
+			coords = append(coords, coord)
+		}
+	}
+	return coords
+}
+
+// Resolves provided conflicting tiles. Map changes are committed, so they could be undone as usual.
+func (ws *WsConflict) resolve(coords []util.Point, r resolution) {
+	if len(coords) == 0 {
+		return
+	}
+
+	log.Printf("resolving [%d] conflicts with [%s]", len(coords), r)
+
+	var source *dmmap.Dmm
+	switch r {
+	case resolveOurs:
+		source = ws.ours
+	case resolveTheirs:
+		source = ws.theirs
+	}
+
+	editor := ws.Map().Editor()
+	for _, coord := range coords {
+		if source != nil && source.HasTile(coord) {
+			editor.Dmm().GetTile(coord).InstancesSet(source.GetTile(coord).Instances().Prefabs())
+		}
+		ws.resolved[coord] = true
+	}
+
+	if source != nil {
+		editor.CommitChanges("Resolve Conflicts")
+	}
+}
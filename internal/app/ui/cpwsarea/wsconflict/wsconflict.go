@@ -0,0 +1,125 @@
+package wsconflict
+
+import (
+	"fmt"
+
+	"sdmm/internal/app/ui/cpwsarea/wsmap"
+	"sdmm/internal/app/ui/cpwsarea/wsmap/pmap/overlay"
+	"sdmm/internal/app/ui/dialog"
+	"sdmm/internal/app/window"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/util"
+
+	"github.com/SpaiR/imgui-go"
+	"github.com/rs/zerolog/log"
+)
+
+type App interface {
+	wsmap.App
+}
+
+// WsConflict is a map workspace opened for the map with git conflicts.
+// Besides the usual map editing, it allows to resolve conflicting tiles by picking one of the map versions.
+type WsConflict struct {
+	*wsmap.WsMap
+
+	app App
+
+	// Map versions restored from the conflicted file.
+	ours, theirs *dmmap.Dmm
+
+	conflicts []util.Point
+	resolved  map[util.Point]bool
+
+	panelFocused bool
+}
+
+// New creates a workspace for the provided map, which is a merge result of "ours" and "theirs" maps.
+// Conflicting tiles of the map are expected to be filled with the content of "ours" map.
+func New(app App, dmm, ours, theirs *dmmap.Dmm, conflicts []util.Point) *WsConflict {
+	return &WsConflict{
+		WsMap: wsmap.New(app, dmm),
+
+		app: app,
+
+		ours:   ours,
+		theirs: theirs,
+
+		conflicts: conflicts,
+		resolved:  make(map[util.Point]bool, len(conflicts)),
+	}
+}
+
+func (ws *WsConflict) Name() string {
+	return "Conflicts: " + ws.WsMap.Name()
+}
+
+func (ws *WsConflict) Title() string {
+	return ws.WsMap.Title() + " (Conflicts)"
+}
+
+func (ws *WsConflict) Focused() bool {
+	return ws.WsMap.Focused() || ws.panelFocused
+}
+
+func (ws *WsConflict) Process() {
+	if imgui.BeginChildV("conflicts_panel", imgui.Vec2{X: panelWidth * window.PointSize()}, true, imgui.WindowFlagsNone) {
+		ws.panelFocused = imgui.IsWindowFocusedV(imgui.FocusedFlagsRootAndChildWindows)
+		ws.showPanel()
+	}
+	imgui.EndChild()
+
+	imgui.SameLine()
+
+	if imgui.BeginChildV("conflicts_map", imgui.Vec2{}, false, ws.WsMap.Ini().WindowFlags) {
+		ws.pushConflictsOverlay()
+		ws.WsMap.Process()
+	}
+	imgui.EndChild()
+}
+
+// Save saves the map. If there are unresolved conflicts, the user will be asked to confirm the saving.
+func (ws *WsConflict) Save() bool {
+	unresolved := ws.unresolvedCount()
+	if unresolved == 0 {
+		return ws.WsMap.Save()
+	}
+
+	log.Print("saving map with unresolved conflicts:", unresolved)
+
+	dialog.Open(dialog.TypeConfirmation{
+		Title: "Unresolved Conflicts",
+		Question: fmt.Sprintf(
+			"There are [%d] unresolved conflicts on the map.\n"+
+				"Their current content will be saved. Save anyway?", unresolved),
+		ActionYes: func() {
+			ws.WsMap.Save()
+		},
+	})
+
+	return false
+}
+
+func (ws *WsConflict) unresolvedCount() (count int) {
+	for _, coord := range ws.conflicts {
+		if !ws.resolved[coord] {
+			count++
+		}
+	}
+	return count
+}
+
+// Unresolved conflicts are highlighted with a fill, resolved ones only with a border.
+func (ws *WsConflict) pushConflictsOverlay() {
+	editor := ws.Map().Editor()
+	for _, coord := range ws.conflicts {
+		if coord.Z != editor.ActiveLevel() {
+			continue
+		}
+		if ws.resolved[coord] {
+			editor.OverlayPushTile(coord, overlay.ColorEmpty, overlay.ColorConflictResolvedTileBorder)
+		} else {
+			editor.OverlayPushTile(coord, overlay.ColorConflictTileFill, overlay.ColorConflictTileBorder)
+		}
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func (ws *WsCreateMap) save(newPath string) {
+func (ws *WsCreateMap) save(newPath string) error {
 	log.Print("saving new map:", newPath)
 
 	// we assume the data is OK at this point
@@ -37,5 +37,5 @@ func (ws *WsCreateMap) save(newPath string) {
 		}
 	}
 
-	data.Save()
+	return data.Save()
 }
@@ -13,6 +13,7 @@ import (
 	"sdmm/internal/imguiext/markdown"
 	"sdmm/internal/imguiext/style"
 	w "sdmm/internal/imguiext/widget"
+	"sdmm/internal/util"
 
 	"github.com/SpaiR/imgui-go"
 	"github.com/rs/zerolog/log"
@@ -139,7 +140,11 @@ func (ws *WsCreateMap) tryCreateMap() {
 
 		log.Print("saving new map to:", file)
 
-		ws.save(file)
+		if err := ws.save(file); err != nil {
+			log.Print("unable to save new map:", err)
+			util.ShowErrorDialog("Unable to save the map: " + err.Error())
+			return
+		}
 		ws.app.DoLoadResourceV(file, ws.Root())
 	} else {
 		log.Print("unable to get new map save location:", err)
@@ -47,6 +47,7 @@ func (c *Canvas) Dispose() {
 		log.Print("disposing...")
 		gl.DeleteFramebuffers(1, &c.frameBuffer)
 		gl.DeleteTextures(1, &c.texture)
+		c.render.Dispose()
 		log.Print("disposed")
 	})
 }
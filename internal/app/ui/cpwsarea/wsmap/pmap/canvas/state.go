@@ -2,6 +2,7 @@ package canvas
 
 import (
 	"sdmm/internal/dmapi/dmmap/dmminstance"
+	"sdmm/internal/dmapi/dmmap/dmmproj"
 	"sdmm/internal/util"
 )
 
@@ -14,7 +15,7 @@ type State struct {
 
 	relMouseX, relMouseY int
 
-	iconSize   int
+	proj       dmmproj.Projection
 	maxX, maxY int
 }
 
@@ -26,45 +27,39 @@ func (s *State) SetMaxY(maxY int) {
 	s.maxY = maxY
 }
 
+func (s *State) SetProjection(proj dmmproj.Projection) {
+	s.proj = proj
+}
+
 func (s *State) SetHoveredInstance(hoveredInstance *dmminstance.Instance) {
 	s.hoveredInstance = hoveredInstance
 }
 
-func NewState(maxX, maxY, iconSize int) *State {
+func NewState(maxX, maxY int, proj dmmproj.Projection) *State {
 	return &State{
-		maxX:     maxX,
-		maxY:     maxY,
-		iconSize: iconSize,
+		maxX: maxX,
+		maxY: maxY,
+		proj: proj,
 	}
 }
 
 func (s *State) SetMousePosition(relMouseX, relMouseY, level int) {
 	s.relMouseX, s.relMouseY = relMouseX, relMouseY
 
+	// Mouse position coords, adjusted to DMM coord system.
+	mapMouseX, mapMouseY := s.proj.TileAt(float32(relMouseX), float32(relMouseY))
+
 	// We are out of bounds for sure.
-	if relMouseX < 0 || relMouseY < 0 || level < 0 {
+	if mapMouseX < 1 || mapMouseY < 1 || level < 0 {
 		s.hoveredTile = util.Point{}
 		s.hoveredTileBounds = util.Bounds{}
 		return
 	}
 
-	// Mouse position coords, but local to the tiles.
-	localMouseX := relMouseX / s.iconSize
-	localMouseY := relMouseY / s.iconSize
-
-	// Local coords, but adjusted to DMM coord system.
-	mapMouseX := localMouseX + 1
-	mapMouseY := localMouseY + 1
-
 	s.hoveredTile = util.Point{X: mapMouseX, Y: mapMouseY, Z: level}
 	s.lastHoveredTile = s.hoveredTile.Copy()
 
-	s.hoveredTileBounds = util.Bounds{
-		X1: float32(localMouseX * s.iconSize),
-		Y1: float32(localMouseY * s.iconSize),
-		X2: float32(localMouseX*s.iconSize + s.iconSize),
-		Y2: float32(localMouseY*s.iconSize + s.iconSize),
-	}
+	s.hoveredTileBounds = s.proj.TileBounds(mapMouseX, mapMouseY)
 }
 
 func (s *State) HoveredInstance() *dmminstance.Instance {
@@ -35,11 +35,11 @@ func (p *PaneMap) processCameraZoom() {
 	// Support for alternative scroll behaviour.
 	// Pan with a scroll, zoom if a space key pressed.
 	if p.app.Prefs().Controls.AltScrollBehaviour && !imgui.IsKeyDown(int(glfw.KeySpace)) {
-		shift := p.calcManualCanvasTranslateShiftV(mouseWheel)
+		shiftX, shiftY := p.calcManualCanvasTranslateShiftV(mouseWheel)
 		if imguiext.IsCtrlDown() {
-			p.translateCanvas(shift, 0)
+			p.translateCanvas(shiftX, 0)
 		} else {
-			p.translateCanvas(0, shift)
+			p.translateCanvas(0, shiftY)
 		}
 		return
 	}
@@ -61,16 +61,16 @@ func (p *PaneMap) processCameraZoom() {
 	camera.Zoom(zoomIn, scaleFactor)
 }
 
-func (p *PaneMap) calcManualCanvasTranslateShift() float32 {
+func (p *PaneMap) calcManualCanvasTranslateShift() (shiftX, shiftY float32) {
 	return p.calcManualCanvasTranslateShiftV(1)
 }
 
-func (p *PaneMap) calcManualCanvasTranslateShiftV(mod float32) float32 {
-	value := mod * float32(dmmap.WorldIconSize)
+func (p *PaneMap) calcManualCanvasTranslateShiftV(mod float32) (shiftX, shiftY float32) {
+	shiftX, shiftY = mod*float32(dmmap.WorldIconWidth), mod*float32(dmmap.WorldIconHeight)
 	if imguiext.IsShiftDown() {
-		return value * 5
+		return shiftX * 5, shiftY * 5
 	}
-	return value
+	return shiftX, shiftY
 }
 
 func (p *PaneMap) translateCanvas(shiftX, shiftY float32) {
@@ -7,6 +7,7 @@ import (
 	"sdmm/internal/dmapi/dm"
 	"sdmm/internal/dmapi/dmmap"
 	"sdmm/internal/dmapi/dmmap/dmminstance"
+	"sdmm/internal/dmapi/dmmheat"
 	"sdmm/internal/util"
 
 	"github.com/SpaiR/imgui-go"
@@ -15,9 +16,12 @@ import (
 const flickDurationSec = .5
 
 func (p *PaneMap) processCanvasOverlay() {
+	p.processCanvasOverlayLighting() // Goes first to not cover other overlays.
+	p.processCanvasOverlayHeatmap()
 	p.processCanvasOverlayTools()
 	p.processCanvasOverlayFlick()
 	p.processCanvasOverlayAreasZones()
+	p.processCanvasOverlayComparison()
 }
 
 func (p *PaneMap) processCanvasOverlayTools() {
@@ -108,22 +112,12 @@ func (p *PaneMap) processCanvasOverlayAreasZones() {
 
 			var borders []util.Bounds
 
-			iconSize := float32(dmmap.WorldIconSize)
+			proj := dmmap.WorldProjection()
 
-			x := float32(areaBorder.Coord.X-1) * iconSize
-			y := float32(areaBorder.Coord.Y-1) * iconSize
-
-			if areaBorder.Dirs&dm.DirNorth != 0 {
-				borders = append(borders, util.Bounds{X1: x, Y1: y + iconSize, X2: x + iconSize, Y2: y + iconSize})
-			}
-			if areaBorder.Dirs&dm.DirEast != 0 {
-				borders = append(borders, util.Bounds{X1: x + iconSize, Y1: y, X2: x + iconSize, Y2: y + iconSize})
-			}
-			if areaBorder.Dirs&dm.DirSouth != 0 {
-				borders = append(borders, util.Bounds{X1: x, Y1: y, X2: x + iconSize, Y2: y})
-			}
-			if areaBorder.Dirs&dm.DirWest != 0 {
-				borders = append(borders, util.Bounds{X1: x, Y1: y, X2: x, Y2: y + iconSize})
+			for _, dir := range []int{dm.DirNorth, dm.DirEast, dm.DirSouth, dm.DirWest} {
+				if areaBorder.Dirs&dir != 0 {
+					borders = append(borders, proj.TileEdge(areaBorder.Coord.X, areaBorder.Coord.Y, dir))
+				}
 			}
 
 			p.canvasOverlay.PushAreaBorder(canvas.OverlayAreaBorder{
@@ -134,6 +128,104 @@ func (p *PaneMap) processCanvasOverlayAreasZones() {
 	}
 }
 
+func (p *PaneMap) processCanvasOverlayComparison() {
+	comparison, ok := p.editor.Comparison()
+	if !ok {
+		return
+	}
+
+	for _, tileDiff := range comparison.Tiles() {
+		if tileDiff.Z != p.activeLevel {
+			continue
+		}
+
+		// Tiles with both added and removed prefabs are shown as edited.
+		var colFill util.Color
+		switch {
+		case len(tileDiff.Edited) == 0 && len(tileDiff.Removed) == 0:
+			colFill = overlay.ColorDiffAddedTileFill
+		case len(tileDiff.Edited) == 0 && len(tileDiff.Added) == 0:
+			colFill = overlay.ColorDiffRemovedTileFill
+		default:
+			colFill = overlay.ColorDiffEditedTileFill
+		}
+
+		p.editor.OverlayPushTile(tileDiff.Coord(), colFill, overlay.ColorDiffChangedTileBorder)
+	}
+}
+
+// Only areas which are visible on the canvas are pushed.
+func (p *PaneMap) processCanvasOverlayLighting() {
+	if !LightingRendering {
+		p.editor.DropLighting()
+		return
+	}
+
+	view := p.canvas.Render().ViewportBounds(p.size.X, p.size.Y)
+	for _, area := range p.editor.LightingAreas() {
+		if b := area.Bounds(); b.X1 < view.X2 && view.X1 < b.X2 && b.Y1 < view.Y2 && view.Y1 < b.Y2 {
+			p.canvasOverlay.PushArea(area)
+		}
+	}
+}
+
+// Tiles are colored relative to the biggest value among visible tiles, so the heatmap is always contrast.
+func (p *PaneMap) processCanvasOverlayHeatmap() {
+	p.heatmapMaxValue = 0
+
+	metric, ok := dmmheat.Find(HeatmapMetric)
+	if !ok {
+		p.editor.DropHeatmap()
+		return
+	}
+
+	query := heatmapQuery(metric)
+	if metric.Check(query) != nil {
+		p.editor.DropHeatmap()
+		return
+	}
+
+	coords := p.visibleTiles()
+	values := make([]int, len(coords))
+	for idx, coord := range coords {
+		values[idx] = p.editor.HeatmapValue(metric, query, coord.X, coord.Y)
+		p.heatmapMaxValue = max(p.heatmapMaxValue, values[idx])
+	}
+
+	for idx, coord := range coords {
+		if values[idx] > 0 {
+			p.editor.OverlayPushTile(coord, dmmheat.Color(values[idx], p.heatmapMaxValue), overlay.ColorEmpty)
+		}
+	}
+}
+
+// Returns coordinates of tiles on the active level, which are visible on the canvas.
+func (p *PaneMap) visibleTiles() []util.Point {
+	proj := dmmap.WorldProjection()
+	view := p.canvas.Render().ViewportBounds(p.size.X, p.size.Y)
+
+	// Isometric tiles are rotated, so the range is taken from all corners of the view.
+	// It's expanded by one tile, since tiles at corners are only partially visible.
+	x1, y1, x2, y2 := p.dmm.MaxX, p.dmm.MaxY, 1, 1
+	for _, corner := range [][2]float32{{view.X1, view.Y1}, {view.X1, view.Y2}, {view.X2, view.Y1}, {view.X2, view.Y2}} {
+		x, y := proj.TileAt(corner[0], corner[1])
+		x1, y1 = min(x1, x), min(y1, y)
+		x2, y2 = max(x2, x), max(y2, y)
+	}
+	x1, y1 = max(x1-1, 1), max(y1-1, 1)
+	x2, y2 = min(x2+1, p.dmm.MaxX), min(y2+1, p.dmm.MaxY)
+
+	var coords []util.Point
+	for y := y1; y <= y2; y++ {
+		for x := x1; x <= x2; x++ {
+			if proj.TileBounds(x, y).ContainsV(view) {
+				coords = append(coords, util.Point{X: x, Y: y, Z: p.activeLevel})
+			}
+		}
+	}
+	return coords
+}
+
 func (p *PaneMap) PushUnitHighlight(instance *dmminstance.Instance, color util.Color) {
 	if instance != nil {
 		p.canvasOverlay.PushUnit(canvas.HighlightUnit{
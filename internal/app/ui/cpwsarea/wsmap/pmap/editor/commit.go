@@ -3,6 +3,8 @@ package editor
 import (
 	"sdmm/internal/app/command"
 	"sdmm/internal/app/window"
+	"sdmm/internal/dmapi/dmenv"
+	"sdmm/internal/dmapi/dmmap"
 	"sdmm/internal/util"
 )
 
@@ -30,6 +32,8 @@ func (e *Editor) onMapSizeChange(maxZ int) {
 	e.pMap.Snapshot().Sync() // Do a full snapshots sync.
 	e.pMap.OnMapSizeChange()
 	e.updateAreasZones()
+	e.updateComparison()
+	e.DropLighting()
 }
 
 // CommitChanges triggers a snapshot to commit changes and create a patch between two map states.
@@ -51,23 +55,31 @@ func (e *Editor) commitChanges(commitMsg string) {
 
 	// Ensure that the user has updated visuals.
 	e.updateAreasZones()
+	e.updateComparison()
+	e.updateLighting(tilesToUpdate)
+	e.updateHeatmap()
 	e.updateBucket(activeLevel, tilesToUpdate)
 
-	e.app.CommandStorage().Push(command.Make(commitMsg, func() {
-		e.pMap.Snapshot().GoTo(stateId - 1)
-		e.updateAreasZones()
-		e.updateBucket(activeLevel, tilesToUpdate)
-		e.dmm.PersistPrefabs()
-		e.app.SyncPrefabs()
-		e.app.SyncVarEditor()
-	}, func() {
-		e.pMap.Snapshot().GoTo(stateId)
-		e.updateAreasZones()
-		e.updateBucket(activeLevel, tilesToUpdate)
-		e.dmm.PersistPrefabs()
-		e.app.SyncPrefabs()
-		e.app.SyncVarEditor()
-	}))
+	undo, redo := e.stateChangeActions(stateId, activeLevel, tilesToUpdate)
+	e.app.CommandStorage().Push(command.Make(commitMsg, undo, redo))
+}
+
+// Returns actions to move the snapshot to the previous state and back to the provided one.
+func (e *Editor) stateChangeActions(stateId, activeLevel int, tilesToUpdate []util.Point) (undo, redo func()) {
+	goTo := func(stateId int) func() {
+		return func() {
+			e.pMap.Snapshot().GoTo(stateId)
+			e.updateAreasZones()
+			e.updateComparison()
+			e.updateLighting(tilesToUpdate)
+			e.updateHeatmap()
+			e.updateBucket(activeLevel, tilesToUpdate)
+			e.dmm.PersistPrefabs()
+			e.app.SyncPrefabs()
+			e.app.SyncVarEditor()
+		}
+	}
+	return goTo(stateId - 1), goTo(stateId)
 }
 
 // We need to update bucket in the main thread, since it can have OpenGL operations.
@@ -77,3 +89,19 @@ func (e *Editor) updateBucket(activeLevel int, tilesToUpdate []util.Point) {
 		e.pMap.Canvas().Render().UpdateBucketV(e.dmm, activeLevel, tilesToUpdate)
 	})
 }
+
+// ReloadEnvironment applies the reloaded environment to the edited map.
+// Instances of removed types are replaced with obsolete placeholders, which is committed as a regular change.
+func (e *Editor) ReloadEnvironment(dme *dmenv.Dme, config dmmap.ObsoleteConfig) {
+	if len(e.dmm.ReplaceUnknown(dme, config)) > 0 {
+		if stateId, tilesToUpdate := e.pMap.Snapshot().Commit(); len(tilesToUpdate) > 0 {
+			// The map could be not active, so the command is pushed to its own stack.
+			undo, redo := e.stateChangeActions(stateId, e.pMap.ActiveLevel(), tilesToUpdate)
+			e.app.CommandStorage().PushV(e.dmm.Path.Absolute, command.Make("Environment Reload", undo, redo))
+		}
+	}
+	e.updateAreasZones()
+	e.updateComparison()
+	e.DropLighting()
+	e.pMap.OnEnvironmentReload()
+}
@@ -0,0 +1,82 @@
+package editor
+
+import (
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/dmapi/dmmap/dmmdata"
+	"sdmm/internal/dmapi/dmmdiff"
+	"sdmm/internal/util"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Comparison stores the result of the current map comparison with another version of it.
+type Comparison struct {
+	// Name of the compared version: a file path or a git revision.
+	Name string
+
+	source dmmdiff.Source
+	result dmmdiff.Result
+	tiles  map[util.Point]dmmdiff.TileDiff
+}
+
+// Tiles returns changed tiles. Changes are made from the compared version to the current map.
+func (c *Comparison) Tiles() []dmmdiff.TileDiff {
+	return c.result.Tiles
+}
+
+// Tile returns changes for the provided tile.
+func (c *Comparison) Tile(coord util.Point) (dmmdiff.TileDiff, bool) {
+	tileDiff, ok := c.tiles[coord]
+	return tileDiff, ok
+}
+
+// CompareWith compares the current map with the provided map data.
+// The comparison is updated with every map change, until it's cleared with the CompareClear method.
+func (e *Editor) CompareWith(name string, data *dmmdata.DmmData) {
+	log.Print("comparing map with:", name)
+	e.comparison = &Comparison{
+		Name:   name,
+		source: dmmdiff.FromData(data),
+	}
+	e.updateComparison()
+}
+
+// CompareClear removes the current comparison.
+func (e *Editor) CompareClear() {
+	e.comparison = nil
+}
+
+// Comparison returns the current comparison, if there is any.
+func (e *Editor) Comparison() (*Comparison, bool) {
+	return e.comparison, e.comparison != nil
+}
+
+func (e *Editor) updateComparison() {
+	comparison := e.comparison
+	if comparison == nil {
+		return
+	}
+
+	result := dmmdiff.Diff(comparison.source, dmmSource{e.dmm})
+	tiles := make(map[util.Point]dmmdiff.TileDiff, len(result.Tiles))
+	for _, tileDiff := range result.Tiles {
+		tiles[tileDiff.Coord()] = tileDiff
+	}
+
+	comparison.result = result
+	comparison.tiles = tiles
+
+	log.Print("changed tiles in comparison:", len(result.Tiles))
+}
+
+type dmmSource struct {
+	dmm *dmmap.Dmm
+}
+
+func (s dmmSource) Size() dmmdiff.Size {
+	return dmmdiff.Size{X: s.dmm.MaxX, Y: s.dmm.MaxY, Z: s.dmm.MaxZ}
+}
+
+func (s dmmSource) TileContent(coord util.Point) dmmdata.Prefabs {
+	return s.dmm.GetTile(coord).Instances().Prefabs()
+}
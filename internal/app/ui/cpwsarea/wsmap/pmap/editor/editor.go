@@ -6,10 +6,13 @@ import (
 	"sdmm/internal/app/ui/cpwsarea/wsmap/pmap/canvas"
 	"sdmm/internal/app/ui/cpwsarea/wsmap/pmap/overlay"
 	"sdmm/internal/dmapi/dm"
+	"sdmm/internal/dmapi/dmenv"
 	"sdmm/internal/dmapi/dmmap"
 	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
 	"sdmm/internal/dmapi/dmmap/dmminstance"
+	"sdmm/internal/dmapi/dmmap/dmmproj"
 	"sdmm/internal/dmapi/dmmclip"
+	"sdmm/internal/dmapi/dmmlight"
 	"sdmm/internal/dmapi/dmmsnap"
 	"sdmm/internal/util"
 
@@ -26,6 +29,19 @@ type Editor struct {
 	flickInstance []overlay.FlickInstance
 
 	areasZones []AreaZone
+
+	comparison *Comparison
+
+	lighting      *dmmlight.Map
+	lightingLevel int
+
+	// Overlay areas of the light map. Built from the light map, when it's changed.
+	lightingAreas     []canvas.OverlayArea
+	lightingAreasProj dmmproj.Projection
+
+	// Values of the heatmap metric for all tiles of the active level. Computed, when the map is changed.
+	heatmapValues []int
+	heatmapKey    heatmapKey
 }
 
 func (e *Editor) SetFlickAreas(flickAreas []overlay.FlickArea) {
@@ -68,6 +84,7 @@ type app interface {
 	SyncVarEditor()
 
 	Prefs() prefs.Prefs
+	LoadedEnvironment() *dmenv.Dme
 }
 
 type attachedMap interface {
@@ -86,6 +103,7 @@ type attachedMap interface {
 	PushAreaHover(bounds util.Bounds, fillColor, borderColor util.Color)
 
 	OnMapSizeChange()
+	OnEnvironmentReload()
 }
 
 func New(app app, attachedMap attachedMap, dmm *dmmap.Dmm) *Editor {
@@ -122,6 +140,16 @@ func (e *Editor) UpdateCanvasByTiles(tiles []dmmap.Tile) {
 	e.UpdateCanvasByCoords(coords)
 }
 
+// UpdateCanvas updates the whole canvas.
+func (e *Editor) UpdateCanvas() {
+	e.pMap.Canvas().Render().ResetBucket(e.dmm)
+}
+
+// UpdateCanvasByIcons updates the canvas for tiles with instances of the provided icons.
+func (e *Editor) UpdateCanvasByIcons(icons []string) {
+	e.pMap.Canvas().Render().UpdateBucketIcons(e.dmm, icons)
+}
+
 // SelectedPrefab returns a currently selected prefab.
 func (e *Editor) SelectedPrefab() (*dmmprefab.Prefab, bool) {
 	return e.app.SelectedPrefab()
@@ -141,25 +169,33 @@ func (e *Editor) ReplacePrefab(oldPrefab, newPrefab *dmmprefab.Prefab) {
 // FocusCamera moves the camera in a way, so it will be centered on the instance.
 func (e *Editor) FocusCamera(i *dmminstance.Instance) {
 	relPos := i.Coord()
-	absPos := util.Point{X: (relPos.X - 1) * -dmmap.WorldIconSize, Y: (relPos.Y - 1) * -dmmap.WorldIconSize, Z: relPos.Z}
+	absX, absY := dmmap.WorldProjection().TilePos(relPos.X, relPos.Y)
 
 	camera := e.pMap.Canvas().Render().Camera
-	camera.ShiftX = e.pMap.Size().X/2/camera.Scale + float32(absPos.X)
-	camera.ShiftY = e.pMap.Size().Y/2/camera.Scale + float32(absPos.Y)
+	camera.ShiftX = e.pMap.Size().X/2/camera.Scale - absX
+	camera.ShiftY = e.pMap.Size().Y/2/camera.Scale - absY
 
 	e.pMap.SetActiveLevel(relPos.Z)
 }
 
 // FocusCameraOnPosition centers the camera on given coordinates.
 func (e *Editor) FocusCameraOnPosition(coord util.Point) {
-	absPos := util.Point{X: (coord.X - 1) * -dmmap.WorldIconSize, Y: (coord.Y - 1) * -dmmap.WorldIconSize, Z: coord.Z}
+	e.FocusCameraOnPositionV(coord, true)
+}
+
+// FocusCameraOnPositionV centers the camera on given coordinates.
+// If flick is true, the tile is highlighted for a moment.
+func (e *Editor) FocusCameraOnPositionV(coord util.Point, flick bool) {
+	absX, absY := dmmap.WorldProjection().TilePos(coord.X, coord.Y)
 
 	camera := e.pMap.Canvas().Render().Camera
-	camera.ShiftX = e.pMap.Size().X/2/camera.Scale + float32(absPos.X)
-	camera.ShiftY = e.pMap.Size().Y/2/camera.Scale + float32(absPos.Y)
+	camera.ShiftX = e.pMap.Size().X/2/camera.Scale - absX
+	camera.ShiftY = e.pMap.Size().Y/2/camera.Scale - absY
 
 	e.pMap.SetActiveLevel(coord.Z)
-	e.OverlaySetTileFlick(coord)
+	if flick {
+		e.OverlaySetTileFlick(coord)
+	}
 }
 
 func (e *Editor) ZoomLevel() float32 {
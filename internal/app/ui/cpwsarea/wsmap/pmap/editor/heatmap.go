@@ -0,0 +1,57 @@
+package editor
+
+import (
+	"sdmm/internal/app/window"
+	"sdmm/internal/dmapi/dmmheat"
+	"sdmm/internal/util"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Everything the heatmap values depend on, except the map content.
+type heatmapKey struct {
+	metric, query string
+	level         int
+	maxX, maxY    int
+}
+
+// HeatmapValue returns the value of the metric for the tile of the active level.
+// Values of all tiles are computed once and then only when the map, the metric or its query is changed,
+// since there are too many tiles to compute them on every frame.
+func (e *Editor) HeatmapValue(metric dmmheat.Metric, query string, x, y int) int {
+	key := heatmapKey{
+		metric: metric.Name,
+		query:  query,
+		level:  e.pMap.ActiveLevel(),
+		maxX:   e.dmm.MaxX,
+		maxY:   e.dmm.MaxY,
+	}
+
+	if e.heatmapValues == nil || e.heatmapKey != key {
+		e.heatmapValues = heatmapValues(e, metric.Value(query), key)
+		e.heatmapKey = key
+		log.Printf("heatmap values computed: [%s], query: [%s], level: [%d]", key.metric, key.query, key.level)
+	}
+
+	return e.heatmapValues[(y-1)*key.maxX+(x-1)]
+}
+
+// DropHeatmap removes computed heatmap values.
+func (e *Editor) DropHeatmap() {
+	e.heatmapValues = nil
+}
+
+// Values are read by the canvas overlay, so they're dropped in the main thread.
+func (e *Editor) updateHeatmap() {
+	window.RunLater(e.DropHeatmap)
+}
+
+func heatmapValues(e *Editor, value dmmheat.TileValue, key heatmapKey) []int {
+	values := make([]int, 0, key.maxX*key.maxY)
+	for y := 1; y <= key.maxY; y++ {
+		for x := 1; x <= key.maxX; x++ {
+			values = append(values, value(e.dmm.GetTile(util.Point{X: x, Y: y, Z: key.level}).Instances()))
+		}
+	}
+	return values
+}
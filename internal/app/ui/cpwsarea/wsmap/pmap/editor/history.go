@@ -0,0 +1,85 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"sdmm/internal/app/command"
+	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
+	"sdmm/internal/dmapi/dmmsave"
+	"sdmm/internal/dmapi/dmmsnap"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RestoreHistory loads snapshot patches saved in the previous session and fills the command stack with them.
+// Nothing is restored, if there is no history or the map file was changed since the history was saved.
+func (e *Editor) RestoreHistory(path string) {
+	snapshot := e.pMap.Snapshot()
+
+	if err := snapshot.LoadHistory(e.app.LoadedEnvironment(), path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return
+		}
+		if errors.Is(err, dmmsnap.ErrHistoryOutdated) {
+			log.Print("history is outdated:", path)
+		} else {
+			log.Printf("unable to restore history [%s]: %v", path, err)
+		}
+		// The history is useless for the current map file, so there is no need to keep it.
+		_ = os.Remove(path)
+		return
+	}
+
+	var undo, redo []command.Command
+	for stateId := 1; stateId <= snapshot.StatesCount(); stateId++ {
+		name := fmt.Sprint("Restored Change #", stateId)
+		tilesToUpdate := snapshot.PatchCoords(stateId)
+		undoAction, redoAction := e.stateChangeActions(stateId, tilesToUpdate[0].Z, tilesToUpdate)
+
+		if stateId <= snapshot.StateId() {
+			undo = append(undo, command.Make(name, undoAction, redoAction))
+		} else {
+			// Commands in the redo stack have swapped actions, and the nearest state is the last one.
+			redo = append([]command.Command{command.Make(name, redoAction, undoAction)}, redo...)
+		}
+	}
+
+	e.app.CommandStorage().Restore(e.dmm.Path.Absolute, undo, redo)
+}
+
+// SaveHistory writes snapshot patches to the file, so they could be restored in the next session.
+// The current map state is expected to be the same as the map file on the disk.
+// If variables were sanitized on save, they are sanitized in the history too, since the file has no such variables.
+func (e *Editor) SaveHistory(path string, sanitizeVariables bool) {
+	snapshot := e.pMap.Snapshot()
+	if snapshot.StatesCount() == 0 {
+		return
+	}
+
+	var sanitize func(*dmmprefab.Prefab) *dmmprefab.Prefab
+	if sanitizeVariables {
+		dme := e.app.LoadedEnvironment()
+		sanitize = func(prefab *dmmprefab.Prefab) *dmmprefab.Prefab {
+			return dmmsave.SanitizePrefab(dme, prefab)
+		}
+	}
+
+	if err := snapshot.SaveHistoryV(path, sanitize); err != nil {
+		log.Printf("unable to save history [%s]: %v", path, err)
+	}
+}
+
+// RecoverJournal applies changes from the journal left by the previous session, which wasn't closed properly.
+// Recovered changes are committed as a single change, so they could be undone or saved.
+func (e *Editor) RecoverJournal(path string) error {
+	tilesToUpdate, err := dmmsnap.ReplayJournal(e.app.LoadedEnvironment(), path, e.dmm)
+	if err != nil {
+		return err
+	}
+	if len(tilesToUpdate) != 0 {
+		e.commitChanges("Recover Unsaved Changes")
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package editor
+
+import (
+	"sdmm/internal/app/ui/cpwsarea/wsmap/pmap/canvas"
+	"sdmm/internal/app/ui/cpwsarea/wsmap/pmap/overlay"
+	"sdmm/internal/app/window"
+	"sdmm/internal/dmapi/dm"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/dmapi/dmmap/dmmproj"
+	"sdmm/internal/dmapi/dmmlight"
+	"sdmm/internal/util"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Maximum opacity of the light color over a fully lit tile.
+const lightingTintAlpha = .25
+
+// Lighting returns the light map of the active level.
+// The map is computed on the first call and then updated with every map change, until it's dropped.
+func (e *Editor) Lighting() *dmmlight.Map {
+	if activeLevel := e.pMap.ActiveLevel(); e.lighting == nil || e.lightingLevel != activeLevel {
+		log.Printf("computing lighting for level: [%d]", activeLevel)
+		e.lighting = dmmlight.New(lightSource{dmm: e.dmm, z: activeLevel})
+		e.lightingLevel = activeLevel
+		e.lightingAreas = nil
+	}
+	return e.lighting
+}
+
+// LightingAreas returns overlay areas of the light map of the active level.
+// Areas are built once and then only when the light map or the world projection is changed,
+// since there are too many tiles to compute their light on every frame.
+func (e *Editor) LightingAreas() []canvas.OverlayArea {
+	lighting := e.Lighting()
+	if proj := dmmap.WorldProjection(); e.lightingAreas == nil || e.lightingAreasProj != proj {
+		e.lightingAreas = lightingAreas(lighting, e.dmm.MaxX, e.dmm.MaxY, proj)
+		e.lightingAreasProj = proj
+		log.Printf("lighting areas built: [%d]", len(e.lightingAreas))
+	}
+	return e.lightingAreas
+}
+
+// DropLighting removes the computed light map, so it's not updated anymore.
+func (e *Editor) DropLighting() {
+	e.lighting = nil
+	e.lightingAreas = nil
+}
+
+// The light map is read by the canvas overlay, so it's updated in the main thread.
+func (e *Editor) updateLighting(tilesToUpdate []util.Point) {
+	window.RunLater(func() {
+		if e.lighting == nil {
+			return
+		}
+
+		coords := make([]util.Point, 0, len(tilesToUpdate))
+		for _, coord := range tilesToUpdate {
+			if coord.Z == e.lightingLevel {
+				coords = append(coords, coord)
+			}
+		}
+		e.lighting.Update(coords)
+		e.lightingAreas = nil
+	})
+}
+
+// The darkness and the tint of the tile are combined into the single color.
+// On grid maps tiles with the same color in a row are combined into the single area too.
+func lightingAreas(lighting *dmmlight.Map, maxX, maxY int, proj dmmproj.Projection) []canvas.OverlayArea {
+	// Not nil even without areas, since nil means that areas should be built.
+	areas := make([]canvas.OverlayArea, 0, maxY)
+
+	pushArea := func(bounds util.Bounds, color util.Color) {
+		if color.A() != 0 {
+			areas = append(areas, canvas.OverlayArea{Bounds_: bounds, FillColor_: color, BorderColor_: overlay.ColorEmpty})
+		}
+	}
+
+	for y := 1; y <= maxY; y++ {
+		startX, startColor := 1, lightingColor(lighting.Light(1, y))
+		for x := 1; x <= maxX; x++ {
+			color := lightingColor(lighting.Light(x, y))
+			if proj.Format == dm.MapFormatIsometric {
+				pushArea(proj.TileBounds(x, y), color)
+				continue
+			}
+			if color != startColor {
+				pushArea(proj.AreaBounds(startX, y, x-1, y), startColor)
+				startX, startColor = x, color
+			}
+		}
+		if proj.Format != dm.MapFormatIsometric {
+			pushArea(proj.AreaBounds(startX, y, maxX, y), startColor)
+		}
+	}
+
+	return areas
+}
+
+// White light only removes the darkness, colored one tints the tile as well.
+// The color is the same as the tint drawn over the darkness.
+func lightingColor(r, g, b float32) util.Color {
+	lum := min(max(r, g, b), 1)
+
+	var darknessA float32
+	if lum < 1 {
+		darknessA = overlay.ColorLightingDarkness.A() * (1 - lum)
+	}
+
+	var tintR, tintG, tintB, tintA float32
+	if lum > 0 && (r != g || g != b) {
+		brightest := max(r, g, b)
+		tintR, tintG, tintB, tintA = r/brightest, g/brightest, b/brightest, lightingTintAlpha*lum
+	}
+
+	a := tintA + darknessA*(1-tintA)
+	if a == 0 {
+		return overlay.ColorEmpty
+	}
+
+	darkness := overlay.ColorLightingDarkness
+	colorPart := func(tint, dark float32) float32 {
+		return (tint*tintA + dark*darknessA*(1-tintA)) / a
+	}
+	return util.MakeColor(colorPart(tintR, darkness.R()), colorPart(tintG, darkness.G()), colorPart(tintB, darkness.B()), a)
+}
+
+type lightSource struct {
+	dmm *dmmap.Dmm
+	z   int
+}
+
+func (s lightSource) Size() (int, int) {
+	return s.dmm.MaxX, s.dmm.MaxY
+}
+
+func (s lightSource) Tile(x, y int) (tile dmmlight.Tile) {
+	for _, instance := range s.dmm.GetTile(util.Point{X: x, Y: y, Z: s.z}).Instances() {
+		vars := instance.Prefab().Vars()
+
+		if vars.IntV("opacity", 0) != 0 {
+			tile.Opaque = true
+		}
+
+		lightRange := vars.FloatV("light_range", 0)
+		if lightRange <= 0 {
+			continue
+		}
+
+		color := util.MakeColor(1, 1, 1, 1)
+		if lightColor, _ := vars.Text("light_color"); lightColor != "" {
+			color = util.ParseColor(lightColor)
+		}
+
+		tile.Lights = append(tile.Lights, dmmlight.Light{
+			Range: lightRange,
+			Power: vars.FloatV("light_power", 1),
+			Color: color,
+		})
+	}
+	return tile
+}
@@ -0,0 +1,34 @@
+package editor
+
+import (
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/util"
+)
+
+// MinimapTexture returns the texture with the minimap of the active level.
+// The minimap is kept by the canvas render, so it's updated together with map tiles.
+// Paths hidden on the canvas are hidden on the minimap too.
+func (e *Editor) MinimapTexture() uint32 {
+	minimap := e.pMap.Canvas().Render().Minimap(e.dmm, e.pMap.ActiveLevel())
+	minimap.SetFilter(e.app.PathsFilter())
+	return minimap.Texture(e.dmm)
+}
+
+// ViewportTiles returns bounds of tiles visible on the canvas. Bounds could be out of the map.
+func (e *Editor) ViewportTiles() util.Bounds {
+	proj := dmmap.WorldProjection()
+	view := e.pMap.Canvas().Render().ViewportBounds(e.pMap.Size().X, e.pMap.Size().Y)
+
+	// Isometric tiles are rotated, so the range is taken from all corners of the view.
+	var bounds util.Bounds
+	for idx, corner := range [][2]float32{{view.X1, view.Y1}, {view.X1, view.Y2}, {view.X2, view.Y1}, {view.X2, view.Y2}} {
+		x, y := proj.TileAt(corner[0], corner[1])
+		if idx == 0 {
+			bounds = util.Bounds{X1: float32(x), Y1: float32(y), X2: float32(x), Y2: float32(y)}
+			continue
+		}
+		bounds.X1, bounds.Y1 = min(bounds.X1, float32(x)), min(bounds.Y1, float32(y))
+		bounds.X2, bounds.Y2 = max(bounds.X2, float32(x)), max(bounds.Y2, float32(y))
+	}
+	return bounds
+}
@@ -21,12 +21,7 @@ func (e *Editor) OverlayPushTile(coord util.Point, colFill, colBorder util.Color
 
 // OverlayPushArea pushes area overlay for the next frame.
 func (e *Editor) OverlayPushArea(area util.Bounds, colFill, colBorder util.Color) {
-	e.pMap.PushAreaHover(util.Bounds{
-		X1: (area.X1 - 1) * float32(dmmap.WorldIconSize),
-		Y1: (area.Y1 - 1) * float32(dmmap.WorldIconSize),
-		X2: (area.X2-1)*float32(dmmap.WorldIconSize) + float32(dmmap.WorldIconSize),
-		Y2: (area.Y2-1)*float32(dmmap.WorldIconSize) + float32(dmmap.WorldIconSize),
-	}, colFill, colBorder)
+	e.pMap.PushAreaHover(dmmap.WorldProjection().AreaBounds(int(area.X1), int(area.Y1), int(area.X2), int(area.Y2)), colFill, colBorder)
 }
 
 // OverlaySetTileFlick sets for the provided tile a flick overlay.
@@ -35,12 +30,7 @@ func (e *Editor) OverlayPushArea(area util.Bounds, colFill, colBorder util.Color
 func (e *Editor) OverlaySetTileFlick(coord util.Point) {
 	e.flickAreas = append(e.flickAreas, overlay.FlickArea{
 		Time: imgui.Time(),
-		Area: util.Bounds{
-			X1: float32((coord.X - 1) * dmmap.WorldIconSize),
-			Y1: float32((coord.Y - 1) * dmmap.WorldIconSize),
-			X2: float32((coord.X-1)*dmmap.WorldIconSize + dmmap.WorldIconSize),
-			Y2: float32((coord.Y-1)*dmmap.WorldIconSize + dmmap.WorldIconSize),
-		},
+		Area: dmmap.WorldProjection().TileBounds(coord.X, coord.Y),
 	})
 }
 
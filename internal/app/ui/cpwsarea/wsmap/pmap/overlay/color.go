@@ -23,16 +23,31 @@ var (
 	ColorToolSelectTileFill   = util.MakeColor(1, 1, 1, 0.25)
 	ColorToolSelectTileBorder = util.MakeColor(0, 1, 0, 1)
 
-	ColorToolPickInstance = util.MakeColor(0, 1, 0, 1)
+	ColorToolPickInstance   = util.MakeColor(0, 1, 0, 1)
+	ColorToolPickTileFill   = util.MakeColor(0, 1, 0, 0.25)
+	ColorToolPickTileBorder = util.MakeColor(0, 1, 0, 1)
 
 	ColorToolDeleteInstance      = util.MakeColor(1, 0, 0, 1)
 	ColorToolDeleteAltTileFill   = util.MakeColor(1, 0, 0, 0.25)
 	ColorToolDeleteAltTileBorder = util.MakeColorFromVec4(style.ColorGold)
 
-	ColorToolReplaceInstance = util.MakeColor(0, 1, 0, 1)
+	ColorToolReplaceInstance      = util.MakeColor(0, 1, 0, 1)
+	ColorToolReplaceAltTileFill   = util.MakeColor(0, 1, 0, 0.25)
+	ColorToolReplaceAltTileBorder = util.MakeColorFromVec4(style.ColorGold)
 
 	ColorFlickTileFill = util.MakeColor(1, 1, 1, 1)
 	ColorFlickInstance = util.MakeColor(0, 1, 0, 1)
 
 	ColorAreaBorder = util.MakeColor(1, 1, 1, 1)
+
+	ColorConflictTileFill           = util.MakeColor(1, 0, 0, 0.35)
+	ColorConflictTileBorder         = util.MakeColorFromVec4(style.ColorRed)
+	ColorConflictResolvedTileBorder = util.MakeColor(0, 1, 0, 0.5)
+
+	ColorDiffAddedTileFill     = util.MakeColor(0, 1, 0, 0.25)
+	ColorDiffRemovedTileFill   = util.MakeColor(1, 0, 0, 0.25)
+	ColorDiffEditedTileFill    = util.MakeColorFromVec4(style.ColorGold.Minus(imgui.Vec4{W: 0.75}))
+	ColorDiffChangedTileBorder = util.MakeColor(1, 1, 1, 0.5)
+
+	ColorLightingDarkness = util.MakeColor(0, 0, 0, 0.8)
 )
@@ -18,6 +18,7 @@ const (
 	pPosTop panelPos = iota
 	pPosRightTop
 	pPosRightBottom
+	pPosLeftBottom
 	pPosBottom
 )
 
@@ -44,6 +45,9 @@ func (p *PaneMap) showPanelV(id string, panelPos panelPos, visible bool, content
 		x := imgui.ContentRegionAvail().X - p.panelRightBottomSize.X - panelPadding
 		y := imgui.ContentRegionAvail().Y - p.panelRightBottomSize.Y - p.panelBottomSize.Y - panelPadding*2
 		pos = p.pos.Plus(imgui.Vec2{X: x, Y: y})
+	case pPosLeftBottom:
+		y := imgui.ContentRegionAvail().Y - p.panelLeftBottomSize.Y - p.panelBottomSize.Y - panelPadding*2
+		pos = p.pos.Plus(imgui.Vec2{X: panelPadding, Y: y})
 	case pPosBottom:
 		y := imgui.ContentRegionAvail().Y - p.panelBottomSize.Y - panelPadding
 		pos = p.pos.Plus(imgui.Vec2{X: panelPadding, Y: y})
@@ -70,6 +74,8 @@ func (p *PaneMap) showPanelV(id string, panelPos panelPos, visible bool, content
 			p.panelRightTopSize = imgui.WindowSize()
 		case pPosRightBottom:
 			p.panelRightBottomSize = imgui.WindowSize()
+		case pPosLeftBottom:
+			p.panelLeftBottomSize = imgui.WindowSize()
 		case pPosBottom:
 			p.panelBottomSize = imgui.WindowSize()
 		}
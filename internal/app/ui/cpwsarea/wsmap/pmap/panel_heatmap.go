@@ -0,0 +1,81 @@
+package pmap
+
+import (
+	"fmt"
+
+	"sdmm/internal/dmapi/dmmheat"
+	"sdmm/internal/imguiext/style"
+
+	"github.com/SpaiR/imgui-go"
+)
+
+const (
+	heatmapLegendWidth  float32 = 150
+	heatmapLegendHeight float32 = 10
+	heatmapLegendSteps          = 10
+)
+
+// Queries of heatmap metrics by metric names. Shared between all panes, the same way as the metric is.
+var heatmapQueries = make(map[string]string)
+
+// Returns the query of the metric. Until the query is changed, the hint of the metric is used.
+func heatmapQuery(metric dmmheat.Metric) string {
+	if query, ok := heatmapQueries[metric.Name]; ok {
+		return query
+	}
+	return metric.Query
+}
+
+func (p *PaneMap) showHeatmapPanel() {
+	metric, ok := dmmheat.Find(HeatmapMetric)
+	if !ok {
+		imgui.TextDisabled(fmt.Sprintf("Unknown metric: %s", HeatmapMetric))
+		return
+	}
+
+	imgui.Text(metric.Name)
+	if imgui.IsItemHovered() {
+		imgui.SetTooltip(metric.Desc)
+	}
+
+	if metric.Query != "" {
+		query := heatmapQuery(metric)
+		imgui.SetNextItemWidth(heatmapLegendWidth)
+		if imgui.InputTextWithHint("##heatmap_query", metric.Query, &query) {
+			heatmapQueries[metric.Name] = query
+		}
+	}
+
+	if err := metric.Check(heatmapQuery(metric)); err != nil {
+		imgui.TextColored(style.ColorRed, err.Error())
+		return
+	}
+
+	if p.heatmapMaxValue == 0 {
+		imgui.TextDisabled("Nothing on visible tiles")
+		return
+	}
+
+	p.showHeatmapLegend()
+}
+
+func (p *PaneMap) showHeatmapLegend() {
+	pos := imgui.CursorScreenPos()
+	drawList := imgui.WindowDrawList()
+
+	// Colors are drawn opaque, since the canvas under the panel would change them otherwise.
+	stepWidth := heatmapLegendWidth / heatmapLegendSteps
+	for step := 0; step < heatmapLegendSteps; step++ {
+		r, g, b, _ := dmmheat.Color(step+1, heatmapLegendSteps).RGBA()
+		from := pos.Plus(imgui.Vec2{X: float32(step) * stepWidth})
+		to := from.Plus(imgui.Vec2{X: stepWidth, Y: heatmapLegendHeight})
+		drawList.AddRectFilled(from, to, imgui.PackedColorFromVec4(imgui.Vec4{X: r, Y: g, Z: b, W: 1}))
+	}
+	imgui.Dummy(imgui.Vec2{X: heatmapLegendWidth, Y: heatmapLegendHeight})
+
+	maxLabel := fmt.Sprint(p.heatmapMaxValue)
+	startX := imgui.CursorPosX()
+	imgui.Text("1")
+	imgui.SameLineV(startX+heatmapLegendWidth-imgui.CalcTextSize(maxLabel, false, 0).X, 0)
+	imgui.Text(maxLabel)
+}
@@ -14,6 +14,7 @@ func (p *PaneMap) showStatusPanel() {
 	w.Layout{
 		p.panelStatusLayoutStatus(),
 		w.SameLine(),
+		p.panelStatusLayoutComparison(),
 		w.Custom(func() {
 			if p.dmm.MaxZ != 1 {
 				w.Layout{
@@ -53,6 +54,35 @@ func (p *PaneMap) panelStatusLayoutStatus() (layout w.Layout) {
 	}
 }
 
+func (p *PaneMap) panelStatusLayoutComparison() (layout w.Layout) {
+	comparison, ok := p.editor.Comparison()
+	if !ok {
+		return nil
+	}
+
+	layout = w.Layout{
+		w.Line(
+			w.TextFrame(fmt.Sprintf("Diff: %d", len(comparison.Tiles()))),
+			w.Tooltip(w.Text(fmt.Sprintf("Tiles changed since: %s", comparison.Name))),
+			w.Button(icon.Clear, p.editor.CompareClear).
+				Tooltip("Clear Comparison").
+				Round(true),
+		),
+	}
+
+	if !p.canvasState.HoverOutOfBounds() {
+		if tileDiff, ok := comparison.Tile(p.canvasState.HoveredTile()); ok {
+			layout = append(layout,
+				w.SameLine(),
+				w.TextFrame(fmt.Sprintf("+%d -%d ~%d", len(tileDiff.Added), len(tileDiff.Removed), len(tileDiff.Edited))),
+				w.Tooltip(w.Text(tileDiff.String())),
+			)
+		}
+	}
+
+	return append(layout, w.SameLine())
+}
+
 func isQuickToolToggled() bool {
 	return tools.IsSelected(tools.TNPick) || tools.IsSelected(tools.TNDelete) || tools.IsSelected(tools.TNReplace)
 }
@@ -58,6 +58,9 @@ type App interface {
 var (
 	MirrorCanvasCamera   bool
 	AreaBordersRendering = true
+	LightingRendering    bool
+	// HeatmapMetric is a name of the dmmheat metric, which colors tiles. Empty, if the heatmap is disabled.
+	HeatmapMetric string
 
 	// Used to do a camera mirroring.
 	activeCamera *render.Camera
@@ -106,8 +109,12 @@ type PaneMap struct {
 	panelTopSize         imgui.Vec2
 	panelRightTopSize    imgui.Vec2
 	panelRightBottomSize imgui.Vec2
+	panelLeftBottomSize  imgui.Vec2
 	panelBottomSize      imgui.Vec2
 
+	// The biggest heatmap value among visible tiles. Shown in the heatmap legend.
+	heatmapMaxValue int
+
 	// The value of the Z-level with which the user is currently working.
 	activeLevel int
 
@@ -179,7 +186,7 @@ func New(app App, dmm *dmmap.Dmm) *PaneMap {
 	p.pSettings = psettings.New(app, p.editor)
 
 	p.canvas = canvas.New()
-	p.canvasState = canvas.NewState(dmm.MaxX, dmm.MaxY, dmmap.WorldIconSize)
+	p.canvasState = canvas.NewState(dmm.MaxX, dmm.MaxY, dmmap.WorldProjection())
 	p.canvasControl = canvas.NewControl()
 	p.canvasOverlay = canvas.NewOverlay()
 
@@ -210,7 +217,8 @@ func (p *PaneMap) Process() {
 
 	if !p.centered {
 		// On first load, set the camera to the center of the map, taking UI size into account.
-		p.canvas.Render().Camera.Translate(float32((int(p.size.X)-p.dmm.MaxX*dmmap.WorldIconSize)/2), float32((int(p.size.Y)-p.dmm.MaxY*dmmap.WorldIconSize)/2))
+		mapBounds := dmmap.WorldProjection().AreaBounds(1, 1, p.dmm.MaxX, p.dmm.MaxY)
+		p.canvas.Render().Camera.Translate((p.size.X-(mapBounds.X2-mapBounds.X1))/2-mapBounds.X1, (p.size.Y-(mapBounds.Y2-mapBounds.Y1))/2-mapBounds.Y1)
 		p.centered = true
 	}
 
@@ -234,6 +242,7 @@ func (p *PaneMap) Process() {
 		p.app.Prefs().Controls.QuickEditMapPane && p.active && p.app.HasSelectedInstance(),
 		p.pQuickEdit.Process,
 	)
+	p.showPanelV("heatmap_"+p.dmm.Name, pPosLeftBottom, HeatmapMetric != "", p.showHeatmapPanel)
 	p.showPanel("canvasStat_"+p.dmm.Name, pPosBottom, p.showStatusPanel)
 }
 
@@ -346,3 +355,10 @@ func (p *PaneMap) OnMapSizeChange() {
 	p.reloadCanvas()
 	p.pSettings.DropSessionMapSize()
 }
+
+// OnEnvironmentReload updates the whole canvas, since the appearance of any instance could be changed.
+// The world icon size or map format could be changed as well, so all levels are recreated.
+func (p *PaneMap) OnEnvironmentReload() {
+	p.canvasState.SetProjection(dmmap.WorldProjection())
+	p.canvas.Render().ResetBucket(p.dmm)
+}
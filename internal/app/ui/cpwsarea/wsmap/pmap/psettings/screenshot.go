@@ -78,9 +78,9 @@ func (p *Panel) createScreenshot() {
 		hasSelectedArea := selectedTool.Name() == tools.TNGrab && selectedTool.(*tools.ToolGrab).HasSelectedArea()
 		if hasSelectedArea {
 			bounds := selectedTool.(*tools.ToolGrab).Bounds() //get grab tool bounds, so we can calculate boundX and boundY
-			width, height = (int(bounds.X2-bounds.X1)+1)*dmmap.WorldIconSize, (int(bounds.Y2-bounds.Y1)+1)*dmmap.WorldIconSize
-			boundX = -float32((int(bounds.X1) - 1) * dmmap.WorldIconSize) //now change bounds so we can use them in Translate
-			boundY = -float32((int(bounds.Y1) - 1) * dmmap.WorldIconSize)
+			viewBounds := dmmap.WorldProjection().AreaBounds(int(bounds.X1), int(bounds.Y1), int(bounds.X2), int(bounds.Y2))
+			width, height = int(viewBounds.X2-viewBounds.X1), int(viewBounds.Y2-viewBounds.Y1)
+			boundX, boundY = -viewBounds.X1, -viewBounds.Y1 //now change bounds so we can use them in Translate
 		} else {
 			appdialog.Open(appdialog.TypeInformation{
 				Title:       "Nothing selected!",
@@ -90,7 +90,9 @@ func (p *Panel) createScreenshot() {
 			return
 		}
 	} else {
-		width, height = p.editor.Dmm().MaxX*dmmap.WorldIconSize, p.editor.Dmm().MaxY*dmmap.WorldIconSize
+		viewBounds := dmmap.WorldProjection().AreaBounds(1, 1, p.editor.Dmm().MaxX, p.editor.Dmm().MaxY)
+		width, height = int(viewBounds.X2-viewBounds.X1), int(viewBounds.Y2-viewBounds.Y1)
+		boundX, boundY = -viewBounds.X1, -viewBounds.Y1
 	}
 
 	c := canvas.New()
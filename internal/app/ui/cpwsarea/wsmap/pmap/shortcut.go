@@ -163,22 +163,26 @@ func (p *PaneMap) DoDeselect() {
 
 func (p *PaneMap) doMoveCameraUp() {
 	log.Print("do move camera up")
-	p.translateCanvas(0, p.calcManualCanvasTranslateShift())
+	_, shiftY := p.calcManualCanvasTranslateShift()
+	p.translateCanvas(0, shiftY)
 }
 
 func (p *PaneMap) doMoveCameraDown() {
 	log.Print("do move camera down")
-	p.translateCanvas(0, -p.calcManualCanvasTranslateShift())
+	_, shiftY := p.calcManualCanvasTranslateShift()
+	p.translateCanvas(0, -shiftY)
 }
 
 func (p *PaneMap) doMoveCameraLeft() {
 	log.Print("do move camera left")
-	p.translateCanvas(p.calcManualCanvasTranslateShift(), 0)
+	shiftX, _ := p.calcManualCanvasTranslateShift()
+	p.translateCanvas(shiftX, 0)
 }
 
 func (p *PaneMap) doMoveCameraRight() {
 	log.Print("do move camera right")
-	p.translateCanvas(-p.calcManualCanvasTranslateShift(), 0)
+	shiftX, _ := p.calcManualCanvasTranslateShift()
+	p.translateCanvas(-shiftX, 0)
 }
 
 func (p *PaneMap) doZoomIn() {
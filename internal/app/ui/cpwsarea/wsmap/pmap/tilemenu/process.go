@@ -202,7 +202,7 @@ func (t *TileMenu) doSearchByPrefabID(i *dmminstance.Instance) func() {
 }
 
 func getSprite(i *dmmprefab.Prefab) *dmicon.Sprite {
-	return dmicon.Cache.GetSpriteOrPlaceholderV(
+	return dmicon.Cache.GetAnimatedSpriteOrPlaceholderV(
 		i.Vars().TextV("icon", ""),
 		i.Vars().TextV("icon_state", ""),
 		i.Vars().IntV("dir", dm.DirDefault),
@@ -3,6 +3,7 @@ package wsmap
 import (
 	"sdmm/internal/app/prefs"
 	"sdmm/internal/dmapi/dmmsave"
+	"sdmm/internal/util"
 
 	"github.com/rs/zerolog/log"
 )
@@ -22,11 +23,25 @@ func (ws *WsMap) Save() bool {
 		saveFormat = dmmsave.FormatDM
 	}
 
-	dmmsave.Save(ws.app.LoadedEnvironment(), ws.paneMap.Dmm(), dmmsave.Config{
+	err := dmmsave.Save(ws.app.LoadedEnvironment(), ws.paneMap.Dmm(), dmmsave.Config{
 		Format:            saveFormat,
 		SanitizeVariables: editorPrefs.SanitizeVariables,
 	})
+	if err != nil {
+		log.Print("unable to save map workspace:", err)
+		util.ShowErrorDialog("Unable to save the map: " + err.Error())
+		return false
+	}
 
 	ws.app.CommandStorage().ForceBalance(ws.CommandStackId())
+	ws.saveHistory()
+	ws.paneMap.Snapshot().ResetJournal()
+	ws.app.RunSaveHooks(ws.paneMap.Dmm().Path.Absolute)
 	return true
 }
+
+// Variables equal to their defaults are the same with or without sanitizing,
+// so the history is sanitized whenever the option is on, even if the map wasn't saved in this session.
+func (ws *WsMap) saveHistory() {
+	ws.paneMap.Editor().SaveHistory(ws.app.MapHistoryPath(ws.paneMap.Dmm().Path.Absolute), ws.app.Prefs().Editor.SanitizeVariables)
+}
@@ -169,7 +169,7 @@ func (t *ToolReplaceObsolete) onMove(coord util.Point) {
 		return
 	}
 
-	tile.InstancesRemove(instance)
+	tile.InstancesRemoveByInstance(instance)
 	tile.InstancesAdd(dmmap.PrefabStorage.Put(newPrefab))
 	tile.InstancesRegenerate()
 
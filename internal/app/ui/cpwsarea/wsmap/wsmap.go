@@ -20,6 +20,9 @@ type App interface {
 	LoadedEnvironment() *dmenv.Dme
 	CommandStorage() *command.Storage
 	Prefs() prefs.Prefs
+	RunSaveHooks(mapPath string)
+	MapHistoryPath(mapPath string) string
+	MapJournalPath(mapPath string) string
 }
 
 type WsMap struct {
@@ -31,10 +34,15 @@ type WsMap struct {
 }
 
 func New(app App, dmm *dmmap.Dmm) *WsMap {
-	return &WsMap{
+	ws := &WsMap{
 		app:     app,
 		paneMap: pmap.New(app, dmm),
 	}
+	ws.paneMap.Editor().RestoreHistory(app.MapHistoryPath(dmm.Path.Absolute))
+	if err := ws.paneMap.Snapshot().StartJournal(app.MapJournalPath(dmm.Path.Absolute)); err != nil {
+		log.Printf("unable to start journal [%s]: %v", dmm.Path.Absolute, err)
+	}
+	return ws
 }
 
 func (ws *WsMap) Map() *pmap.PaneMap {
@@ -78,6 +86,11 @@ func (ws *WsMap) Process() {
 }
 
 func (ws *WsMap) Dispose() {
+	// Unsaved changes are balanced before the workspace is closed, so the map is the same as the file.
+	if !ws.app.CommandStorage().IsModified(ws.CommandStackId()) {
+		ws.saveHistory()
+	}
+	ws.paneMap.Snapshot().CloseJournal()
 	ws.paneMap.Dispose()
 	log.Print("map workspace disposed:", ws.Name())
 }
@@ -7,6 +7,11 @@ type basePref struct {
 	Desc  string
 	Label string
 	Help  string
+
+	// Note is shown under the description, e.g. to tell where the value comes from.
+	Note string
+	// ReadOnly preferences are shown, but can't be changed.
+	ReadOnly bool
 }
 
 type IntPref struct {
@@ -74,12 +74,16 @@ func showIntPref(pref IntPref) {
 	showHelp(pref.Help)
 	imgui.PopTextWrapPos()
 
+	showNote(pref.Note)
+
+	imgui.BeginDisabledV(pref.ReadOnly)
 	v := int32(pref.FGet())
 	if imguiext.InputIntClamp(pref.Label, &v, pref.Min, pref.Max, pref.Step, pref.StepFast) {
 		if int(v) != pref.FGet() {
 			pref.FSet(int(v))
 		}
 	}
+	imgui.EndDisabled()
 }
 
 func showBoolPref(pref BoolPref) {
@@ -89,12 +93,14 @@ func showBoolPref(pref BoolPref) {
 
 	markdown.ShowHeaderV(pref.Name, window.FontH3, style.ColorWhite)
 
+	imgui.BeginDisabledV(pref.ReadOnly)
 	imgui.PushStyleVarVec2(imgui.StyleVarFramePadding, imgui.Vec2{X: window.PointSize(), Y: window.PointSize()})
 	v := pref.FGet()
 	if imgui.Checkbox(pref.Label, &v) {
 		fToggle()
 	}
 	imgui.PopStyleVar()
+	imgui.EndDisabled()
 
 	imgui.SameLine()
 
@@ -103,13 +109,17 @@ func showBoolPref(pref BoolPref) {
 	showHelp(pref.Help)
 	imgui.PopTextWrapPos()
 
-	if imgui.IsItemHovered() {
-		imgui.SetMouseCursor(imgui.MouseCursorHand)
-	}
+	if !pref.ReadOnly {
+		if imgui.IsItemHovered() {
+			imgui.SetMouseCursor(imgui.MouseCursorHand)
+		}
 
-	if imgui.IsItemClicked() {
-		fToggle()
+		if imgui.IsItemClicked() {
+			fToggle()
+		}
 	}
+
+	showNote(pref.Note)
 }
 
 func showOptionPref(pref OptionPref) {
@@ -120,6 +130,9 @@ func showOptionPref(pref OptionPref) {
 	showHelp(pref.Help)
 	imgui.PopTextWrapPos()
 
+	showNote(pref.Note)
+
+	imgui.BeginDisabledV(pref.ReadOnly)
 	if imgui.BeginCombo(pref.Label, pref.FGet()) {
 		for _, option := range pref.Options {
 			if imgui.SelectableV(option, option == pref.FGet(), imgui.SelectableFlagsNone, imgui.Vec2{}) {
@@ -128,6 +141,7 @@ func showOptionPref(pref OptionPref) {
 		}
 		imgui.EndCombo()
 	}
+	imgui.EndDisabled()
 }
 
 func showStringPref(pref StringPref) {
@@ -138,10 +152,22 @@ func showStringPref(pref StringPref) {
 	showHelp(pref.Help)
 	imgui.PopTextWrapPos()
 
+	showNote(pref.Note)
+
+	imgui.BeginDisabledV(pref.ReadOnly)
 	v := pref.FGet()
 	if imgui.InputText(pref.Label, &v) {
 		pref.FSet(v)
 	}
+	imgui.EndDisabled()
+}
+
+func showNote(note string) {
+	if note != "" {
+		imgui.PushTextWrapPos()
+		imgui.TextColored(style.ColorGold, note)
+		imgui.PopTextWrapPos()
+	}
 }
 
 func showHelp(helpText string) {
@@ -3,7 +3,9 @@ package layout
 import (
 	"sdmm/internal/app/config"
 	"sdmm/internal/app/ui/cpenvironment"
+	"sdmm/internal/app/ui/cpminimap"
 	"sdmm/internal/app/ui/cpprefabs"
+	"sdmm/internal/app/ui/cpproblems"
 	"sdmm/internal/app/ui/cpsearch"
 	"sdmm/internal/app/ui/cpvareditor"
 	"sdmm/internal/app/ui/cpwsarea"
@@ -18,6 +20,8 @@ type app interface {
 	cpenvironment.App
 	cpprefabs.App
 	cpsearch.App
+	cpproblems.App
+	cpminimap.App
 	cpwsarea.App
 	cpvareditor.App
 
@@ -55,6 +59,8 @@ type Layout struct {
 	Environment *cpenvironment.Environment
 	Prefabs     *cpprefabs.Prefabs
 	Search      *cpsearch.Search
+	Problems    *cpproblems.Problems
+	Minimap     *cpminimap.Minimap
 	WsArea      *cpwsarea.WsArea
 	VarEditor   *cpvareditor.VarEditor
 
@@ -69,12 +75,16 @@ func New(app app) *Layout {
 	l.Environment = new(cpenvironment.Environment)
 	l.Prefabs = new(cpprefabs.Prefabs)
 	l.Search = new(cpsearch.Search)
+	l.Problems = new(cpproblems.Problems)
+	l.Minimap = new(cpminimap.Minimap)
 	l.WsArea = new(cpwsarea.WsArea)
 	l.VarEditor = new(cpvareditor.VarEditor)
 
 	l.Environment.Init(app)
 	l.Prefabs.Init(app)
 	l.Search.Init(app)
+	l.Problems.Init(app)
+	l.Minimap.Init(app)
 	l.WsArea.Init(app)
 	l.VarEditor.Init(app)
 
@@ -87,6 +97,8 @@ func (l *Layout) Process() {
 	l.showEnvironmentNode()
 	l.showPrefabsNode()
 	l.showSearchNode()
+	l.showProblemsNode()
+	l.showMinimapNode()
 	l.showVariablesNode()
 	l.showWorkspaceAreaNode() // The latest node will have a focus by default
 
@@ -135,6 +147,14 @@ func (l *Layout) showSearchNode() {
 	l.wrapNode(lnode.NameSearch, l.rightUpNodeId, l.Search)
 }
 
+func (l *Layout) showProblemsNode() {
+	l.wrapNode(lnode.NameProblems, l.rightUpNodeId, l.Problems)
+}
+
+func (l *Layout) showMinimapNode() {
+	l.wrapNode(lnode.NameMinimap, l.leftDownNodeId, l.Minimap)
+}
+
 func (l *Layout) showVariablesNode() {
 	l.wrapNode(lnode.NameVariables, l.rightDownNodeId, l.VarEditor)
 }
@@ -7,4 +7,6 @@ const (
 	NamePrefabs       = "Prefabs"
 	NameSearch        = "Search"
 	NameVariables     = "Variables"
+	NameProblems      = "Problems"
+	NameMinimap       = "Minimap"
 )
@@ -0,0 +1,14 @@
+package menu
+
+import (
+	"fmt"
+
+	"sdmm/internal/imguiext/style"
+
+	"github.com/SpaiR/imgui-go"
+)
+
+func (m *Menu) showIconsLoading(loading int) {
+	dotType := loadingDotTypes[(int(imgui.Time()/0.25) & 3)]
+	imgui.TextColored(style.ColorGold, fmt.Sprintf("Loading icons: %d %s", loading, dotType))
+}
@@ -2,10 +2,12 @@ package menu
 
 import (
 	"sdmm/internal/app/command"
+	"sdmm/internal/app/prefs"
 	"sdmm/internal/app/ui/shortcut"
 	"sdmm/internal/dmapi/dm"
 	"sdmm/internal/dmapi/dmenv"
 	"sdmm/internal/dmapi/dmmclip"
+	"sdmm/internal/dmapi/dmmheat"
 	"sdmm/internal/imguiext/icon"
 	"sdmm/internal/imguiext/style"
 	w "sdmm/internal/imguiext/widget"
@@ -39,13 +41,23 @@ type app interface {
 	DoCut()
 	DoDelete()
 	DoSearch()
+	DoCheckProblems()
 	DoDeselect()
 	DoOpenJumpWindow()
+	DoCompareWithFile()
+	DoCompareWithRevision()
 
 	// View
 	DoAreaBorders()
+	DoLighting()
+	DoHeatmap(metric string)
 	DoMultiZRendering()
 	DoMirrorCanvasCamera()
+	DoIconsAnimation()
+	DoPauseIconsAnimation()
+	DoIconSmoothing()
+	DoReloadIcons()
+	DoApplyPathsFilterPreset(prefs.PathsFilterPreset)
 
 	// Window
 	DoResetLayout()
@@ -74,12 +86,20 @@ type app interface {
 	HasActiveMap() bool
 
 	PathsFilter() *dm.PathsFilter
+	PathsFilterPresets() []prefs.PathsFilterPreset
 	CommandStorage() *command.Storage
 	Clipboard() *dmmclip.Clipboard
 
 	AreaBordersRendering() bool
+	LightingRendering() bool
+	HeatmapMetric() string
 	MultiZRendering() bool
 	MirrorCanvasCamera() bool
+	IconsAnimation() bool
+	IconsAnimationPaused() bool
+	IconSmoothing() bool
+	HasIconSmoothing() bool
+	IconsLoading() int
 }
 
 type upStatus int
@@ -198,6 +218,16 @@ func (m *Menu) Process() {
 				Icon(icon.Shrink).
 				Enabled(m.app.HasActiveMap()).
 				Shortcut(platform.KeyModName(), "G"),
+			w.MenuItem("Check Problems", m.app.DoCheckProblems).
+				IconEmpty().
+				Enabled(m.app.HasActiveMap()),
+			w.Separator(),
+			w.MenuItem("Compare with File...", m.app.DoCompareWithFile).
+				IconEmpty().
+				Enabled(m.app.HasActiveMap()),
+			w.MenuItem("Compare with Git Revision...", m.app.DoCompareWithRevision).
+				IconEmpty().
+				Enabled(m.app.HasActiveMap()),
 		}),
 
 		w.Menu("View", w.Layout{
@@ -224,10 +254,31 @@ func (m *Menu) Process() {
 			w.MenuItem("Show All", m.doShowAll).
 				IconEmpty().
 				Enabled(m.app.HasLoadedEnvironment()),
+			w.Menu("Filter Presets", w.Layout{
+				w.Custom(func() {
+					for _, preset := range m.app.PathsFilterPresets() {
+						w.MenuItem(preset.Name, func() {
+							m.app.DoApplyPathsFilterPreset(preset)
+						}).IconEmpty().Build()
+					}
+				}),
+			}).IconEmpty().Enabled(len(m.app.PathsFilterPresets()) != 0),
 			w.Separator(),
 			w.MenuItem("Area Borders", m.app.DoAreaBorders).
 				IconEmpty().
 				Selected(m.app.AreaBordersRendering()),
+			w.MenuItem("Lighting", m.app.DoLighting).
+				IconEmpty().
+				Selected(m.app.LightingRendering()),
+			w.Menu("Heatmap", w.Layout{
+				w.Custom(func() {
+					for _, metric := range dmmheat.Metrics() {
+						w.MenuItem(metric.Name, func() {
+							m.app.DoHeatmap(metric.Name)
+						}).IconEmpty().Selected(m.app.HeatmapMetric() == metric.Name).Build()
+					}
+				}),
+			}).IconEmpty(),
 			w.MenuItem("Multi-Z Rendering", m.app.DoMultiZRendering).
 				IconEmpty().
 				Selected(m.app.MultiZRendering()).
@@ -235,6 +286,21 @@ func (m *Menu) Process() {
 			w.MenuItem("Mirror Canvas Camera", m.app.DoMirrorCanvasCamera).
 				IconEmpty().
 				Selected(m.app.MirrorCanvasCamera()),
+			w.Separator(),
+			w.MenuItem("Animate Icons", m.app.DoIconsAnimation).
+				IconEmpty().
+				Selected(m.app.IconsAnimation()),
+			w.MenuItem("Pause Animation", m.app.DoPauseIconsAnimation).
+				IconEmpty().
+				Selected(m.app.IconsAnimationPaused()).
+				Enabled(m.app.IconsAnimation()),
+			w.MenuItem("Smooth Icons", m.app.DoIconSmoothing).
+				IconEmpty().
+				Selected(m.app.IconSmoothing()).
+				Enabled(m.app.HasIconSmoothing()),
+			w.MenuItem("Reload Icons", m.app.DoReloadIcons).
+				IconEmpty().
+				Enabled(m.app.HasLoadedEnvironment()),
 		}),
 
 		w.Menu("Window", w.Layout{
@@ -267,6 +333,12 @@ func (m *Menu) Process() {
 				m.showUpdateMenu()
 			}
 		}),
+
+		w.Custom(func() {
+			if loading := m.app.IconsLoading(); loading > 0 {
+				m.showIconsLoading(loading)
+			}
+		}),
 	}).Build()
 }
 
@@ -0,0 +1,189 @@
+// Package cli contains commands which run editor features without opening the application window.
+// Commands are meant to be used by scripts, CI pipelines and build servers without any display.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	CNConvert     = "convert"
+	CNMergeDriver = "merge-driver"
+	CNDiff        = "diff"
+	CNRender      = "render"
+	CNLint        = "lint"
+	CNMigrate     = "migrate"
+)
+
+const (
+	exitCodeOk    = 0
+	exitCodeError = 1
+	exitCodeUsage = 2
+)
+
+type command struct {
+	usage string
+	desc  string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	CNConvert: {
+		usage: "convert --to tgm|dm [--dme path] [--sanitize] [--discard-unknown] [-o out.dmm] map.dmm",
+		desc:  "Converts the map between TGM and DM formats, reusing existing keys.",
+		run:   runConvert,
+	},
+	CNMergeDriver: {
+		usage: "merge-driver base.dmm ours.dmm theirs.dmm [path]",
+		desc:  "Three-way merges maps on the tile level. Meant to be used as a git merge driver: merge-driver %O %A %B %P",
+		run:   runMergeDriver,
+	},
+	CNDiff: {
+		usage: "diff [--json] old.dmm new.dmm",
+		desc:  "Reports changed tiles with added, removed and edited prefabs. Exits with 1 if maps are different.",
+		run:   runDiff,
+	},
+	CNRender: {
+		usage: "render --out map.png [--z 1] [--region x1,y1,x2,y2] [--scale 1] [--only paths] [--hide paths] [--dme path] map.dmm",
+		desc:  "Renders the map level into a PNG image without opening the editor window.",
+		run:   runRender,
+	},
+	CNLint: {
+		usage: "lint [--json] [--disable rules] [--rules] [--dme path] map.dmm...",
+		desc:  "Checks maps for common mistakes and reports problems with coordinates. Exits with 1 if problems are found.",
+		run:   runLint,
+	},
+	CNMigrate: {
+		usage: "migrate [--rules files] [--dry-run] [--dme path] [map.dmm...]",
+		desc:  "Updates type paths and variables on maps with migration rules. Every map of the project is migrated by default.",
+		run:   runMigrate,
+	},
+}
+
+// errUsage is returned by commands when they are called with invalid arguments.
+var errUsage = errors.New("invalid arguments")
+
+// IsCommand returns true if the program arguments start with a known command name.
+// The first argument is always a path to the executable.
+func IsCommand(args []string) bool {
+	if len(args) < 2 {
+		return false
+	}
+	if args[1] == "help" {
+		return true
+	}
+	_, ok := commands[args[1]]
+	return ok
+}
+
+// Run executes a command from the program arguments and returns the program exit code.
+func Run(args []string) int {
+	initializeLogs(os.Stderr)
+
+	name := args[1]
+	if name == "help" {
+		printHelp(os.Stdout)
+		return exitCodeOk
+	}
+
+	cmd := commands[name]
+	if err := cmd.run(args[2:]); err != nil {
+		if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "usage:", cmd.usage)
+			return exitCodeUsage
+		}
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			return exitErr.code
+		}
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		return exitCodeError
+	}
+
+	return exitCodeOk
+}
+
+// exitError is used by commands to finish with a specific exit code, without treating it as a failure.
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprint("exit code: ", e.code)
+}
+
+func printHelp(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Commands:")
+	for _, name := range names {
+		fmt.Fprintln(w, " ", commands[name].usage)
+		fmt.Fprintln(w, "     ", commands[name].desc)
+	}
+}
+
+// Commands are silent by default, so only warnings and errors are written to the stderr.
+// Verbose mode enables all logs the editor does.
+func initializeLogs(out io.Writer) {
+	log.Logger = zerolog.New(zerolog.ConsoleWriter{
+		Out:        out,
+		TimeFormat: time.DateTime,
+	}).With().Timestamp().Logger()
+	zerolog.SetGlobalLevel(zerolog.WarnLevel)
+}
+
+// newFlagSet creates a flag set for the command with flags shared by all commands.
+func newFlagSet(name string) (*flag.FlagSet, *bool) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	verbose := fs.Bool("v", false, "verbose output")
+	return fs, verbose
+}
+
+// parseFlags parses command flags and returns positional arguments.
+// Unlike the flag.FlagSet.Parse, flags are allowed to go after positional arguments.
+func parseFlags(fs *flag.FlagSet, verbose *bool, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return nil, errUsage
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if *verbose {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	}
+
+	return positional, nil
+}
+
+// splitList splits a comma separated flag value, ignoring empty items.
+func splitList(value string) (items []string) {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) != 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
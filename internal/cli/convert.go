@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"sdmm/internal/dmapi/dmmsave"
+
+	"github.com/rs/zerolog/log"
+)
+
+func runConvert(args []string) error {
+	fs, verbose := newFlagSet(CNConvert)
+	to := fs.String("to", "", "target format: tgm or dm")
+	out := fs.String("o", "", "output path (the input map is overwritten by default)")
+	dmePath := fs.String("dme", "", "environment file (found from the map location by default)")
+	sanitize := fs.Bool("sanitize", false, "remove variables which are equal to their initial values")
+	discardUnknown := fs.Bool("discard-unknown", false, "discard types absent in the environment instead of failing")
+
+	positional, err := parseFlags(fs, verbose, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errUsage
+	}
+
+	var format dmmsave.Format
+	switch strings.ToLower(*to) {
+	case "tgm":
+		format = dmmsave.FormatTGM
+	case "dm", "dmm":
+		format = dmmsave.FormatDM
+	default:
+		return errUsage
+	}
+
+	mapPath := positional[0]
+	outPath := *out
+	if len(outPath) == 0 {
+		outPath = mapPath
+	}
+
+	env, err := loadEnvironment(*dmePath, mapPath)
+	if err != nil {
+		return err
+	}
+
+	dmm, unknownPaths, err := loadMap(env, mapPath)
+	if err != nil {
+		return err
+	}
+	if len(unknownPaths) != 0 {
+		if !*discardUnknown {
+			return fmt.Errorf("unknown types on the map, use --discard-unknown to drop them:\n - %s",
+				strings.Join(unknownPaths, "\n - "))
+		}
+		log.Warn().Msgf("unknown types discarded: %s", strings.Join(unknownPaths, ", "))
+	}
+
+	return dmmsave.SaveV(env, dmm, outPath, dmmsave.Config{
+		Format:            format,
+		SanitizeVariables: *sanitize,
+	})
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"sdmm/internal/dmapi/dmmap/dmmdata"
+	"sdmm/internal/dmapi/dmmdiff"
+)
+
+func runDiff(args []string) error {
+	fs, verbose := newFlagSet(CNDiff)
+	asJson := fs.Bool("json", false, "print the result as JSON")
+
+	positional, err := parseFlags(fs, verbose, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return errUsage
+	}
+
+	oldData, err := dmmdata.New(positional[0])
+	if err != nil {
+		return fmt.Errorf("unable to parse map [%s]: %w", positional[0], err)
+	}
+	newData, err := dmmdata.New(positional[1])
+	if err != nil {
+		return fmt.Errorf("unable to parse map [%s]: %w", positional[1], err)
+	}
+
+	result := dmmdiff.Diff(dmmdiff.FromData(oldData), dmmdiff.FromData(newData))
+
+	if *asJson {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err = encoder.Encode(result); err != nil {
+			return err
+		}
+	} else {
+		if result.OldSize != result.NewSize {
+			fmt.Printf("size: %s -> %s\n", result.OldSize, result.NewSize)
+		}
+		for _, tile := range result.Tiles {
+			fmt.Println(tile)
+		}
+	}
+
+	if result.HasChanges() {
+		return &exitError{code: exitCodeError}
+	}
+	return nil
+}
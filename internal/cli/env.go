@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"sdmm/internal/dmapi/dmenv"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/dmapi/dmmap/dmmdata"
+
+	"github.com/rs/zerolog/log"
+)
+
+// loadEnvironment parses the environment and initializes the global map state with it.
+// If dmePath is empty, the environment will be found from the provided map location.
+func loadEnvironment(dmePath, mapPath string) (*dmenv.Dme, error) {
+	if len(dmePath) == 0 {
+		absMapPath, err := filepath.Abs(mapPath)
+		if err != nil {
+			return nil, err
+		}
+		if dmePath, err = dmenv.FindFromBase(absMapPath); err != nil {
+			return nil, fmt.Errorf("unable to find environment for [%s], use --dme to provide it", mapPath)
+		}
+	}
+
+	start := time.Now()
+	log.Printf("parsing environment: [%s]...", dmePath)
+
+	env, err := dmenv.New(dmePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open environment [%s]: %w", dmePath, err)
+	}
+
+	log.Printf("environment [%s] parsed in [%d] ms", dmePath, time.Since(start).Milliseconds())
+
+	dmmap.Init(env)
+
+	return env, nil
+}
+
+// loadMap reads the map by the provided path and creates a dmmap.Dmm from it.
+// The original file is used as a map backup, since it's never modified until the map is saved.
+// Returns sorted paths of types which are absent in the environment.
+func loadMap(env *dmenv.Dme, path string) (*dmmap.Dmm, []string, error) {
+	data, err := dmmdata.New(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse map [%s]: %w", path, err)
+	}
+
+	dmm, unknownPrefabs := dmmap.New(env, data, path)
+
+	unknownPaths := make([]string, 0, len(unknownPrefabs))
+	for unknownPath := range unknownPrefabs {
+		unknownPaths = append(unknownPaths, unknownPath)
+	}
+	sort.Strings(unknownPaths)
+
+	return dmm, unknownPaths, nil
+}
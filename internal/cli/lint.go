@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"sdmm/internal/app/prefs"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/dmapi/dmmap/dmmdata"
+	"sdmm/internal/dmapi/dmmlint"
+)
+
+func runLint(args []string) error {
+	fs, verbose := newFlagSet(CNLint)
+	asJson := fs.Bool("json", false, "print problems as JSON")
+	disable := fs.String("disable", "", "comma separated names of rules to skip")
+	listRules := fs.Bool("rules", false, "print available rules and exit")
+	dmePath := fs.String("dme", "", "environment file (found from the map location by default)")
+
+	positional, err := parseFlags(fs, verbose, args)
+	if err != nil {
+		return err
+	}
+
+	if *listRules {
+		for _, rule := range dmmlint.Rules() {
+			fmt.Printf("%-16s %s\n", rule.Name, rule.Desc)
+		}
+		return nil
+	}
+
+	if len(positional) == 0 {
+		return errUsage
+	}
+
+	env, err := loadEnvironment(*dmePath, positional[0])
+	if err != nil {
+		return err
+	}
+
+	cfg := dmmlint.Config{Disabled: splitList(*disable)}
+
+	// Rules disabled for the project are skipped as well, so the command reports the same problems as the editor.
+	project, err := prefs.LoadProject(env.RootFile)
+	if err != nil {
+		return fmt.Errorf("unable to load project preferences: %w", err)
+	}
+	if project != nil {
+		cfg.Disabled = append(cfg.Disabled, project.LintDisabledRules...)
+	}
+
+	problemsByMap := make(map[string][]dmmlint.Problem, len(positional))
+	hasProblems := false
+	for _, mapPath := range positional {
+		data, err := dmmdata.New(mapPath)
+		if err != nil {
+			return fmt.Errorf("unable to parse map [%s]: %w", mapPath, err)
+		}
+
+		mapProblems := make([]dmmlint.Problem, 0)
+		if cfg.IsEnabled(dmmlint.RNUnknownTypes) {
+			mapProblems = append(mapProblems, dmmlint.UnknownTypes(env, data)...)
+		}
+		dmm, _ := dmmap.New(env, data, mapPath)
+		mapProblems = append(mapProblems, dmmlint.Lint(env, dmm, cfg)...)
+		dmmlint.Sort(mapProblems)
+
+		if !*asJson {
+			for _, problem := range mapProblems {
+				fmt.Printf("%s:%s\n", mapPath, problem)
+			}
+		}
+		problemsByMap[mapPath] = mapProblems
+		hasProblems = hasProblems || len(mapProblems) != 0
+	}
+
+	if *asJson {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err = encoder.Encode(problemsByMap); err != nil {
+			return err
+		}
+	}
+
+	if hasProblems {
+		return &exitError{code: exitCodeError}
+	}
+	return nil
+}
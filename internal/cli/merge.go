@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/dmapi/dmmap/dmmdata"
+	"sdmm/internal/dmapi/dmmmerge"
+	"sdmm/internal/dmapi/dmmsave"
+	"sdmm/internal/util"
+)
+
+// runMergeDriver is called by git with the "%O %A %B %P" arguments.
+// The result is written to the "ours" file, which is what git expects from merge drivers.
+// The environment is not needed, since tiles are compared by their raw content.
+func runMergeDriver(args []string) error {
+	fs, verbose := newFlagSet(CNMergeDriver)
+
+	positional, err := parseFlags(fs, verbose, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 3 || len(positional) > 4 {
+		return errUsage
+	}
+
+	basePath, oursPath, theirsPath := positional[0], positional[1], positional[2]
+	name := oursPath
+	if len(positional) == 4 {
+		name = positional[3]
+	}
+
+	base, err := dmmdata.New(basePath)
+	if err != nil {
+		return fmt.Errorf("unable to parse base version of [%s]: %w", name, err)
+	}
+	ours, err := dmmdata.New(oursPath)
+	if err != nil {
+		return fmt.Errorf("unable to parse our version of [%s]: %w", name, err)
+	}
+	theirs, err := dmmdata.New(theirsPath)
+	if err != nil {
+		return fmt.Errorf("unable to parse their version of [%s]: %w", name, err)
+	}
+
+	result, err := dmmmerge.Merge(base, ours, theirs)
+	if err != nil {
+		return fmt.Errorf("unable to merge [%s]: %w", name, err)
+	}
+
+	// Our version is used as a backup, so its keys and format are preserved.
+	if err = dmmsave.SaveV(nil, mergedDmm(result, oursPath), oursPath, dmmsave.Config{}); err != nil {
+		return fmt.Errorf("unable to save merged [%s]: %w", name, err)
+	}
+
+	if result.HasConflicts() {
+		fmt.Fprintf(os.Stderr, "%s: [%d] conflicting tiles, our version is kept for them:\n", name, len(result.Conflicts))
+		for _, coord := range result.Conflicts {
+			fmt.Fprintf(os.Stderr, "  (%d, %d, %d)\n", coord.X, coord.Y, coord.Z)
+		}
+		return &exitError{code: exitCodeError}
+	}
+
+	return nil
+}
+
+// Creates a map from the merge result. Its prefabs are not linked with the environment,
+// but it's enough to save it, since the saving only compares prefabs by their IDs.
+func mergedDmm(result *dmmmerge.Result, backup string) *dmmap.Dmm {
+	dmm := &dmmap.Dmm{
+		Tiles:  make([]*dmmap.Tile, 0, result.MaxX*result.MaxY*result.MaxZ),
+		MaxX:   result.MaxX,
+		MaxY:   result.MaxY,
+		MaxZ:   result.MaxZ,
+		Backup: backup,
+	}
+
+	// Tiles are stored in the same order as the dmmap does.
+	for z := 1; z <= result.MaxZ; z++ {
+		for y := 1; y <= result.MaxY; y++ {
+			for x := 1; x <= result.MaxX; x++ {
+				tile := &dmmap.Tile{Coord: util.Point{X: x, Y: y, Z: z}}
+				tile.InstancesSet(result.Tiles[tile.Coord])
+				dmm.Tiles = append(dmm.Tiles, tile)
+			}
+		}
+	}
+
+	return dmm
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"sdmm/internal/app/prefs"
+	"sdmm/internal/dmapi/dmenv"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/dmapi/dmmap/dmmdata"
+	"sdmm/internal/dmapi/dmmmigrate"
+)
+
+func runMigrate(args []string) error {
+	flags, verbose := newFlagSet(CNMigrate)
+	rulesPaths := flags.String("rules", "", "comma separated rules files or directories (taken from the project preferences by default)")
+	dryRun := flags.Bool("dry-run", false, "report changes without saving maps")
+	dmePath := flags.String("dme", "", "environment file (found from the current directory by default)")
+
+	positional, err := parseFlags(flags, verbose, args)
+	if err != nil {
+		return err
+	}
+
+	// The environment isn't parsed, it's only needed to find project preferences and maps.
+	if len(*dmePath) == 0 {
+		base := filepath.Join(".", "map.dmm")
+		if len(positional) != 0 {
+			base = positional[0]
+		}
+		if base, err = filepath.Abs(base); err != nil {
+			return err
+		}
+		if *dmePath, err = dmenv.FindFromBase(base); err != nil {
+			return fmt.Errorf("unable to find environment, use --dme to provide it")
+		}
+	}
+
+	project, err := prefs.LoadProject(*dmePath)
+	if err != nil {
+		return fmt.Errorf("unable to load project preferences: %w", err)
+	}
+
+	paths := splitList(*rulesPaths)
+	if len(paths) == 0 {
+		paths = project.MigrationRulesPaths(*dmePath)
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no migration rules, use --rules or the project preferences to provide them")
+	}
+
+	rules, err := dmmmigrate.Load(paths...)
+	if err != nil {
+		return fmt.Errorf("unable to load migration rules: %w", err)
+	}
+
+	// Placeholders are migrated only when the project configures obsolete paths, since user preferences are unknown here.
+	cfg := dmmap.ObsoleteConfig{Migrations: rules}
+	if project != nil {
+		cfg.ObjectPath = valueOf(project.ObsoleteObjectPath)
+		cfg.TurfPath = valueOf(project.ObsoleteTurfPath)
+		cfg.AreaPath = valueOf(project.ObsoleteAreaPath)
+	}
+
+	mapPaths := positional
+	if len(mapPaths) == 0 {
+		if mapPaths, err = findMaps(filepath.Dir(*dmePath)); err != nil {
+			return err
+		}
+	}
+
+	for _, mapPath := range mapPaths {
+		data, err := dmmdata.New(mapPath)
+		if err != nil {
+			return fmt.Errorf("unable to parse map [%s]: %w", mapPath, err)
+		}
+
+		changed := dmmap.MigrateData(data, cfg)
+		if changed == 0 {
+			continue
+		}
+
+		fmt.Printf("%s: %d prefabs migrated\n", mapPath, changed)
+		if !*dryRun {
+			if err = data.Save(); err != nil {
+				return fmt.Errorf("unable to save map [%s]: %w", mapPath, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// findMaps returns paths of all maps inside the directory and its subdirectories.
+func findMaps(root string) ([]string, error) {
+	var maps []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && filepath.Ext(path) == ".dmm" {
+			maps = append(maps, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to find maps in [%s]: %w", root, err)
+	}
+	return maps, nil
+}
+
+func valueOf(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"fmt"
+	"image/png"
+	"os"
+	"strconv"
+	"strings"
+
+	"sdmm/internal/app/render/softrender"
+	"sdmm/internal/dmapi/dm"
+	"sdmm/internal/dmapi/dmicon"
+	"sdmm/internal/util"
+
+	"github.com/rs/zerolog/log"
+)
+
+func runRender(args []string) error {
+	fs, verbose := newFlagSet(CNRender)
+	z := fs.Int("z", 1, "map level to render")
+	out := fs.String("out", "", "output PNG path")
+	region := fs.String("region", "", "inclusive tiles region to render: x1,y1,x2,y2 (the whole level by default)")
+	scale := fs.Float64("scale", 1, "multiplier of the result image size")
+	only := fs.String("only", "", "comma separated paths to render, e.g. /turf,/obj (everything by default)")
+	hide := fs.String("hide", "", "comma separated paths to hide, e.g. /area,/obj/effect")
+	dmePath := fs.String("dme", "", "environment file (found from the map location by default)")
+
+	positional, err := parseFlags(fs, verbose, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 || len(*out) == 0 || *scale <= 0 {
+		return errUsage
+	}
+
+	cfg := softrender.Config{
+		Z:          *z,
+		Scale:      float32(*scale),
+		PathFilter: pathFilter(splitList(*only), splitList(*hide)),
+	}
+	if len(*region) != 0 {
+		if cfg.Region, err = parseRegion(*region); err != nil {
+			return err
+		}
+	}
+
+	mapPath := positional[0]
+
+	env, err := loadEnvironment(*dmePath, mapPath)
+	if err != nil {
+		return err
+	}
+
+	dmicon.Headless = true
+	dmicon.Cache.SetRootDirPath(env.RootDir)
+
+	dmm, unknownPaths, err := loadMap(env, mapPath)
+	if err != nil {
+		return err
+	}
+	if len(unknownPaths) != 0 {
+		log.Warn().Msgf("unknown types are not rendered: %s", strings.Join(unknownPaths, ", "))
+	}
+
+	img, err := softrender.Render(dmm, cfg)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return fmt.Errorf("unable to create [%s]: %w", *out, err)
+	}
+	defer f.Close()
+
+	if err = png.Encode(f, img); err != nil {
+		return fmt.Errorf("unable to write [%s]: %w", *out, err)
+	}
+	return nil
+}
+
+// parseRegion parses the "x1,y1,x2,y2" string into tile bounds.
+func parseRegion(value string) (util.Bounds, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return util.Bounds{}, fmt.Errorf("invalid region [%s], expected: x1,y1,x2,y2", value)
+	}
+
+	var coords [4]float32
+	for idx, part := range parts {
+		coord, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || coord < 1 {
+			return util.Bounds{}, fmt.Errorf("invalid region [%s], coordinates should be positive numbers", value)
+		}
+		coords[idx] = float32(coord)
+	}
+
+	return util.Bounds{
+		X1: min(coords[0], coords[2]),
+		Y1: min(coords[1], coords[3]),
+		X2: max(coords[0], coords[2]),
+		Y2: max(coords[1], coords[3]),
+	}, nil
+}
+
+// pathFilter returns a filter which accepts children of the shown paths and rejects children of the hidden ones.
+// Hidden paths have priority, so "/obj" can be shown without "/obj/effect".
+func pathFilter(shown, hidden []string) func(string) bool {
+	if len(shown) == 0 && len(hidden) == 0 {
+		return nil
+	}
+	return func(path string) bool {
+		for _, hiddenPath := range hidden {
+			if dm.IsPath(path, hiddenPath) {
+				return false
+			}
+		}
+		if len(shown) == 0 {
+			return true
+		}
+		for _, shownPath := range shown {
+			if dm.IsPath(path, shownPath) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"testing"
+
+	"sdmm/internal/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRegion(t *testing.T) {
+	region, err := parseRegion("5, 10,1,2")
+	assert.NoError(t, err)
+	assert.Equal(t, util.Bounds{X1: 1, Y1: 2, X2: 5, Y2: 10}, region)
+
+	for _, value := range []string{"", "1,2,3", "1,2,3,a", "0,1,2,3", "1,-1,2,3"} {
+		_, err = parseRegion(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestPathFilter(t *testing.T) {
+	assert.Nil(t, pathFilter(nil, nil))
+
+	filter := pathFilter([]string{"/obj", "/turf"}, []string{"/obj/effect"})
+	assert.True(t, filter("/obj/item"))
+	assert.True(t, filter("/turf/floor"))
+	assert.False(t, filter("/obj/effect/spawner"))
+	assert.False(t, filter("/area/space"))
+
+	filter = pathFilter(nil, []string{"/area"})
+	assert.True(t, filter("/mob"))
+	assert.False(t, filter("/area/space"))
+}
@@ -0,0 +1,136 @@
+package dm
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ColorMatrix is a color transformation matrix, set with a list in the color variable.
+// Rows are contributions of the red, green, blue and alpha channels to the result color, the last row is a constant.
+// A color is transformed as: r' = r*M[0][0] + g*M[1][0] + b*M[2][0] + a*M[3][0] + M[4][0], and so on for other channels.
+type ColorMatrix [5][4]float32
+
+func ColorMatrixIdentity() ColorMatrix {
+	return ColorMatrix{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}, {0, 0, 0, 0}}
+}
+
+// Apply transforms the color. Channels are in the range from 0 to 1, the result is clamped to it.
+func (m ColorMatrix) Apply(r, g, b, a float32) (float32, float32, float32, float32) {
+	var result [4]float32
+	for i := range result {
+		v := r*m[0][i] + g*m[1][i] + b*m[2][i] + a*m[3][i] + m[4][i]
+		result[i] = min(max(v, 0), 1)
+	}
+	return result[0], result[1], result[2], result[3]
+}
+
+// ParseColorMatrix parses a list value of the color variable.
+// Supported are lists of 9, 12, 16 or 20 numbers and lists of 3 to 5 row colors, where null means the default row.
+// The last returned value is false, if the value isn't a color matrix.
+func ParseColorMatrix(value string) (ColorMatrix, bool) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "list(") || !strings.HasSuffix(value, ")") {
+		return ColorMatrixIdentity(), false
+	}
+
+	var elements []string
+	if content := strings.TrimSpace(value[len("list(") : len(value)-1]); content != "" {
+		elements = strings.Split(content, ",")
+	}
+	for idx, element := range elements {
+		elements[idx] = strings.TrimSpace(element)
+	}
+
+	if len(elements) > 0 && (strings.HasPrefix(elements[0], `"`) || elements[0] == "null") {
+		return parseColorMatrixRows(elements)
+	}
+	return parseColorMatrixNumbers(elements)
+}
+
+func parseColorMatrixNumbers(elements []string) (ColorMatrix, bool) {
+	var rowSize int
+	switch len(elements) {
+	case 9, 12:
+		rowSize = 3 // The alpha isn't affected.
+	case 16, 20:
+		rowSize = 4
+	default:
+		return ColorMatrixIdentity(), false
+	}
+
+	m := ColorMatrixIdentity()
+	for idx, element := range elements {
+		n, err := strconv.ParseFloat(element, 32)
+		if err != nil {
+			return ColorMatrixIdentity(), false
+		}
+
+		row, column := idx/rowSize, idx%rowSize
+		// The constant row goes right after color rows, if there is no alpha row.
+		if rowSize == 3 && row == 3 {
+			row = 4
+		}
+		m[row][column] = float32(n)
+	}
+	return m, true
+}
+
+func parseColorMatrixRows(elements []string) (ColorMatrix, bool) {
+	if len(elements) < 3 || len(elements) > 5 {
+		return ColorMatrixIdentity(), false
+	}
+
+	m := ColorMatrixIdentity()
+	for row, element := range elements {
+		if element == "null" {
+			continue
+		}
+		if len(element) < 2 || !strings.HasPrefix(element, `"`) || !strings.HasSuffix(element, `"`) {
+			return ColorMatrixIdentity(), false
+		}
+
+		// Only the alpha row keeps the alpha, when it's omitted.
+		defaultAlpha := float32(0)
+		if row == 3 {
+			defaultAlpha = 1
+		}
+
+		rgba, ok := parseHexColor(element[1:len(element)-1], defaultAlpha)
+		if !ok {
+			return ColorMatrixIdentity(), false
+		}
+		m[row] = rgba
+	}
+	return m, true
+}
+
+// Parses colors in the #rgb, #rgba, #rrggbb or #rrggbbaa format.
+func parseHexColor(value string, defaultAlpha float32) (rgba [4]float32, ok bool) {
+	if !strings.HasPrefix(value, "#") {
+		return rgba, false
+	}
+	value = value[1:]
+
+	var channelSize int
+	switch len(value) {
+	case 3, 4:
+		channelSize = 1
+	case 6, 8:
+		channelSize = 2
+	default:
+		return rgba, false
+	}
+
+	rgba[3] = defaultAlpha
+	for idx := 0; idx < len(value)/channelSize; idx++ {
+		n, err := strconv.ParseUint(value[idx*channelSize:(idx+1)*channelSize], 16, 8)
+		if err != nil {
+			return rgba, false
+		}
+		if channelSize == 1 {
+			n *= 17 // 0xf -> 0xff
+		}
+		rgba[idx] = float32(n) / 255
+	}
+	return rgba, true
+}
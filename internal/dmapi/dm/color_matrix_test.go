@@ -0,0 +1,50 @@
+package dm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseColorMatrix(t *testing.T) {
+	grayscale := ColorMatrix{{.3, .3, .3, 0}, {.59, .59, .59, 0}, {.11, .11, .11, 0}, {0, 0, 0, 1}, {0, 0, 0, 0}}
+
+	tests := []struct {
+		value    string
+		expected ColorMatrix
+	}{
+		{"list(0.3,0.3,0.3, 0.59,0.59,0.59, 0.11,0.11,0.11)", grayscale},
+		{"list(1,0,0, 0,1,0, 0,0,1, 0.5,-0.5,0)", ColorMatrix{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}, {.5, -.5, 0, 0}}},
+		{"list(1,0,0,0, 0,1,0,0, 0,0,1,0, 0,0,0,0.5)", ColorMatrix{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, .5}, {0, 0, 0, 0}}},
+		{"list(0,0,1,0, 0,1,0,0, 1,0,0,0, 0,0,0,1, 0,0,0,0.25)", ColorMatrix{{0, 0, 1, 0}, {0, 1, 0, 0}, {1, 0, 0, 0}, {0, 0, 0, 1}, {0, 0, 0, .25}}},
+		{`list("#ff0000", "#00ff00", "#0000ff")`, ColorMatrixIdentity()},
+		{`list("#00f", null, "#f00", "#00000080", "#fff")`, ColorMatrix{{0, 0, 1, 0}, {0, 1, 0, 0}, {1, 0, 0, 0}, {0, 0, 0, 128. / 255}, {1, 1, 1, 0}}},
+	}
+
+	for _, test := range tests {
+		m, ok := ParseColorMatrix(test.value)
+		assert.True(t, ok, test.value)
+		for row := range m {
+			assert.InDeltaSlice(t, test.expected[row][:], m[row][:], .0001, test.value)
+		}
+	}
+
+	for _, value := range []string{"", "null", `"#ff0000"`, "list()", "list(1,0,0)", `list("#ff0000", "red", "#0000ff")`, "list(1,0,0, 0,1,0, 0,0,a)"} {
+		_, ok := ParseColorMatrix(value)
+		assert.False(t, ok, value)
+	}
+}
+
+func TestColorMatrixApply(t *testing.T) {
+	m, _ := ParseColorMatrix("list(0.3,0.3,0.3, 0.59,0.59,0.59, 0.11,0.11,0.11, 0.5,0,0)")
+	r, g, b, a := m.Apply(1, 1, 1, .5)
+	assert.InDelta(t, 1, r, .0001, "should be clamped")
+	assert.InDelta(t, 1, g, .0001)
+	assert.InDelta(t, 1, b, .0001)
+	assert.InDelta(t, .5, a, .0001)
+
+	r, g, b, _ = m.Apply(1, 0, 0, 1)
+	assert.InDelta(t, .8, r, .0001)
+	assert.InDelta(t, .3, g, .0001)
+	assert.InDelta(t, .3, b, .0001)
+}
@@ -0,0 +1,31 @@
+package dm
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ParseIconSize parses a value of the world.icon_size variable.
+// The value is a number for square icons, or a text like "32x48" with the width and the height.
+// The last returned value is false, if the value has an invalid format.
+func ParseIconSize(value string) (width, height int, ok bool) {
+	if size, err := strconv.Atoi(value); err == nil {
+		return size, size, size > 0
+	}
+
+	text, err := strconv.Unquote(value)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	w, h, found := strings.Cut(text, "x")
+	if !found {
+		return 0, 0, false
+	}
+	width, errW := strconv.Atoi(strings.TrimSpace(w))
+	height, errH := strconv.Atoi(strings.TrimSpace(h))
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return 0, 0, false
+	}
+	return width, height, true
+}
@@ -0,0 +1,33 @@
+package dm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIconSize(t *testing.T) {
+	tests := []struct {
+		value         string
+		width, height int
+		ok            bool
+	}{
+		{"32", 32, 32, true},
+		{"64", 64, 64, true},
+		{`"32x48"`, 32, 48, true},
+		{`"32 x 64"`, 32, 64, true},
+		{`"48x32"`, 48, 32, true},
+		{`"32"`, 0, 0, false},
+		{`"32x"`, 0, 0, false},
+		{`"0x32"`, 0, 0, false},
+		{"0", 0, 0, false},
+		{"null", 0, 0, false},
+		{"32x48", 0, 0, false},
+	}
+	for _, test := range tests {
+		width, height, ok := ParseIconSize(test.value)
+		assert.Equal(t, test.ok, ok, "value: %s", test.value)
+		assert.Equal(t, test.width, width, "value: %s", test.value)
+		assert.Equal(t, test.height, height, "value: %s", test.value)
+	}
+}
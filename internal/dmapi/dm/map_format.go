@@ -0,0 +1,32 @@
+package dm
+
+import "strconv"
+
+// Values of the world.map_format variable.
+const (
+	MapFormatTopdown   = 0
+	MapFormatIsometric = 1
+	MapFormatSide      = 2
+)
+
+// ParseMapFormat parses a value of the world.map_format variable.
+// The value could be a number or a name of the built-in constant.
+// The last returned value is false, if the map format is unknown.
+func ParseMapFormat(value string) (int, bool) {
+	switch value {
+	case "TOPDOWN_MAP":
+		return MapFormatTopdown, true
+	case "ISOMETRIC_MAP":
+		return MapFormatIsometric, true
+	case "SIDE_MAP":
+		return MapFormatSide, true
+	}
+
+	if format, err := strconv.Atoi(value); err == nil {
+		switch format {
+		case MapFormatTopdown, MapFormatIsometric, MapFormatSide:
+			return format, true
+		}
+	}
+	return MapFormatTopdown, false
+}
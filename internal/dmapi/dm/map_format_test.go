@@ -0,0 +1,28 @@
+package dm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMapFormat(t *testing.T) {
+	tests := []struct {
+		value  string
+		format int
+		ok     bool
+	}{
+		{"0", MapFormatTopdown, true},
+		{"1", MapFormatIsometric, true},
+		{"2", MapFormatSide, true},
+		{"SIDE_MAP", MapFormatSide, true},
+		{"ISOMETRIC_MAP", MapFormatIsometric, true},
+		{"32768", MapFormatTopdown, false},
+		{"null", MapFormatTopdown, false},
+	}
+	for _, test := range tests {
+		format, ok := ParseMapFormat(test.value)
+		assert.Equal(t, test.ok, ok, "value: %s", test.value)
+		assert.Equal(t, test.format, format, "value: %s", test.value)
+	}
+}
@@ -0,0 +1,323 @@
+package dm
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Matrix is a 2D transformation matrix, the same as the /matrix datum.
+// A point is transformed as: x' = A*x + B*y + C, y' = D*x + E*y + F.
+type Matrix struct {
+	A, B, C float32
+	D, E, F float32
+}
+
+// Values of built-in constants used by the matrix() proc.
+const (
+	matrixRotate    = 5
+	matrixScale     = 6
+	matrixTranslate = 7
+)
+
+func MatrixIdentity() Matrix {
+	return Matrix{A: 1, E: 1}
+}
+
+func (m Matrix) IsIdentity() bool {
+	return m == MatrixIdentity()
+}
+
+// Multiply returns a matrix which applies the current matrix and then the provided one.
+func (m Matrix) Multiply(n Matrix) Matrix {
+	return Matrix{
+		A: n.A*m.A + n.B*m.D,
+		B: n.A*m.B + n.B*m.E,
+		C: n.A*m.C + n.B*m.F + n.C,
+		D: n.D*m.A + n.E*m.D,
+		E: n.D*m.B + n.E*m.E,
+		F: n.D*m.C + n.E*m.F + n.F,
+	}
+}
+
+// Turn returns the matrix rotated clockwise by the angle in degrees.
+func (m Matrix) Turn(angle float32) Matrix {
+	rad := float64(angle) * math.Pi / 180
+	sin, cos := float32(math.Sin(rad)), float32(math.Cos(rad))
+	return m.Multiply(Matrix{A: cos, B: sin, D: -sin, E: cos})
+}
+
+func (m Matrix) Scale(x, y float32) Matrix {
+	return m.Multiply(Matrix{A: x, E: y})
+}
+
+func (m Matrix) Translate(x, y float32) Matrix {
+	return m.Multiply(Matrix{A: 1, C: x, E: 1, F: y})
+}
+
+// Apply transforms the point.
+func (m Matrix) Apply(x, y float32) (float32, float32) {
+	return m.A*x + m.B*y + m.C, m.D*x + m.E*y + m.F
+}
+
+// Invert returns the inverse matrix. The second value is false, if the matrix can't be inverted.
+func (m Matrix) Invert() (Matrix, bool) {
+	det := m.A*m.E - m.B*m.D
+	if det == 0 {
+		return Matrix{}, false
+	}
+	a, b, d, e := m.E/det, -m.B/det, -m.D/det, m.A/det
+	return Matrix{
+		A: a, B: b, C: -(a*m.C + b*m.F),
+		D: d, E: e, F: -(d*m.C + e*m.F),
+	}, true
+}
+
+// ParseMatrix evaluates a value of the transform variable.
+// Supported are matrix() calls with their rotate, scale and translate forms, the turn() proc,
+// multiplication of matrices and their multiplication or division by numbers.
+// The last returned value is false, if the value can't be evaluated to a matrix.
+func ParseMatrix(value string) (Matrix, bool) {
+	p := &matrixParser{src: value}
+	v, err := p.parseExpr()
+	if p.skipSpaces(); err == nil && p.pos != len(p.src) {
+		err = fmt.Errorf("unexpected symbol at %d", p.pos)
+	}
+	if err != nil || !v.isMatrix {
+		return MatrixIdentity(), false
+	}
+	return v.m, true
+}
+
+type matrixValue struct {
+	isMatrix bool
+	m        Matrix
+	n        float32
+}
+
+type matrixParser struct {
+	src string
+	pos int
+}
+
+func (p *matrixParser) skipSpaces() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *matrixParser) peek() byte {
+	p.skipSpaces()
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *matrixParser) expect(c byte) error {
+	if p.peek() != c {
+		return fmt.Errorf("expected '%c' at %d", c, p.pos)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *matrixParser) parseExpr() (matrixValue, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return left, err
+	}
+
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' {
+			return left, nil
+		}
+		p.pos++
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return right, err
+		}
+		if left, err = applyMatrixOp(left, right, op); err != nil {
+			return left, err
+		}
+	}
+}
+
+func applyMatrixOp(left, right matrixValue, op byte) (matrixValue, error) {
+	switch {
+	case !left.isMatrix && !right.isMatrix:
+		if op == '*' {
+			return matrixValue{n: left.n * right.n}, nil
+		}
+		return matrixValue{n: left.n / right.n}, nil
+	case left.isMatrix && right.isMatrix:
+		if op == '*' {
+			return matrixValue{isMatrix: true, m: left.m.Multiply(right.m)}, nil
+		}
+		inverted, ok := right.m.Invert()
+		if !ok {
+			return left, fmt.Errorf("division by a non-invertible matrix")
+		}
+		return matrixValue{isMatrix: true, m: left.m.Multiply(inverted)}, nil
+	case left.isMatrix:
+		if op == '/' {
+			return matrixValue{isMatrix: true, m: scaleMatrixValues(left.m, 1/right.n)}, nil
+		}
+		return matrixValue{isMatrix: true, m: scaleMatrixValues(left.m, right.n)}, nil
+	default:
+		if op == '/' {
+			return left, fmt.Errorf("division by a matrix")
+		}
+		return matrixValue{isMatrix: true, m: scaleMatrixValues(right.m, left.n)}, nil
+	}
+}
+
+// Multiplication of a matrix by a number multiplies all its values.
+func scaleMatrixValues(m Matrix, n float32) Matrix {
+	return Matrix{A: m.A * n, B: m.B * n, C: m.C * n, D: m.D * n, E: m.E * n, F: m.F * n}
+}
+
+func (p *matrixParser) parseUnary() (matrixValue, error) {
+	switch c := p.peek(); {
+	case c == '-':
+		p.pos++
+		v, err := p.parseUnary()
+		if err == nil && v.isMatrix {
+			return v, fmt.Errorf("negation of a matrix")
+		}
+		return matrixValue{n: -v.n}, err
+	case c == '(':
+		p.pos++
+		v, err := p.parseExpr()
+		if err != nil {
+			return v, err
+		}
+		return v, p.expect(')')
+	case c == '.' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	case c == '_' || unicode.IsLetter(rune(c)):
+		return p.parseIdent()
+	}
+	return matrixValue{}, fmt.Errorf("unexpected symbol at %d", p.pos)
+}
+
+func (p *matrixParser) parseNumber() (matrixValue, error) {
+	start := p.pos
+	for p.pos < len(p.src) && strings.IndexByte("0123456789.eE", p.src[p.pos]) != -1 {
+		// The exponent could have a sign.
+		if (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') && p.pos+1 < len(p.src) && (p.src[p.pos+1] == '-' || p.src[p.pos+1] == '+') {
+			p.pos++
+		}
+		p.pos++
+	}
+	n, err := strconv.ParseFloat(p.src[start:p.pos], 32)
+	return matrixValue{n: float32(n)}, err
+}
+
+func (p *matrixParser) parseIdent() (matrixValue, error) {
+	start := p.pos
+	for p.pos < len(p.src) && (p.src[p.pos] == '_' || unicode.IsLetter(rune(p.src[p.pos])) || unicode.IsDigit(rune(p.src[p.pos]))) {
+		p.pos++
+	}
+	name := p.src[start:p.pos]
+
+	switch name {
+	case "MATRIX_ROTATE":
+		return matrixValue{n: matrixRotate}, nil
+	case "MATRIX_SCALE":
+		return matrixValue{n: matrixScale}, nil
+	case "MATRIX_TRANSLATE":
+		return matrixValue{n: matrixTranslate}, nil
+	case "matrix", "turn":
+	default:
+		return matrixValue{}, fmt.Errorf("unknown identifier: %s", name)
+	}
+
+	args, err := p.parseArgs()
+	if err != nil {
+		return matrixValue{}, err
+	}
+
+	var m Matrix
+	var ok bool
+	if name == "matrix" {
+		m, ok = matrixCall(args)
+	} else {
+		m, ok = turnCall(args)
+	}
+	if !ok {
+		return matrixValue{}, fmt.Errorf("unsupported arguments of %s()", name)
+	}
+	return matrixValue{isMatrix: true, m: m}, nil
+}
+
+func (p *matrixParser) parseArgs() ([]matrixValue, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	var args []matrixValue
+	if p.peek() == ')' {
+		p.pos++
+		return args, nil
+	}
+	for {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		if p.peek() == ',' {
+			p.pos++
+			continue
+		}
+		return args, p.expect(')')
+	}
+}
+
+func matrixCall(args []matrixValue) (Matrix, bool) {
+	for idx, arg := range args {
+		// Only the first argument could be a matrix to copy.
+		if arg.isMatrix && (idx != 0 || len(args) != 1) {
+			return Matrix{}, false
+		}
+	}
+
+	switch len(args) {
+	case 0:
+		return MatrixIdentity(), true
+	case 1:
+		return args[0].m, args[0].isMatrix
+	case 2:
+		if args[1].n == matrixRotate {
+			return MatrixIdentity().Turn(args[0].n), true
+		}
+		if args[1].n == matrixScale {
+			return MatrixIdentity().Scale(args[0].n, args[0].n), true
+		}
+	case 3:
+		if args[2].n == matrixScale {
+			return MatrixIdentity().Scale(args[0].n, args[1].n), true
+		}
+		if args[2].n == matrixTranslate {
+			return MatrixIdentity().Translate(args[0].n, args[1].n), true
+		}
+	case 6:
+		return Matrix{
+			A: args[0].n, B: args[1].n, C: args[2].n,
+			D: args[3].n, E: args[4].n, F: args[5].n,
+		}, true
+	}
+	return Matrix{}, false
+}
+
+func turnCall(args []matrixValue) (Matrix, bool) {
+	if len(args) != 2 || !args[0].isMatrix || args[1].isMatrix {
+		return Matrix{}, false
+	}
+	return args[0].m.Turn(args[1].n), true
+}
@@ -0,0 +1,72 @@
+package dm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertMatrix(t *testing.T, expected, actual Matrix, msgAndArgs ...any) {
+	t.Helper()
+	assert.InDeltaSlice(t,
+		[]float32{expected.A, expected.B, expected.C, expected.D, expected.E, expected.F},
+		[]float32{actual.A, actual.B, actual.C, actual.D, actual.E, actual.F},
+		.0001, msgAndArgs...)
+}
+
+func TestMatrixTurn(t *testing.T) {
+	// A clockwise rotation: the point above the center goes to the right.
+	x, y := MatrixIdentity().Turn(90).Apply(0, 1)
+	assert.InDelta(t, 1, x, .0001)
+	assert.InDelta(t, 0, y, .0001)
+}
+
+func TestMatrixMultiply(t *testing.T) {
+	// Scale first, then translate.
+	m := MatrixIdentity().Scale(2, 2).Translate(10, 0)
+	x, y := m.Apply(1, 1)
+	assert.InDelta(t, 12, x, .0001)
+	assert.InDelta(t, 2, y, .0001)
+
+	inverted, ok := m.Invert()
+	assert.True(t, ok)
+	x, y = inverted.Apply(12, 2)
+	assert.InDelta(t, 1, x, .0001)
+	assert.InDelta(t, 1, y, .0001)
+}
+
+func TestParseMatrix(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected Matrix
+	}{
+		{"matrix()", MatrixIdentity()},
+		{"matrix(1, 0, 0, 0, 1, 0)", MatrixIdentity()},
+		{"matrix(0.5,0,16,0,0.5,-8)", Matrix{A: .5, C: 16, E: .5, F: -8}},
+		{"matrix()*2", Matrix{A: 2, E: 2}},
+		{"2 * matrix()", Matrix{A: 2, E: 2}},
+		{"matrix() / 2", Matrix{A: .5, E: .5}},
+		{"matrix(2, 3, MATRIX_SCALE)", Matrix{A: 2, E: 3}},
+		{"matrix(1.5, 6)", Matrix{A: 1.5, E: 1.5}},
+		{"matrix(4, -8, MATRIX_TRANSLATE)", Matrix{A: 1, C: 4, E: 1, F: -8}},
+		{"matrix(90, MATRIX_ROTATE)", Matrix{B: 1, D: -1}},
+		{"turn(matrix(), 90)", Matrix{B: 1, D: -1}},
+		{"turn(matrix(), -90)", Matrix{B: -1, D: 1}},
+		{"turn(matrix() * 2, 180)", Matrix{A: -2, E: -2}},
+		{"matrix(2, MATRIX_SCALE) * matrix(1, 0, 4, 0, 1, 0)", Matrix{A: 2, C: 4, E: 2}},
+		{"(matrix())", MatrixIdentity()},
+	}
+	for _, test := range tests {
+		m, ok := ParseMatrix(test.value)
+		assert.True(t, ok, "value: %s", test.value)
+		assertMatrix(t, test.expected, m, "value: %s", test.value)
+	}
+}
+
+func TestParseMatrixInvalid(t *testing.T) {
+	for _, value := range []string{"", "null", "2", "matrix(1, 2)", "matrix(", "turn(2, 90)", "matrix() matrix()", "list()"} {
+		m, ok := ParseMatrix(value)
+		assert.False(t, ok, "value: %s", value)
+		assert.Equal(t, MatrixIdentity(), m, "value: %s", value)
+	}
+}
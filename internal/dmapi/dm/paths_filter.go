@@ -9,6 +9,7 @@ import (
 type PathsFilter struct {
 	findDirectChildren func(string) []string
 	filteredPaths      map[string]bool
+	version            uint64
 }
 
 func NewPathsFilter(findDirectChildren func(string) []string) *PathsFilter {
@@ -26,6 +27,7 @@ func NewPathsFilterEmpty() *PathsFilter {
 
 func (p *PathsFilter) Clear() {
 	p.filteredPaths = make(map[string]bool)
+	p.version++
 }
 
 func (p *PathsFilter) Copy() PathsFilter {
@@ -36,9 +38,16 @@ func (p *PathsFilter) Copy() PathsFilter {
 	return PathsFilter{
 		p.findDirectChildren,
 		filteredPaths,
+		p.version,
 	}
 }
 
+// Version is changed with every change of filtered paths.
+// Used to know if something filtered by the filter should be updated.
+func (p *PathsFilter) Version() uint64 {
+	return p.version
+}
+
 func (p *PathsFilter) IsHiddenPath(path string) bool {
 	return p.filteredPaths[path]
 }
@@ -61,7 +70,14 @@ func (p *PathsFilter) TogglePath(path string) {
 	log.Printf("toggle [%s] path: [%t]", path, p.IsVisiblePath(path))
 }
 
+// HidePath hides the path with all its children.
+func (p *PathsFilter) HidePath(path string) {
+	p.togglePath(path, true)
+	log.Printf("hide [%s] path", path)
+}
+
 func (p *PathsFilter) togglePath(path string, isFilteredOut bool) {
+	p.version++
 	for _, directChild := range p.findDirectChildren(path) {
 		p.togglePath(directChild, isFilteredOut)
 	}
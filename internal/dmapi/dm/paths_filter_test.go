@@ -0,0 +1,29 @@
+package dm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathsFilter_Version(t *testing.T) {
+	f := NewPathsFilter(func(path string) []string {
+		if path == "/obj" {
+			return []string{"/obj/item"}
+		}
+		return nil
+	})
+
+	version := f.Version()
+	f.TogglePath("/obj")
+	assert.True(t, f.IsHiddenPath("/obj/item"))
+	assert.NotEqual(t, version, f.Version())
+
+	version = f.Version()
+	fCopy := f.Copy()
+	assert.Equal(t, version, fCopy.Version())
+
+	f.Clear()
+	assert.False(t, f.IsHiddenPath("/obj/item"))
+	assert.NotEqual(t, version, f.Version())
+}
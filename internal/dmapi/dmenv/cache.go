@@ -0,0 +1,187 @@
+package dmenv
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sdmm/internal/util"
+	"sdmm/third_party/sdmmparser"
+
+	"github.com/rs/zerolog/log"
+)
+
+// The parsed object tree is cached to not parse the whole codebase every time the environment is opened.
+// The cache is valid while sources of the environment are the same.
+//
+// Cache file is a gob stream with a header and the object tree after it.
+type cacheHeader struct {
+	SourcesHash uint64 // Hash of sizes and modification times of all code files.
+	TreeHash    uint64 // Hash of the encoded object tree.
+}
+
+// NewCached creates the environment from the cache stored in the provided directory.
+// If there is no cache or sources were changed, the environment is parsed and the cache is updated.
+// The second returned value is true, when the environment was taken from the cache.
+func NewCached(path, cacheDir string) (*Dme, bool, error) {
+	cachePath := cacheFilePath(path, cacheDir)
+
+	sourcesHash, err := SourcesHash(filepath.Dir(path))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if header, tree, err := readCache(cachePath, true); err == nil && header.SourcesHash == sourcesHash {
+		log.Print("environment loaded from the cache:", cachePath)
+		return fromTree(path, tree), true, nil
+	} else if err != nil && !os.IsNotExist(err) {
+		log.Printf("unable to read environment cache [%s]: %v", cachePath, err)
+	}
+
+	tree, err := sdmmparser.ParseEnvironment(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err = writeCache(cachePath, sourcesHash, tree); err != nil {
+		log.Printf("unable to write environment cache [%s]: %v", cachePath, err)
+	}
+
+	return fromTree(path, tree), false, nil
+}
+
+// UpdateCache parses the environment and compares the result with the cached one.
+// When they are different, the cache is rewritten and the parsed environment is returned, otherwise nil is returned.
+// Meant to be used to confirm the environment taken from the cache.
+func UpdateCache(path, cacheDir string) (*Dme, error) {
+	cachePath := cacheFilePath(path, cacheDir)
+
+	sourcesHash, err := SourcesHash(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := sdmmparser.ParseEnvironment(path)
+	if err != nil {
+		return nil, err
+	}
+
+	treeData, err := encodeTree(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	if header, _, err := readCache(cachePath, false); err == nil && header.TreeHash == util.Djb2(string(treeData)) {
+		log.Print("environment cache confirmed:", cachePath)
+		return nil, nil
+	}
+
+	log.Print("environment cache is outdated:", cachePath)
+	return fromTree(path, tree), writeCache(cachePath, sourcesHash, tree)
+}
+
+// SourcesHash returns a hash of sizes and modification times of all code files in the directory.
+// Files aren't read, so it's much faster than parsing. Hidden directories are skipped.
+func SourcesHash(rootDir string) (uint64, error) {
+	var sb strings.Builder
+	err := walkSources(rootDir, func(path string, d fs.DirEntry) error {
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		sb.WriteString(fmt.Sprintf("%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano()))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return util.Djb2(sb.String()), nil
+}
+
+// SourceFiles returns paths of all code files in the directory. Hidden directories are skipped.
+func SourceFiles(rootDir string) ([]string, error) {
+	var paths []string
+	err := walkSources(rootDir, func(path string, _ fs.DirEntry) error {
+		paths = append(paths, path)
+		return nil
+	})
+	return paths, err
+}
+
+func walkSources(rootDir string, fn func(path string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != rootDir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if ext := filepath.Ext(path); ext != ".dm" && ext != ".dme" {
+			return nil
+		}
+		return fn(path, d)
+	})
+}
+
+func cacheFilePath(path, cacheDir string) string {
+	return filepath.Join(cacheDir, fmt.Sprintf("%s_%x.gob", filepath.Base(path), util.Djb2(path)))
+}
+
+func readCache(cachePath string, withTree bool) (header cacheHeader, tree *sdmmparser.ObjectTreeType, err error) {
+	f, err := os.Open(cachePath)
+	if err != nil {
+		return header, nil, err
+	}
+	defer f.Close()
+
+	decoder := gob.NewDecoder(f)
+	if err = decoder.Decode(&header); err != nil {
+		return header, nil, err
+	}
+	if withTree {
+		tree = &sdmmparser.ObjectTreeType{}
+		err = decoder.Decode(tree)
+	}
+	return header, tree, err
+}
+
+func writeCache(cachePath string, sourcesHash uint64, tree *sdmmparser.ObjectTreeType) error {
+	treeData, err := encodeTree(tree)
+	if err != nil {
+		return err
+	}
+
+	// The tree is encoded again with the same encoder, since the gob stream can't be concatenated.
+	var buf bytes.Buffer
+	encoder := gob.NewEncoder(&buf)
+	if err = encoder.Encode(cacheHeader{SourcesHash: sourcesHash, TreeHash: util.Djb2(string(treeData))}); err != nil {
+		return err
+	}
+	if err = encoder.Encode(tree); err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(cachePath), os.ModePerm); err != nil {
+		return err
+	}
+	if err = os.WriteFile(cachePath, buf.Bytes(), os.ModePerm); err != nil {
+		return err
+	}
+
+	log.Print("environment cache written:", cachePath)
+	return nil
+}
+
+func encodeTree(tree *sdmmparser.ObjectTreeType) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(tree)
+	return buf.Bytes(), err
+}
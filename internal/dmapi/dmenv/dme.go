@@ -17,6 +17,15 @@ type Dme struct {
 }
 
 func New(path string) (*Dme, error) {
+	objectTreeType, err := sdmmparser.ParseEnvironment(path)
+	if err != nil {
+		return nil, err
+	}
+	return fromTree(path, objectTreeType), nil
+}
+
+// Creates the environment from the object tree made by the parser.
+func fromTree(path string, objectTreeType *sdmmparser.ObjectTreeType) *Dme {
 	dme := Dme{
 		Name:     filepath.Base(path),
 		RootDir:  filepath.Dir(path),
@@ -24,11 +33,6 @@ func New(path string) (*Dme, error) {
 		Objects:  make(map[string]*Object),
 	}
 
-	objectTreeType, err := sdmmparser.ParseEnvironment(path)
-	if err != nil {
-		return nil, err
-	}
-
 	traverseTree0(objectTreeType, "", nil, &dme)
 
 	for _, object := range dme.Objects {
@@ -40,7 +44,7 @@ func New(path string) (*Dme, error) {
 		}
 	}
 
-	return &dme, nil
+	return &dme
 }
 
 func nameFromPath(path string, parentName string) string {
@@ -0,0 +1,34 @@
+package dmenv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+)
+
+// FindFromBase goes through all parents starting from the provided file location and looks for a ".dme" file.
+func FindFromBase(path string) (string, error) {
+	for {
+		dir := filepath.Dir(path)
+
+		if dir == path {
+			return "", fmt.Errorf("unable to find environment")
+		}
+
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			log.Print("unable to read dir while looking for environment:", err)
+			return "", fmt.Errorf("unable to read dir [%s]: %w", dir, err)
+		}
+
+		for _, file := range files {
+			if filepath.Ext(file.Name()) == ".dme" {
+				return filepath.Join(dir, file.Name()), nil
+			}
+		}
+
+		path = filepath.Dir(path)
+	}
+}
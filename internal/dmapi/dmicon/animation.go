@@ -0,0 +1,102 @@
+package dmicon
+
+import (
+	"math"
+	"time"
+)
+
+var (
+	// AnimationEnabled toggles animation of icon states with several frames.
+	// When disabled, the first frame is always shown.
+	AnimationEnabled = true
+
+	animationStart    = time.Now()
+	animationPaused   bool
+	animationPausedAt time.Time
+)
+
+// defaultDelay is a delay of the frame in deciseconds, used when the icon state has no delays.
+const defaultDelay = 1
+
+// AnimationPaused returns true if animations are stopped at the current frame.
+func AnimationPaused() bool {
+	return animationPaused
+}
+
+// SetAnimationPaused stops animations at the current frame or resumes them from it.
+func SetAnimationPaused(paused bool) {
+	if paused == animationPaused {
+		return
+	}
+	if paused {
+		animationPausedAt = time.Now()
+	} else {
+		animationStart = animationStart.Add(time.Since(animationPausedAt))
+	}
+	animationPaused = paused
+}
+
+// Returns the time in deciseconds passed since animations were started, excluding time on pause.
+func animationTime() float64 {
+	now := time.Now()
+	if animationPaused {
+		now = animationPausedAt
+	}
+	return float64(now.Sub(animationStart)) / float64(100*time.Millisecond)
+}
+
+// Frame returns the index of the frame, which should be shown at the moment.
+func (s State) Frame() int {
+	if !AnimationEnabled || Headless || s.Frames <= 1 {
+		return 0
+	}
+	return s.frameAt(animationTime())
+}
+
+// SpriteAnimatedV returns the sprite of the current animation frame for the provided direction.
+func (s State) SpriteAnimatedV(dir int) *Sprite {
+	return s.SpriteByFrame(dir, s.Frame())
+}
+
+// Frames are shown one by one, and in the reverse order after that, if the state is rewound.
+// When the state has a limited number of loops, the animation ends on its last frame,
+// or on the first one, if the state is rewound.
+func (s State) frameAt(time float64) int {
+	sequenceLen := s.Frames
+	if s.Rewind {
+		sequenceLen = 2*s.Frames - 2
+	}
+	sequenceFrame := func(idx int) int {
+		if idx < s.Frames {
+			return idx
+		}
+		return 2*s.Frames - 2 - idx
+	}
+
+	var cycle float64
+	for idx := 0; idx < sequenceLen; idx++ {
+		cycle += s.delay(sequenceFrame(idx))
+	}
+
+	if s.Loop > 0 && time >= cycle*float64(s.Loop) {
+		if s.Rewind {
+			return sequenceFrame(0)
+		}
+		return s.Frames - 1
+	}
+
+	time = math.Mod(time, cycle)
+	for idx := 0; idx < sequenceLen; idx++ {
+		if time -= s.delay(sequenceFrame(idx)); time < 0 {
+			return sequenceFrame(idx)
+		}
+	}
+	return sequenceFrame(sequenceLen - 1)
+}
+
+func (s State) delay(frame int) float64 {
+	if frame < len(s.Delays) && s.Delays[frame] > 0 {
+		return float64(s.Delays[frame])
+	}
+	return defaultDelay
+}
@@ -0,0 +1,78 @@
+package dmicon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestState_delay(t *testing.T) {
+	tests := []struct {
+		name     string
+		delays   []float32
+		frame    int
+		expected float64
+	}{
+		{name: "frame delay", delays: []float32{1, 2.5, 3}, frame: 1, expected: 2.5},
+		{name: "no delays", frame: 1, expected: defaultDelay},
+		{name: "frame without delay", delays: []float32{1, 2}, frame: 2, expected: defaultDelay},
+		{name: "zero delay", delays: []float32{0, 2}, frame: 0, expected: defaultDelay},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := State{Frames: 3, Delays: test.delays}
+			assert.Equal(t, test.expected, s.delay(test.frame))
+		})
+	}
+}
+
+func TestState_frameAt(t *testing.T) {
+	tests := []struct {
+		name  string
+		state State
+		// Expected frames at times from zero with the step of a half of decisecond.
+		expected []int
+	}{
+		{
+			name:     "default delays",
+			state:    State{Frames: 3},
+			expected: []int{0, 0, 1, 1, 2, 2, 0, 0},
+		},
+		{
+			name:     "per-frame delays",
+			state:    State{Frames: 3, Delays: []float32{1, 2, .5}},
+			expected: []int{0, 0, 1, 1, 1, 1, 2, 0, 0},
+		},
+		{
+			name:     "loop stops on the last frame",
+			state:    State{Frames: 2, Loop: 2},
+			expected: []int{0, 0, 1, 1, 0, 0, 1, 1, 1, 1, 1},
+		},
+		{
+			name:     "rewind",
+			state:    State{Frames: 3, Rewind: true},
+			expected: []int{0, 0, 1, 1, 2, 2, 1, 1, 0, 0, 1},
+		},
+		{
+			name:     "rewind with per-frame delays",
+			state:    State{Frames: 3, Rewind: true, Delays: []float32{.5, 1, 1.5}},
+			expected: []int{0, 1, 1, 2, 2, 2, 1, 1, 0, 1},
+		},
+		{
+			name:     "rewind with loop ends on the first frame",
+			state:    State{Frames: 3, Rewind: true, Loop: 1},
+			expected: []int{0, 0, 1, 1, 2, 2, 1, 1, 0, 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			frames := make([]int, 0, len(test.expected))
+			for idx := range test.expected {
+				frames = append(frames, test.state.frameAt(float64(idx)/2))
+			}
+			assert.Equal(t, test.expected, frames)
+		})
+	}
+}
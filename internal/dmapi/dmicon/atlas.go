@@ -0,0 +1,106 @@
+package dmicon
+
+import (
+	"image"
+
+	"sdmm/internal/platform"
+	"sdmm/internal/util/rectpack"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/rs/zerolog/log"
+)
+
+// Icon sheets are packed into a few big textures, called pages, so sprites from different icons could be drawn
+// without switching textures. Otherwise, the brush batch is flushed on almost every sprite of a big map.
+//
+// Sheets are never moved. The space of a freed sheet is cleared and reused by next sheets, like reloaded icons,
+// and a page is deleted when all its sheets are freed. Sheets which don't fit into an empty page have their own textures.
+//
+// The padding keeps sheets from bleeding into each other only on the first mipmap level, where a texel covers 2 pixels.
+// Deeper levels mix edges of neighbour sheets, but they're used only when the map is zoomed out too far to notice that.
+const (
+	atlasMaxPageSize = 4096
+	atlasPadding     = 2
+)
+
+var iconsAtlas = &atlas{}
+
+type atlas struct {
+	pageSize int // Zero until the first page is created.
+	pages    []*atlasPage
+}
+
+type atlasPage struct {
+	texture uint32
+	packer  *rectpack.Packer
+	sheets  int
+	dirty   bool // Mipmaps should be regenerated.
+}
+
+// Places the image into one of pages. Returns false, if the image is too big for a page.
+// The commit method should be called after to make added images visible on mipmaps.
+func (a *atlas) add(img *image.NRGBA) (page *atlasPage, x, y int, ok bool) {
+	if a.pageSize == 0 {
+		a.pageSize = min(platform.MaxTextureSize(), atlasMaxPageSize)
+		log.Printf("atlas page size: [%d]", a.pageSize)
+	}
+
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if width > a.pageSize || height > a.pageSize {
+		return nil, 0, 0, false
+	}
+
+	for _, page = range a.pages {
+		if x, y, ok = page.packer.Pack(width, height); ok {
+			break
+		}
+	}
+
+	if !ok {
+		page = &atlasPage{
+			texture: platform.CreateEmptyTexture(a.pageSize, a.pageSize),
+			packer:  rectpack.New(a.pageSize, a.pageSize, atlasPadding),
+		}
+		a.pages = append(a.pages, page)
+		log.Printf("atlas page created; [%d] pages", len(a.pages))
+
+		if x, y, ok = page.packer.Pack(width, height); !ok {
+			return nil, 0, 0, false
+		}
+	}
+
+	platform.UpdateTexture(page.texture, x, y, img)
+	page.sheets++
+	page.dirty = true
+	return page, x, y, true
+}
+
+// Regenerates mipmaps of pages changed since the previous call.
+func (a *atlas) commit() {
+	for _, page := range a.pages {
+		if page.dirty {
+			platform.GenerateMipmap(page.texture)
+			page.dirty = false
+		}
+	}
+}
+
+// Releases a sheet added to the page at the provided area. The page is deleted, when it has no sheets.
+// The area is cleared, so old pixels don't bleed into next sheets placed there.
+func (a *atlas) remove(page *atlasPage, area image.Rectangle) {
+	if page.sheets--; page.sheets > 0 {
+		page.packer.Free(area.Min.X, area.Min.Y, area.Dx(), area.Dy())
+		platform.UpdateTexture(page.texture, area.Min.X, area.Min.Y, image.NewNRGBA(image.Rect(0, 0, area.Dx(), area.Dy())))
+		page.dirty = true
+		return
+	}
+
+	gl.DeleteTextures(1, &page.texture)
+	for idx, p := range a.pages {
+		if p == page {
+			a.pages = append(a.pages[:idx], a.pages[idx+1:]...)
+			break
+		}
+	}
+	log.Printf("atlas page deleted; [%d] pages", len(a.pages))
+}
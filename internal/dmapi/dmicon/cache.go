@@ -3,29 +3,89 @@ package dmicon
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
+	"sdmm/internal/app/window"
 	"sdmm/internal/dmapi/dm"
 
 	"github.com/rs/zerolog/log"
 )
 
-var Cache = &IconsCache{icons: make(map[string]*Dmi)}
+var Cache = &IconsCache{
+	icons:   make(map[string]*Dmi),
+	loading: make(map[string]bool),
+}
+
+// ErrIconLoading is returned for icons which are loaded in the background at the moment.
+var ErrIconLoading = errors.New("dmi icon is loading")
 
+// IconsCache stores loaded icons by their paths relative to the environment root dir.
+// Icons which failed to load are stored as nil, so they aren't loaded on every request.
+// Both are kept until the icon is reloaded or the cache is freed.
+//
+// By default, icons are loaded right on the request. In the async mode icons are decoded by background workers,
+// and requests return the ErrIconLoading until the icon texture is created in the main thread.
 type IconsCache struct {
+	mu          sync.Mutex
 	rootDirPath string
 	icons       map[string]*Dmi
+
+	// The generation is changed when the cache is freed, so icons decoded for the freed cache are dropped.
+	generation int
+
+	workers chan struct{} // Nil, if the cache isn't async.
+	onLoad  func(icons []string)
+	loading map[string]bool
+	decoded []decodedIcon
+}
+
+type decodedIcon struct {
+	icon       string
+	dmi        *Dmi // Nil, if the icon failed to load.
+	generation int
+}
+
+// SetAsync makes the cache load icons with the provided number of background workers.
+// The callback is called in the main thread with icons loaded since the previous call.
+func (i *IconsCache) SetAsync(workers int, onLoad func(icons []string)) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.workers = make(chan struct{}, workers)
+	i.onLoad = onLoad
+	log.Printf("cache async loading with [%d] workers", workers)
+}
+
+// Loading returns the number of icons which are loaded in the background at the moment.
+func (i *IconsCache) Loading() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return len(i.loading)
 }
 
 func (i *IconsCache) Free() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	for _, dmi := range i.icons {
-		dmi.free()
+		if dmi != nil {
+			dmi.free()
+		}
 	}
 	log.Printf("cache free; [%d] icons disposed", len(i.icons))
 	i.rootDirPath = ""
 	i.icons = make(map[string]*Dmi)
+	i.loading = make(map[string]bool)
+	i.generation++
 }
 
 func (i *IconsCache) SetRootDirPath(rootDirPath string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	i.rootDirPath = rootDirPath
 	log.Print("cache root dir:", rootDirPath)
 }
@@ -35,6 +95,9 @@ func (i *IconsCache) Get(icon string) (*Dmi, error) {
 		return nil, errors.New("dmi icon is empty")
 	}
 
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	if dmi, ok := i.icons[icon]; ok {
 		if dmi == nil {
 			return nil, fmt.Errorf("dmi [%s] is nil", icon)
@@ -42,9 +105,114 @@ func (i *IconsCache) Get(icon string) (*Dmi, error) {
 		return dmi, nil
 	}
 
-	dmi, err := New(i.rootDirPath + "/" + icon)
-	i.icons[icon] = dmi
-	return dmi, err
+	if i.workers == nil {
+		dmi, err := New(i.rootDirPath + "/" + icon)
+		i.icons[icon] = dmi
+		return dmi, err
+	}
+
+	if !i.loading[icon] {
+		i.loading[icon] = true
+		go i.load(icon, i.rootDirPath+"/"+icon, i.generation)
+	}
+	return nil, ErrIconLoading
+}
+
+func (i *IconsCache) load(icon, path string, generation int) {
+	i.workers <- struct{}{}
+	dmi, _ := decode(path)
+	<-i.workers
+
+	i.mu.Lock()
+	i.decoded = append(i.decoded, decodedIcon{icon: icon, dmi: dmi, generation: generation})
+	flush := len(i.decoded) == 1
+	i.mu.Unlock()
+
+	// Icons decoded until the flush are handled together, so users of icons are notified once per batch.
+	if flush {
+		window.RunLater(i.flushDecoded)
+	}
+}
+
+// Textures could be created only in the main thread, so decoded icons are stored in the cache there.
+func (i *IconsCache) flushDecoded() {
+	i.mu.Lock()
+
+	var loaded []string
+	for _, d := range i.decoded {
+		if d.generation != i.generation {
+			continue
+		}
+		delete(i.loading, d.icon)
+		// The icon could be reloaded while it was decoded.
+		if _, ok := i.icons[d.icon]; ok {
+			continue
+		}
+		if d.dmi != nil {
+			d.dmi.upload()
+		}
+		i.icons[d.icon] = d.dmi
+		loaded = append(loaded, d.icon)
+	}
+	iconsAtlas.commit()
+	i.decoded = nil
+	onLoad := i.onLoad
+
+	i.mu.Unlock()
+
+	if onLoad != nil && len(loaded) > 0 {
+		log.Printf("cache loaded; [%d] icons", len(loaded))
+		onLoad(loaded)
+	}
+}
+
+// Icons returns all icons requested from the cache, including those which failed to load.
+func (i *IconsCache) Icons() []string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	icons := make([]string, 0, len(i.icons))
+	for icon := range i.icons {
+		icons = append(icons, icon)
+	}
+	sort.Strings(icons)
+	return icons
+}
+
+// IconPaths returns paths of files for all icons requested from the cache.
+func (i *IconsCache) IconPaths() []string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	paths := make([]string, 0, len(i.icons))
+	for icon := range i.icons {
+		paths = append(paths, i.rootDirPath+"/"+icon)
+	}
+	return paths
+}
+
+// IconByPath returns the icon for the file path taken from the IconPaths method.
+func (i *IconsCache) IconByPath(path string) (string, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return strings.CutPrefix(path, i.rootDirPath+"/")
+}
+
+// Reload frees textures of the provided icons and loads them from the disk again.
+// Icons are kept in the cache even if they fail to load, so they could be reloaded when fixed.
+// Sprites of old icons must not be used after the call, since their textures are deleted in the end of the frame.
+func (i *IconsCache) Reload(icons []string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, icon := range icons {
+		if dmi, ok := i.icons[icon]; ok && dmi != nil {
+			dmi.free()
+		}
+		i.icons[icon], _ = New(i.rootDirPath + "/" + icon)
+	}
+	log.Printf("cache reloaded; [%d] icons", len(icons))
 }
 
 func (i *IconsCache) GetState(icon, state string) (*State, error) {
@@ -77,3 +245,11 @@ func (i *IconsCache) GetSpriteOrPlaceholderV(icon, state string, dir int) *Sprit
 	}
 	return SpritePlaceholder()
 }
+
+// GetAnimatedSpriteOrPlaceholderV returns a sprite of the current animation frame or a placeholder.
+func (i *IconsCache) GetAnimatedSpriteOrPlaceholderV(icon, state string, dir int) *Sprite {
+	if s, err := i.GetState(icon, state); err == nil {
+		return s.SpriteAnimatedV(dir)
+	}
+	return SpritePlaceholder()
+}
@@ -6,16 +6,20 @@ import (
 	"image/draw"
 	_ "image/png"
 	"os"
-	"sdmm/third_party/sdmmparser"
 
 	"sdmm/internal/app/window"
 	"sdmm/internal/dmapi/dm"
+	"sdmm/internal/dmapi/dmicon/dmimeta"
 	"sdmm/internal/platform"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/rs/zerolog/log"
 )
 
+// Headless disables creation of textures, so icons can be loaded without the application window.
+// Only the Image of the icon is available in the headless mode.
+var Headless bool
+
 type Dmi struct {
 	IconWidth     int
 	IconHeight    int
@@ -25,11 +29,21 @@ type Dmi struct {
 	Image         image.Image
 	Texture       uint32
 	States        map[string]*State
+
+	page     *atlasPage      // Nil, if the icon has its own texture.
+	pageArea image.Rectangle // Area of the icon on the atlas page.
 }
 
 func (d *Dmi) free() {
+	if d.Texture == 0 {
+		return
+	}
 	window.RunLater(func() {
-		gl.DeleteTextures(1, &d.Texture)
+		if d.page != nil {
+			iconsAtlas.remove(d.page, d.pageArea)
+		} else {
+			gl.DeleteTextures(1, &d.Texture)
+		}
 	})
 }
 
@@ -44,9 +58,20 @@ func (d *Dmi) State(state string) (*State, error) {
 }
 
 func New(path string) (*Dmi, error) {
+	dmi, err := decode(path)
+	if err != nil {
+		return nil, err
+	}
+	dmi.upload()
+	iconsAtlas.commit()
+	return dmi, nil
+}
+
+// Reads the icon without creating its texture, so it's safe to call outside the main thread.
+func decode(path string) (*Dmi, error) {
 	log.Printf("creating new: [%s]...", path)
 
-	iconMetadata, err := sdmmparser.ParseIconMetadata(path)
+	iconMetadata, err := dmimeta.ReadFile(path)
 	if err != nil {
 		log.Printf("unable to parse icon metadata [%s]: %s", path, err)
 		return nil, err
@@ -69,7 +94,6 @@ func New(path string) (*Dmi, error) {
 		Cols:          width / iconMetadata.Width,
 		Rows:          height / iconMetadata.Height,
 		Image:         rgba,
-		Texture:       platform.CreateTexture(rgba),
 		States:        make(map[string]*State),
 	}
 
@@ -79,6 +103,9 @@ func New(path string) (*Dmi, error) {
 		dmiState := &State{
 			Dirs:   state.Dirs,
 			Frames: state.Frames,
+			Delays: state.Delays,
+			Loop:   state.Loop,
+			Rewind: state.Rewind,
 		}
 
 		for i := 0; i < state.Dirs*state.Frames; i++ {
@@ -86,6 +113,10 @@ func New(path string) (*Dmi, error) {
 			spriteIdx += 1
 		}
 
+		// Movement states are shown only for moving atoms, so they don't replace regular states with the same name.
+		if _, ok := dmi.States[state.Name]; ok && state.Movement {
+			continue
+		}
 		dmi.States[state.Name] = dmiState
 	}
 
@@ -94,6 +125,41 @@ func New(path string) (*Dmi, error) {
 	return dmi, nil
 }
 
+// Places the decoded icon into the atlas, or creates its own texture, if the icon is too big.
+// Should be called in the main thread. The atlas should be committed after.
+func (d *Dmi) upload() {
+	rgba, ok := d.Image.(*image.NRGBA)
+	if !ok || Headless {
+		return
+	}
+
+	if page, x, y, ok := iconsAtlas.add(rgba); ok {
+		d.page = page
+		d.pageArea = image.Rect(x, y, x+rgba.Bounds().Dx(), y+rgba.Bounds().Dy())
+		d.Texture = page.texture
+		d.updateUV(x, y, iconsAtlas.pageSize, iconsAtlas.pageSize)
+	} else {
+		log.Printf("icon is too big for the atlas: [%dx%d]", d.TextureWidth, d.TextureHeight)
+		d.Texture = createTexture(rgba)
+	}
+}
+
+// Points UV coordinates of sprites to the icon placed into the texture at the provided position.
+func (d *Dmi) updateUV(x, y, textureWidth, textureHeight int) {
+	for _, state := range d.States {
+		for _, sprite := range state.Sprites {
+			sprite.setUV(x, y, textureWidth, textureHeight)
+		}
+	}
+}
+
+func createTexture(img *image.NRGBA) uint32 {
+	if Headless {
+		return 0
+	}
+	return platform.CreateTexture(img)
+}
+
 func loadRgbaImage(path string) (*image.NRGBA, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -120,6 +186,10 @@ func loadRgbaImage(path string) (*image.NRGBA, error) {
 type State struct {
 	Dirs, Frames int
 	Sprites      []*Sprite
+
+	Delays []float32 // Delays of frames in deciseconds.
+	Loop   int       // Number of animation loops, zero means an infinite animation.
+	Rewind bool      // Whether frames are played in the reverse order after the last one.
 }
 
 func (s State) Sprite() *Sprite {
@@ -200,18 +270,24 @@ func (s Sprite) IconHeight() int {
 }
 
 func newDmiSprite(dmi *Dmi, idx int) *Sprite {
-	const uvMargin = .000001
 	x := idx % dmi.Cols
 	y := idx / dmi.Cols
-	return &Sprite{
+	s := &Sprite{
 		dmi: dmi,
 		X1:  x * dmi.IconWidth,
 		Y1:  y * dmi.IconHeight,
 		X2:  (x + 1) * dmi.IconWidth,
 		Y2:  (y + 1) * dmi.IconHeight,
-		U1:  float32(x)/float32(dmi.Cols) + uvMargin,
-		V1:  float32(y)/float32(dmi.Rows) + uvMargin,
-		U2:  float32(x+1)/float32(dmi.Cols) - uvMargin,
-		V2:  float32(y+1)/float32(dmi.Rows) - uvMargin,
 	}
+	s.setUV(0, 0, dmi.TextureWidth, dmi.TextureHeight)
+	return s
+}
+
+// The offset is a position of the icon image in the texture.
+func (s *Sprite) setUV(offsetX, offsetY, textureWidth, textureHeight int) {
+	const uvMargin = .000001
+	s.U1 = float32(offsetX+s.X1)/float32(textureWidth) + uvMargin
+	s.V1 = float32(offsetY+s.Y1)/float32(textureHeight) + uvMargin
+	s.U2 = float32(offsetX+s.X2)/float32(textureWidth) - uvMargin
+	s.V2 = float32(offsetY+s.Y2)/float32(textureHeight) - uvMargin
 }
@@ -0,0 +1,334 @@
+// Package dmimeta reads metadata of DMI files.
+//
+// A DMI file is a PNG image with a text chunk under the "Description" keyword. The chunk describes
+// the size of icons in the image and states made of them:
+//
+//	# BEGIN DMI
+//	version = 4.0
+//		width = 32
+//		height = 32
+//	state = "name"
+//		dirs = 4
+//		frames = 2
+//		delay = 1,2
+//		loop = 1
+//		rewind = 1
+//		movement = 1
+//		hotspot = 16,16,1
+//	# END DMI
+//
+// Icons in the image go row by row. Every state takes dirs*frames icons, where directions change first.
+package dmimeta
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultIconSize = 32
+	descriptionKey  = "Description"
+
+	// Text chunks and the inflated description are read into memory, so their size is limited.
+	// Descriptions of the biggest sheets are hundreds of kilobytes, bigger ones are considered broken.
+	maxDescriptionSize = 16 * 1024 * 1024
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// ErrNoDescription is returned when the PNG image has no DMI description.
+var ErrNoDescription = errors.New("no dmi description")
+
+type Metadata struct {
+	Version       string
+	Width, Height int
+	States        []*State
+}
+
+type State struct {
+	Name         string
+	Dirs, Frames int
+	Delays       []float32 // Delays of frames in deciseconds. Empty, if the state has the only frame.
+	Loop         int       // Number of animation loops, zero means an infinite animation.
+	Rewind       bool      // Whether frames are played in the reverse order after the last one.
+	Movement     bool      // Movement states are shown while the atom moves, instead of states with the same name.
+	Hotspot      *Hotspot  // Could be nil, if the state has no hotspot.
+}
+
+// Hotspot is a point of the cursor icon, which is used as the click position.
+type Hotspot struct {
+	X, Y, Frame int
+}
+
+// ReadFile reads metadata of the DMI file. The image data is skipped.
+func ReadFile(path string) (*Metadata, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(bufio.NewReader(f))
+}
+
+// Read reads metadata from the stream with PNG image.
+// Chunks are read until the description is found, so the image data is skipped if the description goes before it.
+func Read(r io.Reader) (*Metadata, error) {
+	description, err := readDescription(r)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(description)
+}
+
+func readDescription(r io.Reader) (string, error) {
+	signature := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(r, signature); err != nil || !bytes.Equal(signature, pngSignature) {
+		return "", errors.New("not a png image")
+	}
+
+	var header [8]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return "", ErrNoDescription
+			}
+			return "", fmt.Errorf("unable to read chunk header: %w", err)
+		}
+
+		length := binary.BigEndian.Uint32(header[:4])
+		chunkType := string(header[4:])
+
+		switch chunkType {
+		case "zTXt", "tEXt", "iTXt":
+			if length > maxDescriptionSize {
+				return "", fmt.Errorf("%s chunk is too big: %d bytes", chunkType, length)
+			}
+			data := make([]byte, length)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return "", fmt.Errorf("unable to read %s chunk: %w", chunkType, err)
+			}
+			if keyword, text, err := readTextChunk(chunkType, data); err != nil {
+				return "", err
+			} else if keyword == descriptionKey {
+				return text, nil
+			}
+		case "IEND":
+			return "", ErrNoDescription
+		default:
+			if _, err := io.CopyN(io.Discard, r, int64(length)); err != nil {
+				return "", fmt.Errorf("unable to skip %s chunk: %w", chunkType, err)
+			}
+		}
+
+		// Skip the CRC, since the image is decoded properly later anyway.
+		if _, err := io.CopyN(io.Discard, r, 4); err != nil {
+			return "", fmt.Errorf("unable to read %s chunk crc: %w", chunkType, err)
+		}
+	}
+}
+
+// Returns the keyword and the text of the chunk. Text of chunks with different keywords is not decoded.
+func readTextChunk(chunkType string, data []byte) (keyword, text string, err error) {
+	keyword, data, ok := cutNull(data)
+	if !ok {
+		return "", "", fmt.Errorf("invalid %s chunk", chunkType)
+	}
+	if keyword != descriptionKey {
+		return keyword, "", nil
+	}
+
+	switch chunkType {
+	case "tEXt":
+		return keyword, string(data), nil
+	case "zTXt":
+		if len(data) == 0 {
+			return "", "", errors.New("invalid zTXt chunk")
+		}
+		text, err := inflate(data[1:]) // Skip the compression method, which is always zlib.
+		return keyword, text, err
+	default: // iTXt
+		if len(data) < 2 {
+			return "", "", errors.New("invalid iTXt chunk")
+		}
+		compressed := data[0] == 1
+		// Skip the language tag and the translated keyword.
+		if _, data, ok = cutNull(data[2:]); !ok {
+			return "", "", errors.New("invalid iTXt chunk")
+		}
+		if _, data, ok = cutNull(data); !ok {
+			return "", "", errors.New("invalid iTXt chunk")
+		}
+		if compressed {
+			text, err := inflate(data)
+			return keyword, text, err
+		}
+		return keyword, string(data), nil
+	}
+}
+
+func cutNull(data []byte) (string, []byte, bool) {
+	before, after, ok := bytes.Cut(data, []byte{0})
+	return string(before), after, ok
+}
+
+func inflate(data []byte) (string, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("unable to inflate description: %w", err)
+	}
+	defer zr.Close()
+
+	text, err := io.ReadAll(io.LimitReader(zr, maxDescriptionSize+1))
+	if err != nil {
+		return "", fmt.Errorf("unable to inflate description: %w", err)
+	}
+	if len(text) > maxDescriptionSize {
+		return "", fmt.Errorf("inflated description is too big: more than %d bytes", maxDescriptionSize)
+	}
+	return string(text), nil
+}
+
+// Parse parses the DMI description text.
+func Parse(description string) (*Metadata, error) {
+	metadata := &Metadata{Width: defaultIconSize, Height: defaultIconSize}
+
+	var state *State
+	began, ended := false, false
+
+	for idx, line := range strings.Split(strings.ReplaceAll(description, "\r\n", "\n"), "\n") {
+		lineNum := idx + 1
+		line = strings.TrimSpace(line)
+
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			switch line {
+			case "# BEGIN DMI":
+				began = true
+			case "# END DMI":
+				ended = true
+			}
+			continue
+		}
+		if !began || ended {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: no value: %s", lineNum, line)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		var err error
+		if key == "state" {
+			state = &State{Dirs: 1, Frames: 1}
+			state.Name, err = unquote(value)
+			metadata.States = append(metadata.States, state)
+		} else if state == nil {
+			err = parseHeaderValue(metadata, key, value)
+		} else {
+			err = parseStateValue(state, key, value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", lineNum, key, err)
+		}
+	}
+
+	if !began {
+		return nil, ErrNoDescription
+	}
+	if metadata.Width <= 0 || metadata.Height <= 0 {
+		return nil, fmt.Errorf("invalid icon size: %dx%d", metadata.Width, metadata.Height)
+	}
+
+	return metadata, nil
+}
+
+// Unknown keys are skipped, so newer versions of the format could be read.
+func parseHeaderValue(metadata *Metadata, key, value string) (err error) {
+	switch key {
+	case "version":
+		metadata.Version = value
+	case "width":
+		metadata.Width, err = strconv.Atoi(value)
+	case "height":
+		metadata.Height, err = strconv.Atoi(value)
+	}
+	return err
+}
+
+func parseStateValue(state *State, key, value string) (err error) {
+	switch key {
+	case "dirs":
+		if state.Dirs, err = strconv.Atoi(value); err == nil && state.Dirs != 1 && state.Dirs != 4 && state.Dirs != 8 {
+			err = fmt.Errorf("invalid number of dirs: %d", state.Dirs)
+		}
+	case "frames":
+		if state.Frames, err = strconv.Atoi(value); err == nil && state.Frames < 1 {
+			err = fmt.Errorf("invalid number of frames: %d", state.Frames)
+		}
+	case "delay":
+		state.Delays = nil
+		for _, delay := range strings.Split(value, ",") {
+			var d float64
+			if d, err = strconv.ParseFloat(strings.TrimSpace(delay), 32); err != nil {
+				return err
+			}
+			state.Delays = append(state.Delays, float32(d))
+		}
+	case "loop":
+		state.Loop, err = strconv.Atoi(value)
+	case "rewind":
+		state.Rewind, err = parseBool(value)
+	case "movement":
+		state.Movement, err = parseBool(value)
+	case "hotspot":
+		var coords []int
+		for _, coord := range strings.Split(value, ",") {
+			var c int
+			if c, err = strconv.Atoi(strings.TrimSpace(coord)); err != nil {
+				return err
+			}
+			coords = append(coords, c)
+		}
+		if len(coords) != 3 {
+			return fmt.Errorf("invalid hotspot: %s", value)
+		}
+		state.Hotspot = &Hotspot{X: coords[0], Y: coords[1], Frame: coords[2]}
+	}
+	return err
+}
+
+func parseBool(value string) (bool, error) {
+	v, err := strconv.Atoi(value)
+	return v != 0, err
+}
+
+// State names are quoted with escaped quotes and backslashes inside.
+func unquote(value string) (string, error) {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return "", fmt.Errorf("unquoted value: %s", value)
+	}
+
+	value = value[1 : len(value)-1]
+
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+		}
+		sb.WriteByte(value[i])
+	}
+	return sb.String(), nil
+}
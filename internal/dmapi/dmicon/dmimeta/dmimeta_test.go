@@ -0,0 +1,196 @@
+package dmimeta
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image/png"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stateNames(metadata *Metadata) []string {
+	var names []string
+	for _, state := range metadata.States {
+		names = append(names, state.Name)
+	}
+	return names
+}
+
+func TestReadFileAnimated(t *testing.T) {
+	metadata, err := ReadFile("testdata/animated.dmi")
+	require.NoError(t, err)
+
+	assert.Equal(t, "4.0", metadata.Version)
+	assert.Equal(t, 32, metadata.Width)
+	assert.Equal(t, 32, metadata.Height)
+	require.Equal(t, []string{"idle", "blink", "idle"}, stateNames(metadata))
+
+	assert.Equal(t, &State{Name: "idle", Dirs: 4, Frames: 1}, metadata.States[0])
+	assert.Equal(t, &State{
+		Name:   "blink",
+		Dirs:   1,
+		Frames: 4,
+		Delays: []float32{1, 2, .5, 3},
+		Loop:   2,
+		Rewind: true,
+	}, metadata.States[1])
+	assert.Equal(t, &State{
+		Name:     "idle",
+		Dirs:     4,
+		Frames:   2,
+		Delays:   []float32{1, 1},
+		Movement: true,
+	}, metadata.States[2])
+}
+
+func TestReadFileNonSquare(t *testing.T) {
+	metadata, err := ReadFile("testdata/tall.dmi")
+	require.NoError(t, err)
+
+	assert.Equal(t, 32, metadata.Width)
+	assert.Equal(t, 48, metadata.Height)
+	require.Len(t, metadata.States, 1)
+	assert.Equal(t, "", metadata.States[0].Name)
+	assert.Equal(t, &Hotspot{X: 16, Y: 24, Frame: 1}, metadata.States[0].Hotspot)
+}
+
+func TestReadFileEscapedNames(t *testing.T) {
+	metadata, err := ReadFile("testdata/escaped.dmi")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{`quote "inside"`, `back\slash`, "dup", "dup", "with = sign"}, stateNames(metadata))
+}
+
+func TestReadFileDescriptionAfterImage(t *testing.T) {
+	metadata, err := ReadFile("testdata/trailing.dmi")
+	require.NoError(t, err)
+
+	// No size in the description means the default one.
+	assert.Equal(t, 32, metadata.Width)
+	assert.Equal(t, 32, metadata.Height)
+	assert.Equal(t, []string{"one", "two"}, stateNames(metadata))
+}
+
+func TestReadFileInternationalText(t *testing.T) {
+	metadata, err := ReadFile("testdata/itxt.dmi")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"international"}, stateNames(metadata))
+}
+
+// Sheets are full-sized images with the description before the image data, like BYOND writes them.
+func TestReadFileSheets(t *testing.T) {
+	for _, tc := range []struct {
+		path          string
+		width, height int
+		states        int
+		check         func(t *testing.T, metadata *Metadata)
+	}{
+		{
+			path: "testdata/mob.dmi", width: 32, height: 32, states: 5,
+			check: func(t *testing.T, metadata *Metadata) {
+				assert.Equal(t, []string{"", "walk", "walk", "dead", "pointer"}, stateNames(metadata))
+				assert.False(t, metadata.States[1].Movement)
+				assert.True(t, metadata.States[2].Movement)
+				assert.Equal(t, []float32{.5, .5, .5, .5}, metadata.States[2].Delays)
+				assert.Equal(t, &Hotspot{X: 1, Y: 32, Frame: 1}, metadata.States[4].Hotspot)
+			},
+		},
+		{
+			path: "testdata/vehicle.dmi", width: 96, height: 64, states: 2,
+			check: func(t *testing.T, metadata *Metadata) {
+				assert.Equal(t, &State{Name: "engine", Dirs: 8, Frames: 1}, metadata.States[0])
+				assert.Equal(t, &State{
+					Name:   "engine_on",
+					Dirs:   8,
+					Frames: 3,
+					Delays: []float32{2, 1, 2},
+					Loop:   3,
+					Rewind: true,
+				}, metadata.States[1])
+			},
+		},
+		{
+			path: "testdata/floors.dmi", width: 32, height: 32, states: 401,
+			check: func(t *testing.T, metadata *Metadata) {
+				assert.Equal(t, "floor_000", metadata.States[0].Name)
+				assert.Equal(t, &State{Name: "floor_broken", Dirs: 4, Frames: 2, Delays: []float32{5, 5}}, metadata.States[400])
+			},
+		},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			metadata, err := ReadFile(tc.path)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.width, metadata.Width)
+			assert.Equal(t, tc.height, metadata.Height)
+			require.Len(t, metadata.States, tc.states)
+			tc.check(t, metadata)
+
+			// The image should have enough icons for all states.
+			f, err := os.Open(tc.path)
+			require.NoError(t, err)
+			defer f.Close()
+			cfg, err := png.DecodeConfig(f)
+			require.NoError(t, err)
+
+			var icons int
+			for _, state := range metadata.States {
+				icons += state.Dirs * state.Frames
+			}
+			assert.GreaterOrEqual(t, (cfg.Width/metadata.Width)*(cfg.Height/metadata.Height), icons)
+		})
+	}
+}
+
+// Writes the PNG signature and the chunk header with the provided length, but without the chunk data.
+func chunkHeader(chunkType string, length uint32) []byte {
+	data := append([]byte{}, pngSignature...)
+	data = binary.BigEndian.AppendUint32(data, length)
+	return append(data, chunkType...)
+}
+
+func TestReadTooBigChunk(t *testing.T) {
+	_, err := Read(bytes.NewReader(chunkHeader("zTXt", 0xFFFFFFFF)))
+	assert.ErrorContains(t, err, "too big")
+}
+
+func TestReadTooBigDescription(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, err := zw.Write([]byte(strings.Repeat("#", maxDescriptionSize+1)))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	chunkData := append([]byte(descriptionKey+"\x00\x00"), compressed.Bytes()...)
+	data := append(chunkHeader("zTXt", uint32(len(chunkData))), chunkData...)
+
+	_, err = Read(bytes.NewReader(data))
+	assert.ErrorContains(t, err, "too big")
+}
+
+func TestReadFileNoDescription(t *testing.T) {
+	_, err := ReadFile("testdata/plain.png")
+	assert.ErrorIs(t, err, ErrNoDescription)
+}
+
+func TestParseFailure(t *testing.T) {
+	for name, description := range map[string]string{
+		"no begin":       "version = 4.0\n",
+		"no value":       "# BEGIN DMI\nversion\n# END DMI\n",
+		"unquoted state": "# BEGIN DMI\nstate = idle\n# END DMI\n",
+		"invalid dirs":   "# BEGIN DMI\nstate = \"idle\"\n\tdirs = 3\n# END DMI\n",
+		"invalid frames": "# BEGIN DMI\nstate = \"idle\"\n\tframes = 0\n# END DMI\n",
+		"invalid delay":  "# BEGIN DMI\nstate = \"idle\"\n\tdelay = 1,a\n# END DMI\n",
+		"invalid size":   "# BEGIN DMI\n\twidth = 0\n# END DMI\n",
+		"short hotspot":  "# BEGIN DMI\nstate = \"idle\"\n\thotspot = 1,2\n# END DMI\n",
+	} {
+		_, err := Parse(description)
+		assert.Error(t, err, name)
+	}
+}
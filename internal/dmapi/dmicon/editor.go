@@ -1,7 +1,6 @@
 package dmicon
 
 import (
-	"sdmm/internal/platform"
 	"sdmm/internal/rsc"
 )
 
@@ -22,7 +21,7 @@ func initEditorSprites() {
 		Cols:          2,
 		Rows:          1,
 		Image:         img,
-		Texture:       platform.CreateTexture(img),
+		Texture:       createTexture(img),
 	}
 
 	spritePlaceholder = newDmiSprite(dmi, 0)
@@ -2,12 +2,14 @@ package dmmap
 
 import (
 	"path/filepath"
+	"sort"
 
 	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
 
 	"sdmm/internal/dmapi/dmenv"
 	"sdmm/internal/dmapi/dmmap/dmmdata"
 	"sdmm/internal/util"
+	"sdmm/internal/util/slice"
 
 	"github.com/rs/zerolog/log"
 )
@@ -105,10 +107,13 @@ func tileIndex(maxX, maxY, x, y, z int) int {
 }
 
 func New(dme *dmenv.Dme, data *dmmdata.DmmData, backup string) (dmm *Dmm, unknownPrefabs map[string]*dmmprefab.Prefab) {
-	return NewWithObsoleteConfig(dme, data, backup, DefaultObsoleteConfig())
+	dmm, unknownPrefabs, _ = NewWithObsoleteConfig(dme, data, backup, DefaultObsoleteConfig())
+	return dmm, unknownPrefabs
 }
 
-func NewWithObsoleteConfig(dme *dmenv.Dme, data *dmmdata.DmmData, backup string, obsConfig ObsoleteConfig) (dmm *Dmm, unknownPrefabs map[string]*dmmprefab.Prefab) {
+// NewWithObsoleteConfig creates the map with unknown prefabs replaced and migration rules applied.
+// Migrated paths are sorted original paths of prefabs changed by migration rules.
+func NewWithObsoleteConfig(dme *dmenv.Dme, data *dmmdata.DmmData, backup string, obsConfig ObsoleteConfig) (dmm *Dmm, unknownPrefabs map[string]*dmmprefab.Prefab, migratedPaths []string) {
 	unknownPrefabs = make(map[string]*dmmprefab.Prefab)
 	dmm = &Dmm{
 		Name:  filepath.Base(data.Filepath),
@@ -121,12 +126,25 @@ func NewWithObsoleteConfig(dme *dmenv.Dme, data *dmmdata.DmmData, backup string,
 		Backup: backup,
 	}
 
+	// Migrate the dictionary once, instead of doing that for every tile.
+	dictionary := make(map[dmmdata.Key]dmmdata.Prefabs, len(data.Dictionary))
+	for key, prefabs := range data.Dictionary {
+		for _, prefab := range prefabs {
+			migrated, migratedPath := migratePrefab(prefab, obsConfig)
+			dictionary[key] = append(dictionary[key], migrated...)
+			if migratedPath != "" && !slice.StrContains(migratedPaths, migratedPath) {
+				migratedPaths = append(migratedPaths, migratedPath)
+			}
+		}
+	}
+	sort.Strings(migratedPaths)
+
 	for z := 1; z <= data.MaxZ; z++ {
 		for y := 1; y <= data.MaxY; y++ {
 			for x := 1; x <= data.MaxX; x++ {
 				tile := Tile{Coord: util.Point{X: x, Y: y, Z: z}}
 
-				for _, prefab := range data.Dictionary[data.Grid[tile.Coord]] {
+				for _, prefab := range dictionary[data.Grid[tile.Coord]] {
 					if obj, ok := dme.Objects[prefab.Path()]; ok {
 						// Prefabs from the dmmdata don't know about environment objects.
 						if !prefab.Vars().HasParent() {
@@ -151,7 +169,7 @@ func NewWithObsoleteConfig(dme *dmenv.Dme, data *dmmdata.DmmData, backup string,
 		}
 	}
 
-	return dmm, unknownPrefabs
+	return dmm, unknownPrefabs, migratedPaths
 }
 
 // PersistPrefabs persists all prefabs from instances on the current map.
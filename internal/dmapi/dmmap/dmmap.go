@@ -1,14 +1,21 @@
 package dmmap
 
 import (
+	"sdmm/internal/dmapi/dm"
 	"sdmm/internal/dmapi/dmenv"
 	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
+	"sdmm/internal/dmapi/dmmap/dmmproj"
+	"sdmm/internal/dmapi/dmmsmooth"
 
 	"github.com/rs/zerolog/log"
 )
 
 var (
-	WorldIconSize int
+	// Size of a map tile in pixels, taken from the world.icon_size variable.
+	WorldIconWidth  int
+	WorldIconHeight int
+	// One of the dm.MapFormat* constants, taken from the world.map_format variable.
+	WorldMapFormat int
 
 	/*
 		Tiles should have at least one area and one turf.
@@ -23,7 +30,17 @@ var (
 func Init(dme *dmenv.Dme) {
 	environment = dme
 
-	WorldIconSize = dme.Objects["/world"].Vars.IntV("icon_size", 32)
+	iconSize := dme.Objects["/world"].Vars.ValueV("icon_size", "32")
+	var ok bool
+	if WorldIconWidth, WorldIconHeight, ok = dm.ParseIconSize(iconSize); !ok {
+		log.Print("invalid world icon size:", iconSize)
+		WorldIconWidth, WorldIconHeight = 32, 32
+	}
+
+	mapFormat := dme.Objects["/world"].Vars.ValueV("map_format", "0")
+	if WorldMapFormat, ok = dm.ParseMapFormat(mapFormat); !ok {
+		log.Print("unsupported world map format:", mapFormat)
+	}
 
 	baseAreaPath, _ := dme.Objects["/world"].Vars.Value("area")
 	baseTurfPath, _ := dme.Objects["/world"].Vars.Value("turf")
@@ -33,11 +50,21 @@ func Init(dme *dmenv.Dme) {
 	log.Print("initialized with:", dme.RootFile)
 	log.Print("base area:", baseAreaPath)
 	log.Print("base turf:", baseTurfPath)
+	log.Printf("world icon size: [%dx%d]", WorldIconWidth, WorldIconHeight)
+	log.Print("world map format:", WorldMapFormat)
 }
 
 func Free() {
 	environment = nil
-	WorldIconSize = 0
+	WorldIconWidth = 0
+	WorldIconHeight = 0
+	WorldMapFormat = 0
 	BaseArea = nil
 	BaseTurf = nil
+	Smoothing = dmmsmooth.Config{}
+}
+
+// WorldProjection returns the projection of map tiles for the current environment.
+func WorldProjection() dmmproj.Projection {
+	return dmmproj.New(WorldMapFormat, WorldIconWidth, WorldIconHeight)
 }
@@ -0,0 +1,140 @@
+package dmmdata
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrConflictMarkers is returned when the map has unresolved git conflict markers.
+// Such map could be opened with the NewConflicted function.
+var ErrConflictMarkers = errors.New("map contains git conflict markers")
+
+var (
+	markerOurs   = []byte("<<<<<<<")
+	markerBase   = []byte("|||||||")
+	markerSplit  = []byte("=======")
+	markerTheirs = []byte(">>>>>>>")
+)
+
+// Conflicted stores map versions restored from the file with git conflict markers.
+// Base is nil if conflicts were made without the "diff3" conflict style.
+type Conflicted struct {
+	Ours, Theirs, Base *DmmData
+}
+
+// NewConflicted parses the map with git conflict markers and rebuilds all versions of the map stored in it.
+func NewConflicted(path string) (*Conflicted, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ours, theirs, base, err := splitConflict(data)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicted := &Conflicted{}
+	if conflicted.Ours, err = parse(newBytesReader(path, ours)); err != nil {
+		return nil, fmt.Errorf("unable to parse our version: %w", err)
+	}
+	if conflicted.Theirs, err = parse(newBytesReader(path, theirs)); err != nil {
+		return nil, fmt.Errorf("unable to parse their version: %w", err)
+	}
+	if base != nil {
+		if conflicted.Base, err = parse(newBytesReader(path, base)); err != nil {
+			return nil, fmt.Errorf("unable to parse base version: %w", err)
+		}
+	}
+
+	return conflicted, nil
+}
+
+func hasConflictMarkers(data []byte) bool {
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if bytes.HasPrefix(line, markerOurs) {
+			return true
+		}
+	}
+	return false
+}
+
+// Splits the file content into versions. Lines outside of conflict hunks go to every version.
+// The base version is nil if there is no base hunks.
+func splitConflict(data []byte) (ours, theirs, base []byte, err error) {
+	const (
+		stateCommon = iota
+		stateOurs
+		stateBase
+		stateTheirs
+	)
+
+	var (
+		state   = stateCommon
+		hasBase bool
+		lineNo  int
+	)
+
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		lineNo++
+
+		switch {
+		case bytes.HasPrefix(line, markerOurs):
+			if state != stateCommon {
+				return nil, nil, nil, fmt.Errorf("at line %d: unexpected conflict start", lineNo)
+			}
+			state = stateOurs
+			continue
+		case bytes.HasPrefix(line, markerBase) && state == stateOurs:
+			state = stateBase
+			hasBase = true
+			continue
+		case bytes.HasPrefix(line, markerSplit) && (state == stateOurs || state == stateBase):
+			state = stateTheirs
+			continue
+		case bytes.HasPrefix(line, markerTheirs):
+			if state != stateTheirs {
+				return nil, nil, nil, fmt.Errorf("at line %d: unexpected conflict end", lineNo)
+			}
+			state = stateCommon
+			continue
+		}
+
+		switch state {
+		case stateCommon:
+			ours = append(ours, line...)
+			theirs = append(theirs, line...)
+			base = append(base, line...)
+		case stateOurs:
+			ours = append(ours, line...)
+		case stateBase:
+			base = append(base, line...)
+		case stateTheirs:
+			theirs = append(theirs, line...)
+		}
+	}
+
+	if state != stateCommon {
+		return nil, nil, nil, errors.New("unexpected end of file inside of a conflict")
+	}
+	if !hasBase {
+		base = nil
+	}
+
+	return ours, theirs, base, nil
+}
+
+type bytesReader struct {
+	*bytes.Reader
+	name string
+}
+
+func newBytesReader(name string, data []byte) *bytesReader {
+	return &bytesReader{bytes.NewReader(data), name}
+}
+
+func (r *bytesReader) Name() string {
+	return r.name
+}
@@ -0,0 +1,61 @@
+package dmmdata
+
+import (
+	"testing"
+
+	"sdmm/internal/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const conflictedMap = `"a" = (/obj/foo1)
+<<<<<<< HEAD
+"b" = (/obj/foo2)
+||||||| base
+"b" = (/obj/foo1)
+=======
+"b" = (/obj/foo3)
+>>>>>>> branch
+
+(1,1,1) = {"
+ab
+"}
+`
+
+func TestHasConflictMarkers(t *testing.T) {
+	assert.True(t, hasConflictMarkers([]byte(conflictedMap)))
+	assert.False(t, hasConflictMarkers([]byte(`"a" = (/obj/foo1)`+"\n")))
+}
+
+func TestSplitConflict(t *testing.T) {
+	ours, theirs, base, err := splitConflict([]byte(conflictedMap))
+	require.Nil(t, err)
+
+	for _, tc := range []struct {
+		data []byte
+		path string
+	}{
+		{ours, "/obj/foo2"},
+		{theirs, "/obj/foo3"},
+		{base, "/obj/foo1"},
+	} {
+		dmm, err := parse(newBytesReader("TestReader", tc.data))
+		require.Nil(t, err)
+		assert.Equal(t, 2, dmm.MaxX)
+		assert.Equal(t, tc.path, dmm.Dictionary[dmm.Grid[util.Point{X: 2, Y: 1, Z: 1}]][0].Path())
+	}
+}
+
+func TestSplitConflictWithoutBase(t *testing.T) {
+	_, _, base, err := splitConflict([]byte("<<<<<<< HEAD\na\n=======\nb\n>>>>>>> branch\n"))
+	require.Nil(t, err)
+	assert.Nil(t, base)
+}
+
+func TestSplitConflictBroken(t *testing.T) {
+	_, _, _, err := splitConflict([]byte("<<<<<<< HEAD\na\n=======\nb\n"))
+	assert.NotNil(t, err)
+	_, _, _, err = splitConflict([]byte("a\n>>>>>>> branch\n"))
+	assert.NotNil(t, err)
+}
@@ -27,12 +27,11 @@ type DmmData struct {
 	Grid       DataGrid
 }
 
-func (d DmmData) Save() {
+func (d DmmData) Save() error {
 	if d.IsTgm {
-		d.SaveTGM(d.Filepath)
-	} else {
-		d.SaveDM(d.Filepath)
+		return d.SaveTGM(d.Filepath)
 	}
+	return d.SaveDM(d.Filepath)
 }
 
 func (d DmmData) Keys() []Key {
@@ -58,11 +57,21 @@ func (d DmmData) String() string {
 		d.Filepath, d.IsTgm, winLineBreak, d.KeyLength, d.MaxX, d.MaxY, d.MaxZ)
 }
 
+// New parses the map by the provided path.
+// Returns ErrConflictMarkers if the map has unresolved git conflicts.
 func New(path string) (*DmmData, error) {
-	file, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
-	return parse(file)
+	return Parse(path, data)
+}
+
+// Parse parses the map content. The name is used as a path of the map.
+// Returns ErrConflictMarkers if the map has unresolved git conflicts.
+func Parse(name string, data []byte) (*DmmData, error) {
+	if hasConflictMarkers(data) {
+		return nil, ErrConflictMarkers
+	}
+	return parse(newBytesReader(name, data))
 }
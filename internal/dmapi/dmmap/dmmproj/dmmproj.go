@@ -0,0 +1,110 @@
+// Package dmmproj converts map coordinates into positions on the canvas and back, according to the world map format.
+//
+// Canvas positions are in pixels with the Y axis pointing up. Map coordinates start from 1.
+// Topdown and side maps are a grid of tiles. Isometric maps are a grid of diamonds, where the north is the upper right
+// direction and the east is the lower right one. A diamond is as wide as the icon and has a half of its width in height.
+package dmmproj
+
+import (
+	"math"
+
+	"sdmm/internal/dmapi/dm"
+	"sdmm/internal/util"
+)
+
+type Projection struct {
+	Format                int
+	IconWidth, IconHeight int
+}
+
+func New(format, iconWidth, iconHeight int) Projection {
+	return Projection{Format: format, IconWidth: iconWidth, IconHeight: iconHeight}
+}
+
+// TilePos returns the bottom left corner of the tile icon.
+func (p Projection) TilePos(x, y int) (posX, posY float32) {
+	i, j := float32(x-1), float32(y-1)
+	if p.Format == dm.MapFormatIsometric {
+		w := float32(p.IconWidth)
+		return (i + j) * w / 2, (j - i) * w / 4
+	}
+	return i * float32(p.IconWidth), j * float32(p.IconHeight)
+}
+
+// TileAt returns coordinates of the tile at the provided canvas position.
+func (p Projection) TileAt(posX, posY float32) (x, y int) {
+	if p.Format == dm.MapFormatIsometric {
+		// Inverse of the TilePos with the position moved to the diamond center.
+		w := float32(p.IconWidth)
+		a := (posX - w/2) / (w / 2) // i + j
+		b := (posY - w/4) / (w / 4) // j - i
+		return int(math.Round(float64((a-b)/2))) + 1, int(math.Round(float64((a+b)/2))) + 1
+	}
+	return int(math.Floor(float64(posX/float32(p.IconWidth)))) + 1, int(math.Floor(float64(posY/float32(p.IconHeight)))) + 1
+}
+
+// TileBounds returns bounds of the tile. For isometric maps it's bounds of the tile diamond.
+func (p Projection) TileBounds(x, y int) util.Bounds {
+	posX, posY := p.TilePos(x, y)
+	if p.Format == dm.MapFormatIsometric {
+		return util.Bounds{X1: posX, Y1: posY, X2: posX + float32(p.IconWidth), Y2: posY + float32(p.IconWidth)/2}
+	}
+	return util.Bounds{X1: posX, Y1: posY, X2: posX + float32(p.IconWidth), Y2: posY + float32(p.IconHeight)}
+}
+
+// AreaBounds returns bounds which contain icons of all tiles in the area.
+func (p Projection) AreaBounds(x1, y1, x2, y2 int) util.Bounds {
+	if p.Format == dm.MapFormatIsometric {
+		left, _ := p.TilePos(x1, y1)
+		right, _ := p.TilePos(x2, y2)
+		_, bottom := p.TilePos(x2, y1)
+		_, top := p.TilePos(x1, y2)
+		return util.Bounds{X1: left, Y1: bottom, X2: right + float32(p.IconWidth), Y2: top + float32(p.IconHeight)}
+	}
+	b1, b2 := p.TileBounds(x1, y1), p.TileBounds(x2, y2)
+	return util.Bounds{X1: b1.X1, Y1: b1.Y1, X2: b2.X2, Y2: b2.Y2}
+}
+
+// TileEdge returns the edge of the tile in the provided direction as a line from the X1/Y1 to the X2/Y2 point.
+// Only cardinal directions are supported.
+func (p Projection) TileEdge(x, y, dir int) util.Bounds {
+	b := p.TileBounds(x, y)
+	if p.Format == dm.MapFormatIsometric {
+		midX, midY := (b.X1+b.X2)/2, (b.Y1+b.Y2)/2
+		switch dir {
+		case dm.DirNorth:
+			return util.Bounds{X1: midX, Y1: b.Y2, X2: b.X2, Y2: midY}
+		case dm.DirEast:
+			return util.Bounds{X1: b.X2, Y1: midY, X2: midX, Y2: b.Y1}
+		case dm.DirSouth:
+			return util.Bounds{X1: midX, Y1: b.Y1, X2: b.X1, Y2: midY}
+		case dm.DirWest:
+			return util.Bounds{X1: b.X1, Y1: midY, X2: midX, Y2: b.Y2}
+		}
+		return util.Bounds{}
+	}
+
+	switch dir {
+	case dm.DirNorth:
+		return util.Bounds{X1: b.X1, Y1: b.Y2, X2: b.X2, Y2: b.Y2}
+	case dm.DirEast:
+		return util.Bounds{X1: b.X2, Y1: b.Y1, X2: b.X2, Y2: b.Y2}
+	case dm.DirSouth:
+		return util.Bounds{X1: b.X1, Y1: b.Y1, X2: b.X2, Y2: b.Y1}
+	case dm.DirWest:
+		return util.Bounds{X1: b.X1, Y1: b.Y1, X2: b.X1, Y2: b.Y2}
+	}
+	return util.Bounds{}
+}
+
+// Depth returns the drawing order of the tile: tiles with a bigger depth are closer to the viewer, so drawn later.
+// On topdown maps all tiles have the same depth.
+func (p Projection) Depth(x, y int) int {
+	switch p.Format {
+	case dm.MapFormatSide:
+		return -y
+	case dm.MapFormatIsometric:
+		return x - y
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package dmmproj
+
+import (
+	"testing"
+
+	"sdmm/internal/dmapi/dm"
+	"sdmm/internal/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopdown(t *testing.T) {
+	p := New(dm.MapFormatTopdown, 32, 48)
+
+	x, y := p.TilePos(2, 3)
+	assert.Equal(t, float32(32), x)
+	assert.Equal(t, float32(96), y)
+
+	tx, ty := p.TileAt(40, 100)
+	assert.Equal(t, 2, tx)
+	assert.Equal(t, 3, ty)
+
+	tx, ty = p.TileAt(-1, 10)
+	assert.Equal(t, 0, tx)
+	assert.Equal(t, 1, ty)
+
+	assert.Equal(t, util.Bounds{X1: 0, Y1: 0, X2: 96, Y2: 96}, p.AreaBounds(1, 1, 3, 2))
+	assert.Equal(t, 0, p.Depth(5, 5))
+}
+
+func TestSide(t *testing.T) {
+	p := New(dm.MapFormatSide, 32, 32)
+
+	x, y := p.TilePos(2, 3)
+	assert.Equal(t, float32(32), x)
+	assert.Equal(t, float32(64), y)
+	assert.Greater(t, p.Depth(1, 1), p.Depth(1, 2))
+	assert.Equal(t, p.Depth(1, 1), p.Depth(5, 1))
+}
+
+func TestIsometric(t *testing.T) {
+	p := New(dm.MapFormatIsometric, 64, 64)
+
+	// The east is the lower right direction, the north is the upper right one.
+	x, y := p.TilePos(2, 1)
+	assert.Equal(t, float32(32), x)
+	assert.Equal(t, float32(-16), y)
+	x, y = p.TilePos(1, 2)
+	assert.Equal(t, float32(32), x)
+	assert.Equal(t, float32(16), y)
+
+	for tileX := 1; tileX <= 5; tileX++ {
+		for tileY := 1; tileY <= 5; tileY++ {
+			b := p.TileBounds(tileX, tileY)
+			// The diamond center and points near its corners belong to the tile.
+			midX, midY := (b.X1+b.X2)/2, (b.Y1+b.Y2)/2
+			for _, pos := range [][2]float32{{midX, midY}, {b.X1 + 2, midY}, {b.X2 - 2, midY}, {midX, b.Y1 + 1}, {midX, b.Y2 - 1}} {
+				x, y := p.TileAt(pos[0], pos[1])
+				assert.Equal(t, tileX, x, "tile: %d,%d, pos: %v", tileX, tileY, pos)
+				assert.Equal(t, tileY, y, "tile: %d,%d, pos: %v", tileX, tileY, pos)
+			}
+		}
+	}
+
+	// Tiles closer to the bottom of the screen are drawn later.
+	assert.Greater(t, p.Depth(2, 1), p.Depth(1, 1))
+	assert.Greater(t, p.Depth(1, 1), p.Depth(1, 2))
+
+	b := p.AreaBounds(1, 1, 2, 2)
+	assert.Equal(t, util.Bounds{X1: 0, Y1: -16, X2: 128, Y2: 80}, b)
+}
+
+func TestTileEdge(t *testing.T) {
+	p := New(dm.MapFormatTopdown, 32, 32)
+	assert.Equal(t, util.Bounds{X1: 0, Y1: 32, X2: 32, Y2: 32}, p.TileEdge(1, 1, dm.DirNorth))
+	assert.Equal(t, util.Bounds{X1: 0, Y1: 0, X2: 0, Y2: 32}, p.TileEdge(1, 1, dm.DirWest))
+
+	p = New(dm.MapFormatIsometric, 32, 32)
+	assert.Equal(t, util.Bounds{X1: 16, Y1: 16, X2: 32, Y2: 8}, p.TileEdge(1, 1, dm.DirNorth))
+	assert.Equal(t, util.Bounds{X1: 16, Y1: 0, X2: 0, Y2: 8}, p.TileEdge(1, 1, dm.DirSouth))
+}
@@ -6,8 +6,11 @@ import (
 
 	"sdmm/internal/dmapi/dm"
 	"sdmm/internal/dmapi/dmenv"
+	"sdmm/internal/dmapi/dmmap/dmmdata"
 	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
+	"sdmm/internal/dmapi/dmmmigrate"
 	"sdmm/internal/dmapi/dmvars"
+	"sdmm/internal/util/slice"
 
 	"github.com/rs/zerolog/log"
 )
@@ -17,6 +20,10 @@ type ObsoleteConfig struct {
 	ObjectPath string // Path to use for unknown /obj and /mob types
 	TurfPath   string // Path to use for unknown /turf types
 	AreaPath   string // Path to use for unknown /area types
+
+	// Migrations are applied to map prefabs before they are checked against the environment.
+	// Obsolete placeholders saved in the map are migrated by their original path and variables.
+	Migrations dmmmigrate.Rules
 }
 
 // DefaultObsoleteConfig returns a default configuration (no replacement, just discard).
@@ -69,6 +76,84 @@ func CreateObsoletePrefab(dme *dmenv.Dme, originalPrefab *dmmprefab.Prefab, conf
 	return dmmprefab.New(dmmprefab.IdNone, obsoletePath, immutableVars)
 }
 
+// RestoreObsoletePrefab returns a prefab with the original path and variables stored in the obsolete placeholder.
+// Returns nil if the prefab is not a placeholder.
+func RestoreObsoletePrefab(prefab *dmmprefab.Prefab, config ObsoleteConfig) *dmmprefab.Prefab {
+	if !IsObsoletePrefab(prefab.Path(), config) || prefab.Vars() == nil {
+		return nil
+	}
+	if !slice.StrContains(prefab.Vars().Iterate(), "original_path") {
+		return nil
+	}
+
+	originalPathValue, _ := prefab.Vars().Value("original_path")
+	originalPath, err := strconv.Unquote(originalPathValue)
+	if err != nil {
+		log.Print("invalid original path of the obsolete prefab:", originalPathValue)
+		return nil
+	}
+
+	vars := &dmvars.MutableVariables{}
+	if slice.StrContains(prefab.Vars().Iterate(), "original_vars") {
+		originalVarsValue, _ := prefab.Vars().Value("original_vars")
+		// Keep variables in the original order, so the restored prefab will be the same as before.
+		forEachOriginalVar(originalVarsValue, vars.Put)
+	}
+
+	return dmmprefab.New(dmmprefab.IdNone, originalPath, vars.ToImmutable())
+}
+
+// MigrateData applies migration rules to prefabs of the map data, including saved obsolete placeholders.
+// Returns the number of changed prefabs.
+func MigrateData(data *dmmdata.DmmData, config ObsoleteConfig) int {
+	if len(config.Migrations) == 0 {
+		return 0
+	}
+
+	count := 0
+	for key, prefabs := range data.Dictionary {
+		var result dmmdata.Prefabs
+		keyChanged := false
+
+		for _, prefab := range prefabs {
+			migrated, migratedPath := migratePrefab(prefab, config)
+			if migratedPath != "" {
+				count++
+				keyChanged = true
+			}
+			result = append(result, migrated...)
+		}
+
+		if keyChanged {
+			data.Dictionary[key] = result
+		}
+	}
+
+	return count
+}
+
+// migratePrefab applies migration rules to the prefab.
+// Placeholders are replaced only when rules are applied to their original prefab, otherwise they stay as they are.
+// Returns the path of the migrated prefab, or the original path for placeholders. The path is empty, if nothing is changed.
+func migratePrefab(prefab *dmmprefab.Prefab, config ObsoleteConfig) (dmmdata.Prefabs, string) {
+	if len(config.Migrations) == 0 {
+		return dmmdata.Prefabs{prefab}, ""
+	}
+
+	if original := RestoreObsoletePrefab(prefab, config); original != nil {
+		if migrated, ok := config.Migrations.Apply(original); ok {
+			log.Print("obsolete prefab migrated:", original.Path())
+			return migrated, original.Path()
+		}
+		return dmmdata.Prefabs{prefab}, ""
+	}
+
+	if migrated, ok := config.Migrations.Apply(prefab); ok {
+		return migrated, prefab.Path()
+	}
+	return dmmdata.Prefabs{prefab}, ""
+}
+
 // serializeVars converts variables to a semicolon-separated string for storage.
 func serializeVars(vars *dmvars.Variables) string {
 	if vars == nil {
@@ -86,24 +171,67 @@ func serializeVars(vars *dmvars.Variables) string {
 // ParseOriginalVars parses the original_vars string back into key-value pairs.
 func ParseOriginalVars(originalVars string) map[string]string {
 	result := make(map[string]string)
+	forEachOriginalVar(originalVars, func(key, value string) {
+		result[key] = value
+	})
+	return result
+}
+
+// forEachOriginalVar calls the action for every pair of the original_vars string in the original order.
+func forEachOriginalVar(originalVars string, action func(key, value string)) {
 	if originalVars == "" {
-		return result
+		return
 	}
 
 	// Handle quoted string
-	if strings.HasPrefix(originalVars, "\"") && strings.HasSuffix(originalVars, "\"") {
+	if unquoted, err := strconv.Unquote(originalVars); err == nil {
+		originalVars = unquoted
+	} else if strings.HasPrefix(originalVars, "\"") && strings.HasSuffix(originalVars, "\"") {
 		originalVars = originalVars[1 : len(originalVars)-1]
 	}
 
-	pairs := strings.Split(originalVars, ";")
-	for _, pair := range pairs {
+	for _, pair := range splitOriginalVars(originalVars) {
 		if idx := strings.Index(pair, "="); idx > 0 {
-			key := pair[:idx]
-			value := pair[idx+1:]
-			result[key] = value
+			action(pair[:idx], pair[idx+1:])
 		}
 	}
-	return result
+}
+
+// splitOriginalVars splits the original_vars string by semicolons.
+// Semicolons inside of values, like in "a;b" strings or list(a;b) lists, don't split pairs.
+func splitOriginalVars(originalVars string) []string {
+	var (
+		pairs     []string
+		start     int
+		depth     int
+		quote     byte
+		isEscaped bool
+	)
+
+	for idx := 0; idx < len(originalVars); idx++ {
+		c := originalVars[idx]
+		switch {
+		case isEscaped:
+			isEscaped = false
+		case quote != 0:
+			if c == '\\' {
+				isEscaped = true
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth = max(depth-1, 0)
+		case c == ';' && depth == 0:
+			pairs = append(pairs, originalVars[start:idx])
+			start = idx + 1
+		}
+	}
+
+	return append(pairs, originalVars[start:])
 }
 
 // IsObsoletePrefab checks if a prefab is an obsolete placeholder.
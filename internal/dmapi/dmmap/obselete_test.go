@@ -0,0 +1,27 @@
+package dmmap
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOriginalVars(t *testing.T) {
+	originalVars := strconv.Quote(`name="pen; blue";desc="a \"good\" one";list=list("a"=1;"b"=2);dir=4`)
+
+	var keys []string
+	forEachOriginalVar(originalVars, func(key, _ string) {
+		keys = append(keys, key)
+	})
+	assert.Equal(t, []string{"name", "desc", "list", "dir"}, keys)
+
+	assert.Equal(t, map[string]string{
+		"name": `"pen; blue"`,
+		"desc": `"a \"good\" one"`,
+		"list": `list("a"=1;"b"=2)`,
+		"dir":  "4",
+	}, ParseOriginalVars(originalVars))
+
+	assert.Empty(t, ParseOriginalVars(""))
+}
@@ -0,0 +1,76 @@
+package dmmap
+
+import (
+	"sdmm/internal/dmapi/dmenv"
+	"sdmm/internal/dmapi/dmmap/dmmdata"
+	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
+	"sdmm/internal/util"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Reload switches the package to the reloaded environment without losing existing prefabs.
+// Prefabs are relinked with variables of new objects, so their initial values are taken from the new environment.
+// Prefabs of types absent in the new environment are kept as they are, maps should be fixed with ReplaceUnknown.
+func Reload(dme *dmenv.Dme) {
+	environment = dme
+
+	var relinked int
+	for _, prefab := range PrefabStorage.prefabs {
+		if relinkPrefab(dme, prefab) {
+			relinked++
+		}
+	}
+	log.Printf("prefabs relinked: [%d/%d]", relinked, len(PrefabStorage.prefabs))
+
+	// Base prefabs are taken from the storage, so they are already relinked.
+	Init(dme)
+}
+
+// ReplaceUnknown replaces instances of types absent in the environment with obsolete placeholders.
+// Instances are removed, if there is no placeholder configured. Returns coordinates of changed tiles.
+func (d *Dmm) ReplaceUnknown(dme *dmenv.Dme, config ObsoleteConfig) []util.Point {
+	var changedTiles []util.Point
+
+	for _, tile := range d.Tiles {
+		changed := false
+		prefabs := make(dmmdata.Prefabs, 0, len(tile.instances))
+
+		for _, instance := range tile.instances {
+			prefab := instance.Prefab()
+			if relinkPrefab(dme, prefab) {
+				prefabs = append(prefabs, prefab)
+				continue
+			}
+
+			changed = true
+			if obsoletePrefab := CreateObsoletePrefab(dme, prefab, config); obsoletePrefab != nil {
+				prefabs = append(prefabs, PrefabStorage.Put(obsoletePrefab))
+			}
+		}
+
+		if changed {
+			tile.InstancesSet(prefabs)
+			tile.InstancesRegenerate()
+			changedTiles = append(changedTiles, tile.Coord)
+		}
+	}
+
+	if len(changedTiles) > 0 {
+		log.Printf("unknown types replaced: [%s], tiles: [%d]", d.Name, len(changedTiles))
+	}
+
+	return changedTiles
+}
+
+// Returns false if the environment has no object with the prefab path.
+func relinkPrefab(dme *dmenv.Dme, prefab *dmmprefab.Prefab) bool {
+	obj, ok := dme.Objects[prefab.Path()]
+	if !ok {
+		return false
+	}
+	if prefab.Vars() != nil {
+		prefab.Vars().RelinkParent(obj.Vars)
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package dmmap
+
+import (
+	"sdmm/internal/dmapi/dm"
+	"sdmm/internal/dmapi/dmmap/dmminstance"
+	"sdmm/internal/dmapi/dmmsmooth"
+	"sdmm/internal/util"
+)
+
+var (
+	// SmoothingPreview enables the icon smoothing preview, if the environment has the Smoothing configured.
+	SmoothingPreview bool
+	// Smoothing configures the icon smoothing preview. Taken from the project preferences.
+	Smoothing dmmsmooth.Config
+)
+
+var dirShifts = map[int]util.Point{
+	dm.DirNorth:     {Y: 1},
+	dm.DirSouth:     {Y: -1},
+	dm.DirEast:      {X: 1},
+	dm.DirWest:      {X: -1},
+	dm.DirNortheast: {X: 1, Y: 1},
+	dm.DirSoutheast: {X: 1, Y: -1},
+	dm.DirSouthwest: {X: -1, Y: -1},
+	dm.DirNorthwest: {X: -1, Y: 1},
+}
+
+// SmoothingEnabled returns true, if icons are smoothed with their neighbors.
+func SmoothingEnabled() bool {
+	return SmoothingPreview && Smoothing.Enabled()
+}
+
+// SmoothedIconState returns the icon state of the instance chosen by its neighbors.
+// Returns false, if the smoothing preview is disabled or the instance isn't smoothed.
+func (d *Dmm) SmoothedIconState(i *dmminstance.Instance) (string, bool) {
+	if !SmoothingEnabled() {
+		return "", false
+	}
+
+	with := Smoothing.With(i.Prefab().Vars())
+	if len(with) == 0 {
+		return "", false
+	}
+
+	coord := i.Coord()
+	junction := Smoothing.Junction(func(dir int) bool {
+		neighbor := coord.Plus(dirShifts[dir])
+		if !d.HasTile(neighbor) {
+			return false
+		}
+		for _, n := range d.GetTile(neighbor).Instances() {
+			if dmmsmooth.SmoothsWith(with, Smoothing.Groups(n.Prefab().Path(), n.Prefab().Vars())) {
+				return true
+			}
+		}
+		return false
+	})
+
+	state, _ := i.Prefab().Vars().Text("icon_state")
+	return Smoothing.IconState(state, junction), true
+}
+
+// TilesWithNeighbors returns the provided tiles with all their neighbors.
+// Used to update smoothed icons, since they depend on neighbors.
+func (d *Dmm) TilesWithNeighbors(coords []util.Point) []util.Point {
+	seen := make(map[util.Point]bool, len(coords)*9)
+	result := make([]util.Point, 0, len(coords)*9)
+	for _, coord := range coords {
+		for x := coord.X - 1; x <= coord.X+1; x++ {
+			for y := coord.Y - 1; y <= coord.Y+1; y++ {
+				if neighbor := (util.Point{X: x, Y: y, Z: coord.Z}); !seen[neighbor] && d.HasTile(neighbor) {
+					seen[neighbor] = true
+					result = append(result, neighbor)
+				}
+			}
+		}
+	}
+	return result
+}
@@ -7,7 +7,10 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-var PrefabStorage = &prefabStorage{prefabs: make(map[uint64]*dmmprefab.Prefab)}
+var PrefabStorage = &prefabStorage{
+	prefabs:       make(map[uint64]*dmmprefab.Prefab),
+	prefabsByPath: make(map[string][]*dmmprefab.Prefab),
+}
 
 type prefabStorage struct {
 	prefabs       map[uint64]*dmmprefab.Prefab
@@ -0,0 +1,260 @@
+// Package dmmdiff compares maps tile by tile.
+// Tiles are compared by their content, so keys renaming doesn't produce any changes.
+package dmmdiff
+
+import (
+	"fmt"
+	"strings"
+
+	"sdmm/internal/dmapi/dmmap/dmmdata"
+	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
+	"sdmm/internal/dmapi/dmvars"
+	"sdmm/internal/util"
+)
+
+// Source provides the content of the map to compare.
+type Source interface {
+	Size() Size
+	// TileContent returns the content of the tile. Coordinates are always inside of the map bounds.
+	TileContent(coord util.Point) dmmdata.Prefabs
+}
+
+type Size struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	Z int `json:"z"`
+}
+
+func (s Size) String() string {
+	return fmt.Sprintf("%dx%dx%d", s.X, s.Y, s.Z)
+}
+
+func (s Size) has(coord util.Point) bool {
+	return coord.X <= s.X && coord.Y <= s.Y && coord.Z <= s.Z
+}
+
+type dataSource struct {
+	data *dmmdata.DmmData
+}
+
+// FromData creates a source from the raw map data.
+func FromData(data *dmmdata.DmmData) Source {
+	return dataSource{data}
+}
+
+func (s dataSource) Size() Size {
+	return Size{X: s.data.MaxX, Y: s.data.MaxY, Z: s.data.MaxZ}
+}
+
+func (s dataSource) TileContent(coord util.Point) dmmdata.Prefabs {
+	return s.data.Dictionary[s.data.Grid[coord]]
+}
+
+// VarChange describes a change of the variable value. Empty value means the variable wasn't set.
+type VarChange struct {
+	Name string `json:"name"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// PrefabEdit describes a prefab which stayed on the tile, but has edited variables.
+type PrefabEdit struct {
+	Path string      `json:"path"`
+	Vars []VarChange `json:"vars"`
+}
+
+// TileDiff describes changes of the single tile.
+// Added and removed prefabs are stored in the same notation as they're stored in the map file.
+type TileDiff struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	Z int `json:"z"`
+
+	Added   []string     `json:"added,omitempty"`
+	Removed []string     `json:"removed,omitempty"`
+	Edited  []PrefabEdit `json:"edited,omitempty"`
+}
+
+func (t TileDiff) Coord() util.Point {
+	return util.Point{X: t.X, Y: t.Y, Z: t.Z}
+}
+
+func (t TileDiff) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("(%d, %d, %d)", t.X, t.Y, t.Z))
+	for _, prefab := range t.Added {
+		sb.WriteString("\n  + " + prefab)
+	}
+	for _, prefab := range t.Removed {
+		sb.WriteString("\n  - " + prefab)
+	}
+	for _, edit := range t.Edited {
+		sb.WriteString("\n  ~ " + edit.Path)
+		for _, change := range edit.Vars {
+			sb.WriteString(fmt.Sprintf("\n      %s: %s -> %s", change.Name, varValueText(change.Old), varValueText(change.New)))
+		}
+	}
+	return sb.String()
+}
+
+func varValueText(value string) string {
+	if len(value) == 0 {
+		return "(unset)"
+	}
+	return value
+}
+
+// Result is a product of the maps comparison.
+type Result struct {
+	OldSize Size       `json:"old_size"`
+	NewSize Size       `json:"new_size"`
+	Tiles   []TileDiff `json:"tiles"`
+}
+
+// HasChanges returns true if the compared maps are different.
+func (r Result) HasChanges() bool {
+	return r.OldSize != r.NewSize || len(r.Tiles) != 0
+}
+
+// Diff compares two maps. Tiles outside the bounds of one of the maps are treated as empty.
+func Diff(oldMap, newMap Source) Result {
+	result := Result{
+		OldSize: oldMap.Size(),
+		NewSize: newMap.Size(),
+		Tiles:   make([]TileDiff, 0),
+	}
+
+	maxX := max(result.OldSize.X, result.NewSize.X)
+	maxY := max(result.OldSize.Y, result.NewSize.Y)
+	maxZ := max(result.OldSize.Z, result.NewSize.Z)
+
+	for z := 1; z <= maxZ; z++ {
+		for y := 1; y <= maxY; y++ {
+			for x := 1; x <= maxX; x++ {
+				coord := util.Point{X: x, Y: y, Z: z}
+
+				var oldTile, newTile dmmdata.Prefabs
+				if result.OldSize.has(coord) {
+					oldTile = oldMap.TileContent(coord)
+				}
+				if result.NewSize.has(coord) {
+					newTile = newMap.TileContent(coord)
+				}
+
+				if tileDiff, ok := diffTile(oldTile, newTile); ok {
+					tileDiff.X, tileDiff.Y, tileDiff.Z = x, y, z
+					result.Tiles = append(result.Tiles, tileDiff)
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+func diffTile(oldTile, newTile dmmdata.Prefabs) (TileDiff, bool) {
+	if oldTile.Equals(newTile) {
+		return TileDiff{}, false
+	}
+
+	// Prefabs are matched by their IDs. Only the difference between two tiles remains.
+	oldIds := make(map[uint64]int, len(oldTile))
+	for _, prefab := range oldTile {
+		oldIds[prefab.Id()]++
+	}
+
+	var added, removed dmmdata.Prefabs
+	for _, prefab := range newTile {
+		if oldIds[prefab.Id()] > 0 {
+			oldIds[prefab.Id()]--
+		} else {
+			added = append(added, prefab)
+		}
+	}
+	for _, prefab := range oldTile {
+		if oldIds[prefab.Id()] > 0 {
+			oldIds[prefab.Id()]--
+			removed = append(removed, prefab)
+		}
+	}
+
+	// A prefab was only reordered.
+	if len(added) == 0 && len(removed) == 0 {
+		return TileDiff{}, false
+	}
+
+	var tileDiff TileDiff
+
+	// Removed and added prefabs with the same path are treated as a var edit.
+	for _, removedPrefab := range removed {
+		editIdx := -1
+		for idx, addedPrefab := range added {
+			if addedPrefab.Path() == removedPrefab.Path() {
+				editIdx = idx
+				break
+			}
+		}
+
+		if editIdx == -1 {
+			tileDiff.Removed = append(tileDiff.Removed, prefabText(removedPrefab))
+			continue
+		}
+
+		tileDiff.Edited = append(tileDiff.Edited, PrefabEdit{
+			Path: removedPrefab.Path(),
+			Vars: diffVars(removedPrefab.Vars(), added[editIdx].Vars()),
+		})
+		added = append(added[:editIdx], added[editIdx+1:]...)
+	}
+	for _, addedPrefab := range added {
+		tileDiff.Added = append(tileDiff.Added, prefabText(addedPrefab))
+	}
+
+	return tileDiff, true
+}
+
+func diffVars(oldVars, newVars *dmvars.Variables) (changes []VarChange) {
+	for _, name := range varNames(oldVars) {
+		oldValue := ownValue(oldVars, name)
+		if newValue := ownValue(newVars, name); oldValue != newValue {
+			changes = append(changes, VarChange{Name: name, Old: oldValue, New: newValue})
+		}
+	}
+	for _, name := range varNames(newVars) {
+		if len(ownValue(oldVars, name)) == 0 {
+			changes = append(changes, VarChange{Name: name, New: ownValue(newVars, name)})
+		}
+	}
+	return changes
+}
+
+func varNames(vars *dmvars.Variables) []string {
+	if vars == nil {
+		return nil
+	}
+	return vars.Iterate()
+}
+
+// Returns the value set for the prefab itself, ignoring values of the parent.
+func ownValue(vars *dmvars.Variables, name string) string {
+	for _, varName := range varNames(vars) {
+		if varName == name {
+			value, _ := vars.Value(name)
+			return value
+		}
+	}
+	return ""
+}
+
+// Returns the prefab in the same notation as it's stored in the map file: /path{name = value; ...}
+func prefabText(prefab *dmmprefab.Prefab) string {
+	if prefab.Vars() == nil || prefab.Vars().Len() == 0 {
+		return prefab.Path()
+	}
+
+	vars := make([]string, 0, prefab.Vars().Len())
+	for _, name := range prefab.Vars().Iterate() {
+		vars = append(vars, name+" = "+ownValue(prefab.Vars(), name))
+	}
+	return prefab.Path() + "{" + strings.Join(vars, "; ") + "}"
+}
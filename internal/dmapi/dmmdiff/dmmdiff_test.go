@@ -0,0 +1,99 @@
+package dmmdiff
+
+import (
+	"testing"
+
+	"sdmm/internal/dmapi/dmmap/dmmdata"
+	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
+	"sdmm/internal/dmapi/dmvars"
+	"sdmm/internal/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testSource struct {
+	size  Size
+	tiles map[util.Point]dmmdata.Prefabs
+}
+
+func (s testSource) Size() Size {
+	return s.size
+}
+
+func (s testSource) TileContent(coord util.Point) dmmdata.Prefabs {
+	return s.tiles[coord]
+}
+
+// Creates a map with a single tile in the (1, 1, 1).
+func singleTile(prefabs ...*dmmprefab.Prefab) testSource {
+	return testSource{
+		size:  Size{X: 1, Y: 1, Z: 1},
+		tiles: map[util.Point]dmmdata.Prefabs{{X: 1, Y: 1, Z: 1}: prefabs},
+	}
+}
+
+func prefab(path string, vars ...string) *dmmprefab.Prefab {
+	mutableVars := &dmvars.MutableVariables{}
+	for idx := 0; idx < len(vars); idx += 2 {
+		mutableVars.Put(vars[idx], vars[idx+1])
+	}
+	return dmmprefab.New(dmmprefab.IdNone, path, mutableVars.ToImmutable())
+}
+
+func TestDiffNoChanges(t *testing.T) {
+	result := Diff(singleTile(prefab("/turf"), prefab("/area")), singleTile(prefab("/turf"), prefab("/area")))
+	assert.False(t, result.HasChanges())
+
+	// Reordering of the same content is not a change as well.
+	result = Diff(singleTile(prefab("/obj/a"), prefab("/obj/b")), singleTile(prefab("/obj/b"), prefab("/obj/a")))
+	assert.False(t, result.HasChanges())
+}
+
+func TestDiffAddedRemoved(t *testing.T) {
+	result := Diff(
+		singleTile(prefab("/turf"), prefab("/obj/a")),
+		singleTile(prefab("/turf"), prefab("/obj/b", "name", `"b"`), prefab("/obj/b", "name", `"b"`)),
+	)
+
+	assert.Equal(t, []TileDiff{{
+		X: 1, Y: 1, Z: 1,
+		Added:   []string{`/obj/b{name = "b"}`, `/obj/b{name = "b"}`},
+		Removed: []string{"/obj/a"},
+	}}, result.Tiles)
+}
+
+func TestDiffEdited(t *testing.T) {
+	result := Diff(
+		singleTile(prefab("/obj/a", "name", `"a"`, "dir", "2")),
+		singleTile(prefab("/obj/a", "name", `"b"`, "pixel_x", "4")),
+	)
+
+	assert.Equal(t, []TileDiff{{
+		X: 1, Y: 1, Z: 1,
+		Edited: []PrefabEdit{{
+			Path: "/obj/a",
+			Vars: []VarChange{
+				{Name: "name", Old: `"a"`, New: `"b"`},
+				{Name: "dir", Old: "2"},
+				{Name: "pixel_x", New: "4"},
+			},
+		}},
+	}}, result.Tiles)
+}
+
+func TestDiffSize(t *testing.T) {
+	newMap := testSource{
+		size: Size{X: 2, Y: 1, Z: 1},
+		tiles: map[util.Point]dmmdata.Prefabs{
+			{X: 1, Y: 1, Z: 1}: {prefab("/turf")},
+			{X: 2, Y: 1, Z: 1}: {prefab("/turf")},
+		},
+	}
+
+	result := Diff(singleTile(prefab("/turf")), newMap)
+
+	assert.True(t, result.HasChanges())
+	assert.Equal(t, Size{X: 1, Y: 1, Z: 1}, result.OldSize)
+	assert.Equal(t, Size{X: 2, Y: 1, Z: 1}, result.NewSize)
+	assert.Equal(t, []TileDiff{{X: 2, Y: 1, Z: 1, Added: []string{"/turf"}}}, result.Tiles)
+}
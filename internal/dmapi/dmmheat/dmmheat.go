@@ -0,0 +1,173 @@
+// Package dmmheat computes per-tile metrics of maps to show them as heatmaps.
+// Every metric is a Metric, which turns a single tile into a number. Some metrics use a query, like a type path.
+package dmmheat
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sdmm/internal/dmapi/dm"
+	"sdmm/internal/dmapi/dmmap/dmminstance"
+	"sdmm/internal/dmapi/dmvars"
+	"sdmm/internal/util"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Names of built-in metrics.
+const (
+	MNInstances = "Instances"
+	MNTypeCount = "Type Count"
+	MNVarValue  = "Var Value"
+)
+
+// Metric computes a value of a single tile.
+type Metric struct {
+	Name string
+	Desc string
+	// Query is a hint for the query of the metric. Empty, if the metric doesn't use a query.
+	Query string
+	// Validate returns an error, if the query can't be used. Optional.
+	Validate func(query string) error
+	// Value returns a function, which computes the value of a single tile. Called only with a valid query.
+	// The query is handled once, so the returned function is cheap enough to be called for every tile of the map.
+	Value func(query string) TileValue
+}
+
+// TileValue computes the value of the tile with the provided instances.
+type TileValue func(instances []*dmminstance.Instance) int
+
+// Check returns an error, if the metric can't be computed with the query.
+func (m Metric) Check(query string) error {
+	if m.Query != "" && strings.TrimSpace(query) == "" {
+		return errors.New("empty query")
+	}
+	if m.Validate != nil {
+		return m.Validate(query)
+	}
+	return nil
+}
+
+var metrics = map[string]Metric{
+	MNInstances: {
+		Name:  MNInstances,
+		Desc:  "Count of instances on the tile.",
+		Value: valueInstances,
+	},
+	MNTypeCount: {
+		Name:  MNTypeCount,
+		Desc:  "Count of instances of the type and its subtypes on the tile.",
+		Query: "/obj/item",
+		Value: valueTypeCount,
+	},
+	MNVarValue: {
+		Name:     MNVarValue,
+		Desc:     "Count of instances with the variable set to the value on the tile. TRUE and FALSE are the same as 1 and 0.",
+		Query:    "density = 1",
+		Validate: validateVarValue,
+		Value:    valueVarValue,
+	},
+}
+
+// Register adds a new metric or replaces an existing one with the same name.
+func Register(metric Metric) {
+	metrics[metric.Name] = metric
+	log.Print("metric registered:", metric.Name)
+}
+
+// Find returns the registered metric with the provided name.
+func Find(name string) (Metric, bool) {
+	metric, ok := metrics[name]
+	return metric, ok
+}
+
+// Metrics returns all registered metrics sorted by their names.
+func Metrics() []Metric {
+	result := make([]Metric, 0, len(metrics))
+	for _, metric := range metrics {
+		result = append(result, metric)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+func valueInstances(string) TileValue {
+	return func(instances []*dmminstance.Instance) int {
+		return len(instances)
+	}
+}
+
+func valueTypeCount(query string) TileValue {
+	path := strings.TrimSpace(query)
+	return func(instances []*dmminstance.Instance) int {
+		var count int
+		for _, instance := range instances {
+			if dm.IsPath(instance.Prefab().Path(), path) {
+				count++
+			}
+		}
+		return count
+	}
+}
+
+func validateVarValue(query string) error {
+	_, _, err := ParseVarQuery(query)
+	return err
+}
+
+func valueVarValue(query string) TileValue {
+	name, value, _ := ParseVarQuery(query)
+	return func(instances []*dmminstance.Instance) int {
+		var count int
+		for _, instance := range instances {
+			if normalizeValue(instance.Prefab().Vars().ValueV(name, dmvars.NullValue)) == value {
+				count++
+			}
+		}
+		return count
+	}
+}
+
+// ParseVarQuery splits the query in the "name = value" format into the variable name and its value.
+// The value is normalized, so it could be compared with normalized values of variables.
+func ParseVarQuery(query string) (name, value string, err error) {
+	name, value, ok := strings.Cut(query, "=")
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+	if !ok || name == "" || value == "" {
+		return "", "", fmt.Errorf("query [%s] is not in the \"name = value\" format", query)
+	}
+	return name, normalizeValue(value), nil
+}
+
+// DM has no booleans, so TRUE and FALSE are just 1 and 0. Numbers are compared by values, so 1 is the same as 1.0.
+// Other values, like strings or paths, are compared as they are.
+func normalizeValue(value string) string {
+	switch value {
+	case "TRUE":
+		return "1"
+	case "FALSE":
+		return "0"
+	}
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		return strconv.FormatFloat(number, 'g', -1, 64)
+	}
+	return value
+}
+
+// Color returns the heatmap color of the value. Values go from green to red, while the value grows to the maxValue.
+// Tiles without the value are transparent.
+func Color(value, maxValue int) util.Color {
+	if value <= 0 || maxValue <= 0 {
+		return util.MakeColor(0, 0, 0, 0)
+	}
+
+	t := min(float32(value)/float32(maxValue), 1)
+	// Green goes to yellow in the first half, yellow goes to red in the second one.
+	r, g := min(t*2, 1), min((1-t)*2, 1)
+	return util.MakeColor(r, g, 0, .2+.3*t)
+}
@@ -0,0 +1,68 @@
+package dmmheat
+
+import (
+	"testing"
+
+	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
+	"sdmm/internal/dmapi/dmmap/dmminstance"
+	"sdmm/internal/dmapi/dmvars"
+	"sdmm/internal/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	instances := []*dmminstance.Instance{
+		dmminstance.New(util.Point{}, dmmprefab.New(1, "/turf/open/floor", &dmvars.Variables{})),
+		dmminstance.New(util.Point{}, dmmprefab.New(2, "/obj/item/pen", dmvars.Set(&dmvars.Variables{}, "density", "1"))),
+		dmminstance.New(util.Point{}, dmmprefab.New(3, "/obj/item/paper", &dmvars.Variables{})),
+		dmminstance.New(util.Point{}, dmmprefab.New(4, "/obj/structure/table", dmvars.Set(&dmvars.Variables{}, "density", "1"))),
+		dmminstance.New(util.Point{}, dmmprefab.New(5, "/obj/structure/rack", dmvars.Set(&dmvars.Variables{}, "density", "TRUE"))),
+	}
+
+	value := func(name, query string) int {
+		metric, ok := Find(name)
+		assert.True(t, ok, name)
+		assert.NoError(t, metric.Check(query), name)
+		return metric.Value(query)(instances)
+	}
+
+	assert.Equal(t, 5, value(MNInstances, ""))
+	assert.Equal(t, 2, value(MNTypeCount, "/obj/item"))
+	assert.Equal(t, 2, value(MNTypeCount, "/obj/structure"))
+	assert.Equal(t, 3, value(MNVarValue, "density = 1"))
+	assert.Equal(t, 3, value(MNVarValue, "density = TRUE"))
+	assert.Equal(t, 3, value(MNVarValue, "density = 1.0"))
+	assert.Equal(t, 0, value(MNVarValue, "density=0"))
+}
+
+func TestMetric_Check(t *testing.T) {
+	typeCount, _ := Find(MNTypeCount)
+	assert.Error(t, typeCount.Check(" "))
+
+	varValue, _ := Find(MNVarValue)
+	assert.Error(t, varValue.Check("density"))
+	assert.Error(t, varValue.Check("= 1"))
+}
+
+func TestParseVarQuery(t *testing.T) {
+	name, value, err := ParseVarQuery(` name = "Table" `)
+	assert.NoError(t, err)
+	assert.Equal(t, "name", name)
+	assert.Equal(t, `"Table"`, value)
+
+	_, value, err = ParseVarQuery("density = FALSE")
+	assert.NoError(t, err)
+	assert.Equal(t, "0", value)
+}
+
+func TestColor(t *testing.T) {
+	assert.Zero(t, Color(0, 5).A())
+	assert.Zero(t, Color(1, 0).A())
+
+	low, high := Color(1, 10), Color(10, 10)
+	assert.Less(t, low.R(), high.R())
+	assert.Greater(t, low.G(), high.G())
+	assert.Less(t, low.A(), high.A())
+	assert.Equal(t, high, Color(20, 10), "should be clamped")
+}
@@ -0,0 +1,218 @@
+// Package dmmlight computes a light map of a map z-level from instances with light sources.
+//
+// Light of a source fades linearly with the distance and reaches zero at its range.
+// Opaque tiles block the light, but are lit themselves, so walls around a room are visible.
+// The light of different sources is summed up per color channel.
+package dmmlight
+
+import (
+	"math"
+
+	"sdmm/internal/util"
+)
+
+// MaxRange limits the range of a single light source, so a broken value won't make the whole map recomputed.
+const MaxRange = 32
+
+// Source provides the content of the z-level to light.
+type Source interface {
+	Size() (maxX, maxY int)
+	// Tile returns the content of the tile. Coordinates are always inside of the level bounds.
+	Tile(x, y int) Tile
+}
+
+type Tile struct {
+	Opaque bool
+	Lights []Light
+}
+
+type Light struct {
+	Range float32
+	Power float32
+	Color util.Color
+}
+
+// Map stores the light of every tile on the level.
+type Map struct {
+	source     Source
+	maxX, maxY int
+
+	tiles    []Tile
+	light    []lum
+	maxRange int // The biggest range of lights on the level.
+}
+
+type lum struct {
+	r, g, b float32
+}
+
+// New computes the light map of the whole level.
+func New(source Source) *Map {
+	m := &Map{source: source}
+	m.maxX, m.maxY = source.Size()
+	m.tiles = make([]Tile, m.maxX*m.maxY)
+	m.light = make([]lum, m.maxX*m.maxY)
+
+	for y := 1; y <= m.maxY; y++ {
+		for x := 1; x <= m.maxX; x++ {
+			m.tiles[m.idx(x, y)] = source.Tile(x, y)
+		}
+	}
+
+	m.updateMaxRange()
+	m.compute(1, 1, m.maxX, m.maxY)
+	return m
+}
+
+// Update reads the provided tiles from the source again and recomputes the light around them.
+// Tiles from other z-levels should be filtered by the caller.
+func (m *Map) Update(coords []util.Point) {
+	if len(coords) == 0 {
+		return
+	}
+
+	x1, y1, x2, y2 := m.maxX, m.maxY, 1, 1
+	for _, coord := range coords {
+		if !m.has(coord.X, coord.Y) {
+			continue
+		}
+		m.tiles[m.idx(coord.X, coord.Y)] = m.source.Tile(coord.X, coord.Y)
+		x1, y1 = min(x1, coord.X), min(y1, coord.Y)
+		x2, y2 = max(x2, coord.X), max(y2, coord.Y)
+	}
+	if x1 > x2 || y1 > y2 {
+		return
+	}
+
+	// Removed lights could be bigger than the remaining ones, so the range is taken before the update.
+	maxRange := m.maxRange
+	m.updateMaxRange()
+	maxRange = max(maxRange, m.maxRange)
+
+	// A changed tile affects lights in the range of it, and such lights reach tiles in the range of them.
+	m.compute(x1-2*maxRange, y1-2*maxRange, x2+2*maxRange, y2+2*maxRange)
+}
+
+// Light returns the summed up light of the tile per color channel.
+// Values could be bigger than one, when the tile is lit by several sources.
+func (m *Map) Light(x, y int) (r, g, b float32) {
+	if !m.has(x, y) {
+		return 0, 0, 0
+	}
+	l := m.light[m.idx(x, y)]
+	return l.r, l.g, l.b
+}
+
+func (m *Map) idx(x, y int) int {
+	return (y-1)*m.maxX + (x - 1)
+}
+
+func (m *Map) has(x, y int) bool {
+	return x >= 1 && y >= 1 && x <= m.maxX && y <= m.maxY
+}
+
+func (m *Map) updateMaxRange() {
+	m.maxRange = 0
+	for _, tile := range m.tiles {
+		for _, light := range tile.Lights {
+			m.maxRange = max(m.maxRange, lightRadius(light))
+		}
+	}
+}
+
+// Recomputes the light of tiles in the provided bounds.
+func (m *Map) compute(x1, y1, x2, y2 int) {
+	x1, y1 = max(x1, 1), max(y1, 1)
+	x2, y2 = min(x2, m.maxX), min(y2, m.maxY)
+
+	for y := y1; y <= y2; y++ {
+		for x := x1; x <= x2; x++ {
+			m.light[m.idx(x, y)] = lum{}
+		}
+	}
+
+	// Only lights which reach the bounds are taken.
+	for ly := max(y1-m.maxRange, 1); ly <= min(y2+m.maxRange, m.maxY); ly++ {
+		for lx := max(x1-m.maxRange, 1); lx <= min(x2+m.maxRange, m.maxX); lx++ {
+			for _, light := range m.tiles[m.idx(lx, ly)].Lights {
+				m.cast(lx, ly, light, x1, y1, x2, y2)
+			}
+		}
+	}
+}
+
+// Adds the light of the source placed on the lx/ly tile to tiles in the provided bounds.
+func (m *Map) cast(lx, ly int, light Light, x1, y1, x2, y2 int) {
+	radius := lightRadius(light)
+	if radius == 0 {
+		return
+	}
+	lightRange := min(light.Range, MaxRange)
+
+	for y := max(ly-radius, y1); y <= min(ly+radius, y2); y++ {
+		for x := max(lx-radius, x1); x <= min(lx+radius, x2); x++ {
+			dist := float32(math.Hypot(float64(x-lx), float64(y-ly)))
+			if dist >= lightRange || !m.visible(lx, ly, x, y) {
+				continue
+			}
+
+			power := light.Power * (1 - dist/lightRange)
+			l := &m.light[m.idx(x, y)]
+			l.r += power * light.Color.R()
+			l.g += power * light.Color.G()
+			l.b += power * light.Color.B()
+		}
+	}
+}
+
+// Checks that there are no opaque tiles on the line between two tiles. The tiles themselves aren't checked.
+// The line is traced with the Bresenham's algorithm.
+func (m *Map) visible(x1, y1, x2, y2 int) bool {
+	dx, dy := abs(x2-x1), -abs(y2-y1)
+	sx, sy := sign(x2-x1), sign(y2-y1)
+	e := dx + dy
+	x, y := x1, y1
+
+	for {
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y += sy
+		}
+		if x == x2 && y == y2 {
+			return true
+		}
+		if m.tiles[m.idx(x, y)].Opaque {
+			return false
+		}
+	}
+}
+
+// Returns the range of the light in whole tiles.
+func lightRadius(light Light) int {
+	if light.Range <= 0 || light.Power <= 0 {
+		return 0
+	}
+	return int(math.Ceil(float64(min(light.Range, MaxRange))))
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
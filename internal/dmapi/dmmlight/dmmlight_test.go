@@ -0,0 +1,90 @@
+package dmmlight
+
+import (
+	"testing"
+
+	"sdmm/internal/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testSource struct {
+	maxX, maxY int
+	tiles      map[util.Point]Tile
+}
+
+func newTestSource(maxX, maxY int) *testSource {
+	return &testSource{maxX: maxX, maxY: maxY, tiles: make(map[util.Point]Tile)}
+}
+
+func (s *testSource) Size() (int, int) {
+	return s.maxX, s.maxY
+}
+
+func (s *testSource) Tile(x, y int) Tile {
+	return s.tiles[util.Point{X: x, Y: y}]
+}
+
+var white = util.MakeColor(1, 1, 1, 1)
+
+func TestMap_Falloff(t *testing.T) {
+	source := newTestSource(9, 9)
+	source.tiles[util.Point{X: 5, Y: 5}] = Tile{Lights: []Light{{Range: 4, Power: 1, Color: white}}}
+
+	m := New(source)
+
+	r, _, _ := m.Light(5, 5)
+	assert.Equal(t, float32(1), r)
+	r, _, _ = m.Light(7, 5)
+	assert.InDelta(t, 0.5, r, 0.001)
+	r, _, _ = m.Light(9, 5)
+	assert.Equal(t, float32(0), r)
+	r, _, _ = m.Light(1, 1)
+	assert.Equal(t, float32(0), r)
+}
+
+func TestMap_Color(t *testing.T) {
+	source := newTestSource(3, 1)
+	source.tiles[util.Point{X: 1, Y: 1}] = Tile{Lights: []Light{{Range: 2, Power: 1, Color: util.MakeColor(1, 0, 0, 1)}}}
+	source.tiles[util.Point{X: 3, Y: 1}] = Tile{Lights: []Light{{Range: 2, Power: 1, Color: util.MakeColor(0, 0, 1, 1)}}}
+
+	r, g, b := New(source).Light(2, 1)
+	assert.InDelta(t, 0.5, r, 0.001)
+	assert.Equal(t, float32(0), g)
+	assert.InDelta(t, 0.5, b, 0.001)
+}
+
+func TestMap_Opacity(t *testing.T) {
+	source := newTestSource(5, 1)
+	source.tiles[util.Point{X: 1, Y: 1}] = Tile{Lights: []Light{{Range: 5, Power: 1, Color: white}}}
+	source.tiles[util.Point{X: 3, Y: 1}] = Tile{Opaque: true}
+
+	m := New(source)
+
+	r, _, _ := m.Light(3, 1)
+	assert.NotZero(t, r, "opaque tile should be lit")
+	r, _, _ = m.Light(4, 1)
+	assert.Zero(t, r, "light should be blocked")
+}
+
+func TestMap_Update(t *testing.T) {
+	source := newTestSource(20, 20)
+	source.tiles[util.Point{X: 5, Y: 5}] = Tile{Lights: []Light{{Range: 3, Power: 1, Color: white}}}
+
+	m := New(source)
+
+	// Move the light and block the old one.
+	delete(source.tiles, util.Point{X: 5, Y: 5})
+	source.tiles[util.Point{X: 15, Y: 15}] = Tile{Lights: []Light{{Range: 3, Power: 1, Color: white}}}
+	m.Update([]util.Point{{X: 5, Y: 5, Z: 1}, {X: 15, Y: 15, Z: 1}})
+
+	assert.Equal(t, New(source).light, m.light)
+
+	// Wall on the way of the light.
+	source.tiles[util.Point{X: 14, Y: 15}] = Tile{Opaque: true}
+	m.Update([]util.Point{{X: 14, Y: 15, Z: 1}})
+
+	assert.Equal(t, New(source).light, m.light)
+	r, _, _ := m.Light(13, 15)
+	assert.Zero(t, r)
+}
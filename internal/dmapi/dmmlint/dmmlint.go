@@ -0,0 +1,171 @@
+// Package dmmlint checks maps for common mapping mistakes.
+// Every check is a Rule, which inspects a single tile and reports found problems with their coordinates.
+package dmmlint
+
+import (
+	"fmt"
+	"sort"
+
+	"sdmm/internal/dmapi/dmenv"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/dmapi/dmmap/dmminstance"
+	"sdmm/internal/util"
+	"sdmm/internal/util/slice"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Problem describes a single rule violation on the map.
+type Problem struct {
+	Rule string `json:"rule"`
+
+	X int `json:"x"`
+	Y int `json:"y"`
+	Z int `json:"z"`
+
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+
+	instance *dmminstance.Instance
+}
+
+func (p Problem) Coord() util.Point {
+	return util.Point{X: p.X, Y: p.Y, Z: p.Z}
+}
+
+// Instance returns the instance which caused the problem.
+// It could be nil if the problem was found on the map data, not on the dmmap.Dmm.
+func (p Problem) Instance() *dmminstance.Instance {
+	return p.instance
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("(%d, %d, %d) [%s] %s", p.X, p.Y, p.Z, p.Rule, p.Message)
+}
+
+func newProblem(rule string, instance *dmminstance.Instance, format string, args ...any) Problem {
+	coord := instance.Coord()
+	return Problem{
+		Rule:     rule,
+		X:        coord.X,
+		Y:        coord.Y,
+		Z:        coord.Z,
+		Path:     instance.Prefab().Path(),
+		Message:  fmt.Sprintf(format, args...),
+		instance: instance,
+	}
+}
+
+// Context provides data to check tiles with.
+type Context struct {
+	Env      *dmenv.Dme
+	Obsolete dmmap.ObsoleteConfig
+}
+
+// Rule checks a single tile of the map.
+type Rule struct {
+	Name string
+	Desc string
+	// Check returns all problems of the tile.
+	Check func(ctx Context, tile *dmmap.Tile) []Problem
+}
+
+var rules = map[string]Rule{
+	RNMultipleTurfs: {
+		Name:  RNMultipleTurfs,
+		Desc:  "More than one turf on the tile.",
+		Check: checkMultipleTurfs,
+	},
+	RNMultipleAreas: {
+		Name:  RNMultipleAreas,
+		Desc:  "More than one area on the tile.",
+		Check: checkMultipleAreas,
+	},
+	RNStackedObjects: {
+		Name:  RNStackedObjects,
+		Desc:  "Identical objects or mobs stacked on the tile.",
+		Check: checkStackedObjects,
+	},
+	RNUnknownTypes: {
+		Name:  RNUnknownTypes,
+		Desc:  "Types absent in the environment and obsolete placeholders.",
+		Check: checkUnknownTypes,
+	},
+	RNDefaultVars: {
+		Name:  RNDefaultVars,
+		Desc:  "Variables edited to the same value as the type has.",
+		Check: checkDefaultVars,
+	},
+	RNReadOnlyVars: {
+		Name:  RNReadOnlyVars,
+		Desc:  "Edited tmp, const or static variables, which are ignored by the game.",
+		Check: checkReadOnlyVars,
+	},
+}
+
+// Register adds a new rule or replaces an existing one with the same name.
+func Register(rule Rule) {
+	rules[rule.Name] = rule
+	log.Print("rule registered:", rule.Name)
+}
+
+// Rules returns all registered rules sorted by their names.
+func Rules() []Rule {
+	result := make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		result = append(result, rule)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+type Config struct {
+	// Disabled contains names of rules which are not checked.
+	Disabled []string
+	// Obsolete is used to find placeholders of unknown types on the map.
+	Obsolete dmmap.ObsoleteConfig
+}
+
+func (c Config) IsEnabled(rule string) bool {
+	return !slice.StrContains(c.Disabled, rule)
+}
+
+// Lint checks every tile of the map with all enabled rules.
+// Problems are ordered by the tile position in the map.
+func Lint(env *dmenv.Dme, dmm *dmmap.Dmm, cfg Config) []Problem {
+	ctx := Context{Env: env, Obsolete: cfg.Obsolete}
+
+	var enabled []Rule
+	for _, rule := range Rules() {
+		if cfg.IsEnabled(rule.Name) {
+			enabled = append(enabled, rule)
+		}
+	}
+
+	problems := make([]Problem, 0)
+	for _, tile := range dmm.Tiles {
+		for _, rule := range enabled {
+			problems = append(problems, rule.Check(ctx, tile)...)
+		}
+	}
+
+	log.Printf("map [%s] checked with [%d] rules, problems: [%d]", dmm.Name, len(enabled), len(problems))
+
+	return problems
+}
+
+// Sort orders problems by their coordinates: z-level, then y, then x.
+func Sort(problems []Problem) {
+	sort.SliceStable(problems, func(i, j int) bool {
+		a, b := problems[i], problems[j]
+		if a.Z != b.Z {
+			return a.Z < b.Z
+		}
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.X < b.X
+	})
+}
@@ -0,0 +1,168 @@
+package dmmlint
+
+import (
+	"testing"
+
+	"sdmm/internal/dmapi/dmenv"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/dmapi/dmmtest"
+	"sdmm/internal/dmapi/dmvars"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEnv() *dmenv.Dme {
+	return dmmtest.NewEnv("/", map[string]*dmenv.Object{
+		"/area/space":         {},
+		"/turf/floor":         {},
+		"/turf/wall":          {},
+		"/obj/item/pen":       {Vars: dmvars.Set(&dmvars.Variables{}, "name", `"pen"`)},
+		"/obj/obsolete":       {},
+		"/mob/living/monkey":  {},
+		"/obj/machinery/door": {VarFlags: map[string]dmenv.VarFlags{"tag": {Tmp: true}, "max": {Const: true}}},
+	})
+}
+
+var testObsolete = dmmap.ObsoleteConfig{ObjectPath: "/obj/obsolete"}
+
+// Lints the map with the single rule enabled.
+func lintWith(env *dmenv.Dme, dmm *dmmap.Dmm, rule string) []string {
+	var disabled []string
+	for _, r := range Rules() {
+		if r.Name != rule {
+			disabled = append(disabled, r.Name)
+		}
+	}
+
+	problems := Lint(env, dmm, Config{Disabled: disabled, Obsolete: testObsolete})
+	Sort(problems)
+
+	result := make([]string, 0, len(problems))
+	for _, problem := range problems {
+		result = append(result, problem.String())
+	}
+	return result
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		rule     string
+		content  string
+		expected []string
+	}{
+		{
+			rule: RNMultipleTurfs,
+			content: `"a" = (/turf/floor,/area/space)
+"b" = (/turf/floor,/turf/wall,/area/space)
+(1,1,1) = {"
+ab
+"}`,
+			expected: []string{"(2, 1, 1) [multiple-turfs] 2 turfs on the tile: /turf/floor, /turf/wall"},
+		},
+		{
+			rule: RNMultipleAreas,
+			content: `"a" = (/turf/floor,/area/space,/area/space)
+"b" = (/turf/floor,/area/space)
+(1,1,1) = {"
+a
+b
+"}`,
+			expected: []string{"(1, 2, 1) [multiple-areas] 2 areas on the tile: /area/space, /area/space"},
+		},
+		{
+			rule: RNStackedObjects,
+			content: `"a" = (/obj/item/pen,/obj/item/pen,/turf/floor,/area/space)
+"b" = (/obj/item/pen,/obj/item/pen{name = "red pen"},/turf/floor,/area/space)
+"c" = (/mob/living/monkey,/mob/living/monkey,/mob/living/monkey,/turf/floor,/area/space)
+(1,1,1) = {"
+abc
+"}`,
+			expected: []string{
+				"(1, 1, 1) [stacked-objects] identical /obj/item/pen is stacked on the tile",
+				"(3, 1, 1) [stacked-objects] identical /mob/living/monkey is stacked on the tile",
+				"(3, 1, 1) [stacked-objects] identical /mob/living/monkey is stacked on the tile",
+			},
+		},
+		{
+			rule: RNUnknownTypes,
+			content: `"a" = (/obj/item/removed,/turf/floor,/area/space)
+(1,1,1) = {"
+a
+"}`,
+			expected: []string{"(1, 1, 1) [unknown-types] obsolete placeholder of /obj/item/removed"},
+		},
+		{
+			rule: RNDefaultVars,
+			content: `"a" = (/obj/item/pen{name = "pen"},/turf/floor,/area/space)
+"b" = (/obj/item/pen{name = "red pen"},/turf/floor,/area/space)
+(1,1,1) = {"
+ab
+"}`,
+			expected: []string{"(1, 1, 1) [default-vars] /obj/item/pen: name is equal to the type default"},
+		},
+		{
+			rule: RNReadOnlyVars,
+			content: `"a" = (/obj/machinery/door{tag = "door"; max = 2; name = "door"},/turf/floor,/area/space)
+(1,1,1) = {"
+a
+"}`,
+			expected: []string{
+				"(1, 1, 1) [readonly-vars] /obj/machinery/door: tag is a tmp variable and can't be edited on the map",
+				"(1, 1, 1) [readonly-vars] /obj/machinery/door: max is a const variable and can't be edited on the map",
+			},
+		},
+	}
+
+	env := newTestEnv()
+	for _, test := range tests {
+		t.Run(test.rule, func(t *testing.T) {
+			_, dmm := dmmtest.ParseMap(t, env, test.content, testObsolete)
+			assert.Equal(t, test.expected, lintWith(env, dmm, test.rule))
+		})
+	}
+}
+
+func TestLint_Clean(t *testing.T) {
+	env := newTestEnv()
+	_, dmm := dmmtest.ParseMap(t, env, `"a" = (/obj/item/pen{name = "red pen"},/turf/floor,/area/space)
+"b" = (/mob/living/monkey,/obj/machinery/door{name = "door"},/turf/wall,/area/space)
+(1,1,1) = {"
+ab
+ba
+"}`, testObsolete)
+
+	assert.Empty(t, Lint(env, dmm, Config{Obsolete: testObsolete}))
+}
+
+func TestLint_Disabled(t *testing.T) {
+	env := newTestEnv()
+	_, dmm := dmmtest.ParseMap(t, env, `"a" = (/turf/floor,/turf/wall,/area/space,/area/space)
+(1,1,1) = {"
+a
+"}`, testObsolete)
+
+	problems := Lint(env, dmm, Config{Disabled: []string{RNMultipleTurfs}})
+	require.Len(t, problems, 1)
+	assert.Equal(t, RNMultipleAreas, problems[0].Rule)
+}
+
+func TestUnknownTypes(t *testing.T) {
+	env := newTestEnv()
+	data, _ := dmmtest.ParseMap(t, env, `"a" = (/turf/floor,/area/space)
+"b" = (/obj/item/removed,/turf/lava,/area/space)
+(1,1,1) = {"
+ab
+"}`, testObsolete)
+
+	problems := UnknownTypes(env, data)
+	require.Len(t, problems, 2)
+	assert.Equal(t, "(2, 1, 1) [unknown-types] unknown type /obj/item/removed", problems[0].String())
+	assert.Equal(t, "(2, 1, 1) [unknown-types] unknown type /turf/lava", problems[1].String())
+}
+
+func TestSort(t *testing.T) {
+	problems := []Problem{{X: 2, Y: 1, Z: 2}, {X: 2, Y: 1, Z: 1}, {X: 1, Y: 2, Z: 1}, {X: 1, Y: 1, Z: 1}}
+	Sort(problems)
+	assert.Equal(t, []Problem{{X: 1, Y: 1, Z: 1}, {X: 2, Y: 1, Z: 1}, {X: 1, Y: 2, Z: 1}, {X: 2, Y: 1, Z: 2}}, problems)
+}
@@ -0,0 +1,159 @@
+package dmmlint
+
+import (
+	"strings"
+
+	"sdmm/internal/dmapi/dm"
+	"sdmm/internal/dmapi/dmenv"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/dmapi/dmmap/dmmdata"
+	"sdmm/internal/dmapi/dmmap/dmminstance"
+	"sdmm/internal/util"
+)
+
+// Names of built-in rules.
+const (
+	RNMultipleTurfs  = "multiple-turfs"
+	RNMultipleAreas  = "multiple-areas"
+	RNStackedObjects = "stacked-objects"
+	RNUnknownTypes   = "unknown-types"
+	RNDefaultVars    = "default-vars"
+	RNReadOnlyVars   = "readonly-vars"
+)
+
+func checkMultipleTurfs(_ Context, tile *dmmap.Tile) []Problem {
+	return checkMultipleOf(RNMultipleTurfs, "turfs", "/turf", tile)
+}
+
+func checkMultipleAreas(_ Context, tile *dmmap.Tile) []Problem {
+	return checkMultipleOf(RNMultipleAreas, "areas", "/area", tile)
+}
+
+// Reports every instance of the path after the first one.
+func checkMultipleOf(rule, name, path string, tile *dmmap.Tile) []Problem {
+	var found []*dmminstance.Instance
+	for _, instance := range tile.Instances() {
+		if dm.IsPath(instance.Prefab().Path(), path) {
+			found = append(found, instance)
+		}
+	}
+	if len(found) < 2 {
+		return nil
+	}
+
+	paths := make([]string, 0, len(found))
+	for _, instance := range found {
+		paths = append(paths, instance.Prefab().Path())
+	}
+
+	problems := make([]Problem, 0, len(found)-1)
+	for _, instance := range found[1:] {
+		problems = append(problems, newProblem(rule, instance, "%d %s on the tile: %s", len(found), name, strings.Join(paths, ", ")))
+	}
+	return problems
+}
+
+func checkStackedObjects(_ Context, tile *dmmap.Tile) []Problem {
+	var problems []Problem
+	seen := make(map[uint64]bool)
+	for _, instance := range tile.Instances() {
+		path := instance.Prefab().Path()
+		if !dm.IsPath(path, "/obj") && !dm.IsPath(path, "/mob") {
+			continue
+		}
+		if seen[instance.Prefab().Id()] {
+			problems = append(problems, newProblem(RNStackedObjects, instance, "identical %s is stacked on the tile", path))
+		}
+		seen[instance.Prefab().Id()] = true
+	}
+	return problems
+}
+
+func checkUnknownTypes(ctx Context, tile *dmmap.Tile) []Problem {
+	var problems []Problem
+	for _, instance := range tile.Instances() {
+		path := instance.Prefab().Path()
+		if _, ok := ctx.Env.Objects[path]; !ok {
+			problems = append(problems, newProblem(RNUnknownTypes, instance, "unknown type %s", path))
+		} else if dmmap.IsObsoletePrefab(path, ctx.Obsolete) {
+			originalPath, _ := instance.Prefab().Vars().Text("original_path")
+			problems = append(problems, newProblem(RNUnknownTypes, instance, "obsolete placeholder of %s", originalPath))
+		}
+	}
+	return problems
+}
+
+// UnknownTypes reports types absent in the environment over the raw map data.
+// Unknown types are discarded when the dmmap.Dmm is created, so they could be found only before that.
+func UnknownTypes(env *dmenv.Dme, data *dmmdata.DmmData) []Problem {
+	var problems []Problem
+	for z := 1; z <= data.MaxZ; z++ {
+		for y := 1; y <= data.MaxY; y++ {
+			for x := 1; x <= data.MaxX; x++ {
+				for _, prefab := range data.Dictionary[data.Grid[util.Point{X: x, Y: y, Z: z}]] {
+					if _, ok := env.Objects[prefab.Path()]; !ok {
+						problems = append(problems, Problem{
+							Rule:    RNUnknownTypes,
+							X:       x,
+							Y:       y,
+							Z:       z,
+							Path:    prefab.Path(),
+							Message: "unknown type " + prefab.Path(),
+						})
+					}
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// Does the same check as the variables sanitizing on the map save.
+func checkDefaultVars(ctx Context, tile *dmmap.Tile) []Problem {
+	var problems []Problem
+	for _, instance := range tile.Instances() {
+		prefab := instance.Prefab()
+		obj, ok := ctx.Env.Objects[prefab.Path()]
+		if !ok || prefab.Vars().Len() == 0 {
+			continue
+		}
+
+		for _, varName := range prefab.Vars().Iterate() {
+			origValue, _ := obj.Vars.Value(varName)
+			prefValue, _ := prefab.Vars().Value(varName)
+			if origValue == prefValue {
+				problems = append(problems, newProblem(RNDefaultVars, instance,
+					"%s: %s is equal to the type default", prefab.Path(), varName))
+			}
+		}
+	}
+	return problems
+}
+
+func checkReadOnlyVars(ctx Context, tile *dmmap.Tile) []Problem {
+	var problems []Problem
+	for _, instance := range tile.Instances() {
+		prefab := instance.Prefab()
+		obj, ok := ctx.Env.Objects[prefab.Path()]
+		if !ok || prefab.Vars().Len() == 0 {
+			continue
+		}
+
+		for _, varName := range prefab.Vars().Iterate() {
+			var kind string
+			switch flags := obj.Flags(varName); {
+			case flags.Tmp:
+				kind = "tmp"
+			case flags.Const:
+				kind = "const"
+			case flags.Static:
+				kind = "static"
+			default:
+				continue
+			}
+			problems = append(problems, newProblem(RNReadOnlyVars, instance,
+				"%s: %s is a %s variable and can't be edited on the map", prefab.Path(), varName, kind))
+		}
+	}
+	return problems
+}
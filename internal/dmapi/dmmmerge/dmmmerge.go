@@ -0,0 +1,99 @@
+// Package dmmmerge does a three-way merge of maps on the tile level.
+// Tiles are compared by IDs of their prefabs, so keys renaming doesn't produce any changes.
+package dmmmerge
+
+import (
+	"fmt"
+
+	"sdmm/internal/dmapi/dmmap/dmmdata"
+	"sdmm/internal/util"
+)
+
+// Result is a product of the merge.
+// Conflicting tiles are filled with the content from the "ours" side.
+type Result struct {
+	MaxX, MaxY, MaxZ int
+
+	Tiles     map[util.Point]dmmdata.Prefabs
+	Conflicts []util.Point
+}
+
+// HasConflicts returns true if there are tiles changed by both sides in a different way.
+func (r Result) HasConflicts() bool {
+	return len(r.Conflicts) != 0
+}
+
+// Merge merges changes from the "ours" and "theirs" maps made since the "base".
+// A tile changed only by one side takes the content of that side.
+// A tile changed by both sides in a different way becomes a conflict.
+// It's not possible to merge maps if both sides have changed the map size differently.
+//
+// The base could be nil. Then it's unknown which side has made changes, so every difference is a conflict.
+func Merge(base, ours, theirs *dmmdata.DmmData) (*Result, error) {
+	if base == nil {
+		base = &dmmdata.DmmData{}
+	}
+
+	maxX, err := mergeSize(base.MaxX, ours.MaxX, theirs.MaxX)
+	if err != nil {
+		return nil, fmt.Errorf("max x: %w", err)
+	}
+	maxY, err := mergeSize(base.MaxY, ours.MaxY, theirs.MaxY)
+	if err != nil {
+		return nil, fmt.Errorf("max y: %w", err)
+	}
+	maxZ, err := mergeSize(base.MaxZ, ours.MaxZ, theirs.MaxZ)
+	if err != nil {
+		return nil, fmt.Errorf("max z: %w", err)
+	}
+
+	result := &Result{
+		MaxX:  maxX,
+		MaxY:  maxY,
+		MaxZ:  maxZ,
+		Tiles: make(map[util.Point]dmmdata.Prefabs, maxX*maxY*maxZ),
+	}
+
+	for z := 1; z <= maxZ; z++ {
+		for y := 1; y <= maxY; y++ {
+			for x := 1; x <= maxX; x++ {
+				coord := util.Point{X: x, Y: y, Z: z}
+
+				baseTile := tileContent(base, coord)
+				oursTile := tileContent(ours, coord)
+				theirsTile := tileContent(theirs, coord)
+
+				switch {
+				case oursTile.Equals(theirsTile), theirsTile.Equals(baseTile):
+					result.Tiles[coord] = oursTile
+				case oursTile.Equals(baseTile):
+					result.Tiles[coord] = theirsTile
+				default:
+					result.Tiles[coord] = oursTile
+					result.Conflicts = append(result.Conflicts, coord)
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func mergeSize(base, ours, theirs int) (int, error) {
+	if ours == theirs || theirs == base {
+		return ours, nil
+	}
+	if ours == base {
+		return theirs, nil
+	}
+	return 0, fmt.Errorf("size changed by both sides: base [%d], ours [%d], theirs [%d]", base, ours, theirs)
+}
+
+// Returns the content of the tile in the order it was saved. Nil if the tile is out of map bounds.
+func tileContent(data *dmmdata.DmmData, coord util.Point) dmmdata.Prefabs {
+	key, ok := data.Grid[coord]
+	if !ok {
+		return nil
+	}
+	return data.Dictionary[key]
+}
@@ -0,0 +1,107 @@
+package dmmmerge
+
+import (
+	"testing"
+
+	"sdmm/internal/dmapi/dmmap/dmmdata"
+	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
+	"sdmm/internal/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Creates a map with one z-level from rows of keys. Every key is a tile with a single prefab of the "/obj/<key>" path.
+// The first row is the top of the map, like in the map file.
+func makeData(rows ...string) *dmmdata.DmmData {
+	data := &dmmdata.DmmData{
+		KeyLength:  1,
+		MaxX:       len(rows[0]),
+		MaxY:       len(rows),
+		MaxZ:       1,
+		Dictionary: make(dmmdata.DataDictionary),
+		Grid:       make(dmmdata.DataGrid),
+	}
+	for rowIdx, row := range rows {
+		for x, c := range row {
+			key := dmmdata.Key(c)
+			data.Dictionary[key] = dmmdata.Prefabs{dmmprefab.New(dmmprefab.IdNone, "/obj/"+string(c), nil)}
+			data.Grid[util.Point{X: x + 1, Y: len(rows) - rowIdx, Z: 1}] = key
+		}
+	}
+	return data
+}
+
+func tilePath(r *Result, x, y int) string {
+	return r.Tiles[util.Point{X: x, Y: y, Z: 1}][0].Path()
+}
+
+func TestMergeOneSideChanges(t *testing.T) {
+	base := makeData("aa", "aa")
+	ours := makeData("ba", "aa")
+	theirs := makeData("aa", "ac")
+
+	result, err := Merge(base, ours, theirs)
+	require.NoError(t, err)
+
+	assert.False(t, result.HasConflicts())
+	assert.Equal(t, "/obj/b", tilePath(result, 1, 2))
+	assert.Equal(t, "/obj/a", tilePath(result, 2, 2))
+	assert.Equal(t, "/obj/a", tilePath(result, 1, 1))
+	assert.Equal(t, "/obj/c", tilePath(result, 2, 1))
+}
+
+func TestMergeSameChanges(t *testing.T) {
+	result, err := Merge(makeData("aa"), makeData("ab"), makeData("ab"))
+	require.NoError(t, err)
+
+	assert.False(t, result.HasConflicts())
+	assert.Equal(t, "/obj/b", tilePath(result, 2, 1))
+}
+
+func TestMergeConflicts(t *testing.T) {
+	result, err := Merge(makeData("aa"), makeData("ab"), makeData("ac"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []util.Point{{X: 2, Y: 1, Z: 1}}, result.Conflicts)
+	assert.Equal(t, "/obj/b", tilePath(result, 2, 1), "conflicting tiles should keep our content")
+}
+
+func TestMergeIgnoresKeys(t *testing.T) {
+	base := makeData("aa")
+	ours := makeData("aa")
+	theirs := makeData("ab")
+
+	// Rename the key without changing the content.
+	ours.Dictionary["z"] = ours.Dictionary["a"]
+	delete(ours.Dictionary, "a")
+	ours.Grid[util.Point{X: 1, Y: 1, Z: 1}] = "z"
+	ours.Grid[util.Point{X: 2, Y: 1, Z: 1}] = "z"
+
+	result, err := Merge(base, ours, theirs)
+	require.NoError(t, err)
+
+	assert.False(t, result.HasConflicts())
+	assert.Equal(t, "/obj/b", tilePath(result, 2, 1))
+}
+
+func TestMergeSize(t *testing.T) {
+	result, err := Merge(makeData("aa"), makeData("aa"), makeData("aab"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.MaxX)
+	assert.Equal(t, "/obj/b", tilePath(result, 3, 1))
+	assert.Len(t, result.Tiles, 3)
+
+	_, err = Merge(makeData("aa"), makeData("a"), makeData("aab"))
+	assert.Error(t, err)
+}
+
+func TestMergeWithoutBase(t *testing.T) {
+	result, err := Merge(nil, makeData("ab"), makeData("ac"))
+	require.NoError(t, err)
+	assert.Equal(t, []util.Point{{X: 2, Y: 1, Z: 1}}, result.Conflicts)
+	assert.Equal(t, "/obj/a", tilePath(result, 1, 1))
+
+	_, err = Merge(nil, makeData("ab"), makeData("abc"))
+	assert.Error(t, err)
+}
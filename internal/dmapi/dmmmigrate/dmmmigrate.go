@@ -0,0 +1,167 @@
+// Package dmmmigrate updates type paths and variables of prefabs on maps with the UpdatePaths-like rules.
+//
+// Every rule has a match and a replacement separated by ":":
+//
+//	/obj/old{dir = 4} : /obj/new{@OLD; dir = @SKIP}
+//	/obj/old/@SUBTYPES : /obj/new/@SUBTYPES{@OLD}
+//	/obj/removed : @DELETE
+//	/obj/split : /obj/first{name = @OLD}, /obj/second{desc = @OLD:name}
+//
+// The match side can have variable conditions. The "@UNSET" value means that the variable must not be set.
+// The replacement side is a list of new prefabs or "@DELETE" to remove the matched prefab at all.
+// Variables of new prefabs can be literals or keywords:
+//   - "@OLD" alone copies all old variables;
+//   - "name = @OLD" copies the old variable with the same name;
+//   - "name = @OLD:old_name" copies the old variable with a different name, which is how renames are done;
+//   - "name = @SKIP" drops the variable, which is useful together with "@OLD".
+package dmmmigrate
+
+import (
+	"strings"
+
+	"sdmm/internal/dmapi/dm"
+	"sdmm/internal/dmapi/dmmap/dmmdata"
+	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
+	"sdmm/internal/dmapi/dmvars"
+)
+
+type Rules []Rule
+
+// Rule describes which prefabs should be replaced and with what.
+// A rule without targets deletes matched prefabs.
+type Rule struct {
+	Source string // File and line of the rule. Used for logging.
+
+	Path       string
+	Subtypes   bool
+	Conditions []Condition
+
+	Targets []Target
+}
+
+type Condition struct {
+	Name  string
+	Value string
+	Unset bool
+}
+
+type Target struct {
+	Path     string
+	Subtypes bool
+	KeepOld  bool
+	Vars     []VarEdit
+}
+
+type VarOp int
+
+const (
+	OpSet  VarOp = iota // Set the variable to the literal value.
+	OpOld               // Copy the old variable with the name stored in the value.
+	OpSkip              // Remove the variable.
+)
+
+type VarEdit struct {
+	Name  string
+	Op    VarOp
+	Value string
+}
+
+// Apply applies rules to the prefab in the order they are declared.
+// Prefabs created by one rule are matched by the next rules as well.
+// Returns the list of resulting prefabs and whether the prefab was changed by any rule.
+func (r Rules) Apply(prefab *dmmprefab.Prefab) (dmmdata.Prefabs, bool) {
+	prefabs := dmmdata.Prefabs{prefab}
+	changed := false
+
+	for _, rule := range r {
+		var result dmmdata.Prefabs
+		for _, p := range prefabs {
+			if rule.matches(p) {
+				result = append(result, rule.apply(p)...)
+				changed = true
+			} else {
+				result = append(result, p)
+			}
+		}
+		prefabs = result
+	}
+
+	return prefabs, changed
+}
+
+func (r Rule) matches(prefab *dmmprefab.Prefab) bool {
+	if r.Subtypes {
+		if prefab.Path() != r.Path && !dm.IsPath(prefab.Path(), r.Path+"/") {
+			return false
+		}
+	} else if prefab.Path() != r.Path {
+		return false
+	}
+
+	for _, condition := range r.Conditions {
+		value, ok := ownValue(prefab.Vars(), condition.Name)
+		if condition.Unset {
+			if ok {
+				return false
+			}
+		} else if !ok || value != condition.Value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r Rule) apply(prefab *dmmprefab.Prefab) dmmdata.Prefabs {
+	prefabs := make(dmmdata.Prefabs, 0, len(r.Targets))
+	for _, target := range r.Targets {
+		prefabs = append(prefabs, target.create(prefab, strings.TrimPrefix(prefab.Path(), r.Path)))
+	}
+	return prefabs
+}
+
+func (t Target) create(prefab *dmmprefab.Prefab, subtype string) *dmmprefab.Prefab {
+	path := t.Path
+	if t.Subtypes {
+		path += subtype
+	}
+
+	old := prefab.Vars()
+	vars := &dmvars.MutableVariables{}
+
+	if t.KeepOld && old != nil {
+		for _, name := range old.Iterate() {
+			if value, ok := old.Value(name); ok {
+				vars.Put(name, value)
+			}
+		}
+	}
+
+	for _, edit := range t.Vars {
+		switch edit.Op {
+		case OpSet:
+			vars.Put(edit.Name, edit.Value)
+		case OpOld:
+			if value, ok := ownValue(old, edit.Value); ok {
+				vars.Put(edit.Name, value)
+			}
+		case OpSkip:
+			vars.Variables = *dmvars.Delete(&vars.Variables, edit.Name)
+		}
+	}
+
+	return dmmprefab.New(dmmprefab.IdNone, path, vars.ToImmutable())
+}
+
+// Conditions and copies work only with variables set on the map, values from the environment are ignored.
+func ownValue(vars *dmvars.Variables, name string) (string, bool) {
+	if vars == nil {
+		return "", false
+	}
+	for _, n := range vars.Iterate() {
+		if n == name {
+			return vars.Value(name)
+		}
+	}
+	return "", false
+}
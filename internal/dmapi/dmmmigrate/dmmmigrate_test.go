@@ -0,0 +1,122 @@
+package dmmmigrate
+
+import (
+	"strings"
+	"testing"
+
+	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
+	"sdmm/internal/dmapi/dmvars"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, rules string) Rules {
+	r, err := Parse("test", strings.NewReader(rules))
+	require.NoError(t, err)
+	return r
+}
+
+// Creates a prefab with variables provided as name-value pairs.
+func makePrefab(path string, vars ...string) *dmmprefab.Prefab {
+	mutable := &dmvars.MutableVariables{}
+	for idx := 0; idx < len(vars); idx += 2 {
+		mutable.Put(vars[idx], vars[idx+1])
+	}
+	return dmmprefab.New(dmmprefab.IdNone, path, mutable.ToImmutable())
+}
+
+func varsOf(prefab *dmmprefab.Prefab) map[string]string {
+	result := make(map[string]string)
+	for _, name := range prefab.Vars().Iterate() {
+		result[name], _ = prefab.Vars().Value(name)
+	}
+	return result
+}
+
+func TestParse(t *testing.T) {
+	rules := parse(t, `
+# Comment
+/obj/old{dir = 4; name = @UNSET} : /obj/new{@OLD; dir = @SKIP}, /obj/extra{name = "a:b, c; d"}
+/obj/gone/@SUBTYPES : @DELETE
+`)
+
+	require.Len(t, rules, 2)
+	assert.Equal(t, "test:3", rules[0].Source)
+	assert.Equal(t, "/obj/old", rules[0].Path)
+	assert.Equal(t, []Condition{{Name: "dir", Value: "4"}, {Name: "name", Value: "@UNSET", Unset: true}}, rules[0].Conditions)
+	require.Len(t, rules[0].Targets, 2)
+	assert.True(t, rules[0].Targets[0].KeepOld)
+	assert.Equal(t, []VarEdit{{Name: "dir", Op: OpSkip}}, rules[0].Targets[0].Vars)
+	assert.Equal(t, []VarEdit{{Name: "name", Op: OpSet, Value: `"a:b, c; d"`}}, rules[0].Targets[1].Vars)
+
+	assert.True(t, rules[1].Subtypes)
+	assert.Empty(t, rules[1].Targets)
+}
+
+func TestParseFailure(t *testing.T) {
+	for _, rules := range []string{
+		"/obj/old",
+		"obj/old : /obj/new",
+		"/obj/old : /obj/new{name = 1",
+		"/obj/old : /obj/new/@SUBTYPES",
+		"/obj/old : /obj/new{name}",
+	} {
+		_, err := Parse("test", strings.NewReader(rules))
+		assert.Error(t, err, rules)
+	}
+}
+
+func TestApplyReplace(t *testing.T) {
+	rules := parse(t, "/obj/old{dir = 4} : /obj/new{dir = @OLD; desc = @OLD:name; icon_state = \"new\"}")
+
+	prefabs, changed := rules.Apply(makePrefab("/obj/old", "dir", "4", "name", `"thing"`, "pixel_x", "8"))
+	require.True(t, changed)
+	require.Len(t, prefabs, 1)
+	assert.Equal(t, "/obj/new", prefabs[0].Path())
+	assert.Equal(t, map[string]string{"dir": "4", "desc": `"thing"`, "icon_state": `"new"`}, varsOf(prefabs[0]))
+
+	_, changed = rules.Apply(makePrefab("/obj/old", "dir", "8"))
+	assert.False(t, changed)
+	_, changed = rules.Apply(makePrefab("/obj/old"))
+	assert.False(t, changed)
+}
+
+func TestApplyKeepOld(t *testing.T) {
+	rules := parse(t, "/obj/old{name = @UNSET} : /obj/new{@OLD; dir = @SKIP}")
+
+	prefabs, changed := rules.Apply(makePrefab("/obj/old", "dir", "4", "pixel_x", "8"))
+	require.True(t, changed)
+	assert.Equal(t, map[string]string{"pixel_x": "8"}, varsOf(prefabs[0]))
+
+	_, changed = rules.Apply(makePrefab("/obj/old", "name", `"thing"`))
+	assert.False(t, changed)
+}
+
+func TestApplySubtypes(t *testing.T) {
+	rules := parse(t, "/obj/old/@SUBTYPES : /obj/new/@SUBTYPES{@OLD}")
+
+	prefabs, changed := rules.Apply(makePrefab("/obj/old/sub/deep", "dir", "4"))
+	require.True(t, changed)
+	assert.Equal(t, "/obj/new/sub/deep", prefabs[0].Path())
+	assert.Equal(t, map[string]string{"dir": "4"}, varsOf(prefabs[0]))
+
+	prefabs, changed = rules.Apply(makePrefab("/obj/old"))
+	require.True(t, changed)
+	assert.Equal(t, "/obj/new", prefabs[0].Path())
+
+	_, changed = rules.Apply(makePrefab("/obj/older"))
+	assert.False(t, changed)
+}
+
+func TestApplyDeleteAndSplit(t *testing.T) {
+	rules := parse(t, `
+/obj/old : /obj/first, /obj/second
+/obj/second : @DELETE
+`)
+
+	prefabs, changed := rules.Apply(makePrefab("/obj/old"))
+	require.True(t, changed)
+	require.Len(t, prefabs, 1)
+	assert.Equal(t, "/obj/first", prefabs[0].Path())
+}
@@ -0,0 +1,239 @@
+package dmmmigrate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Keywords of the rules syntax.
+const (
+	kwSubtypes = "@SUBTYPES"
+	kwDelete   = "@DELETE"
+	kwOld      = "@OLD"
+	kwSkip     = "@SKIP"
+	kwUnset    = "@UNSET"
+)
+
+// Load reads rules from files. Directories are read as well: every ".txt" file in them is a rules file.
+// Rules are ordered the same way as files are provided, files of directories are sorted by their names.
+func Load(paths ...string) (Rules, error) {
+	var rules Rules
+	for _, path := range paths {
+		files, err := rulesFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			fileRules, err := loadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, fileRules...)
+		}
+	}
+	return rules, nil
+}
+
+func rulesFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func loadFile(path string) (Rules, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(path, f)
+}
+
+// Parse reads rules line by line. Empty lines and lines starting with "#" are ignored.
+// Every rule looks like: "/old/path{var = value} : /new/path{var = @OLD}, /another/path".
+func Parse(name string, r io.Reader) (Rules, error) {
+	var rules Rules
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule, err := parseRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, lineNo, err)
+		}
+		rule.Source = fmt.Sprintf("%s:%d", name, lineNo)
+		rules = append(rules, rule)
+	}
+
+	return rules, scanner.Err()
+}
+
+func parseRule(line string) (rule Rule, err error) {
+	sides := splitOutside(line, ':')
+	if len(sides) != 2 {
+		return rule, fmt.Errorf("expected [match : replacement], got: %s", line)
+	}
+
+	matchPath, matchVars, err := parsePrefab(sides[0])
+	if err != nil {
+		return rule, err
+	}
+	rule.Path, rule.Subtypes = cutSubtypes(matchPath)
+	for _, v := range matchVars {
+		rule.Conditions = append(rule.Conditions, Condition{
+			Name:  v.name,
+			Value: v.value,
+			Unset: v.value == kwUnset,
+		})
+	}
+
+	replacement := strings.TrimSpace(sides[1])
+	if replacement == kwDelete {
+		return rule, nil
+	}
+
+	for _, targetText := range splitOutside(replacement, ',') {
+		target, err := parseTarget(targetText, rule.Subtypes)
+		if err != nil {
+			return rule, err
+		}
+		rule.Targets = append(rule.Targets, target)
+	}
+
+	return rule, nil
+}
+
+func parseTarget(text string, subtypes bool) (target Target, err error) {
+	path, vars, err := parsePrefab(text)
+	if err != nil {
+		return target, err
+	}
+
+	target.Path, target.Subtypes = cutSubtypes(path)
+	if target.Subtypes && !subtypes {
+		return target, fmt.Errorf("%s is used in the replacement, but not in the match: %s", kwSubtypes, text)
+	}
+
+	for _, v := range vars {
+		switch {
+		case v.name == kwOld && len(v.value) == 0:
+			target.KeepOld = true
+		case v.value == kwOld:
+			target.Vars = append(target.Vars, VarEdit{Name: v.name, Op: OpOld, Value: v.name})
+		case strings.HasPrefix(v.value, kwOld+":"):
+			target.Vars = append(target.Vars, VarEdit{Name: v.name, Op: OpOld, Value: strings.TrimPrefix(v.value, kwOld+":")})
+		case v.value == kwSkip:
+			target.Vars = append(target.Vars, VarEdit{Name: v.name, Op: OpSkip})
+		case len(v.value) == 0:
+			return target, fmt.Errorf("variable [%s] has no value: %s", v.name, text)
+		default:
+			target.Vars = append(target.Vars, VarEdit{Name: v.name, Op: OpSet, Value: v.value})
+		}
+	}
+
+	return target, nil
+}
+
+type variable struct {
+	name, value string
+}
+
+// Parses the prefab in the map notation: /path{name = value; name2 = value2}.
+func parsePrefab(text string) (path string, vars []variable, err error) {
+	text = strings.TrimSpace(text)
+
+	varsStart := strings.IndexRune(text, '{')
+	if varsStart == -1 {
+		return text, nil, validatePath(text)
+	}
+	if !strings.HasSuffix(text, "}") {
+		return "", nil, fmt.Errorf("unclosed variables block: %s", text)
+	}
+
+	path = strings.TrimSpace(text[:varsStart])
+	if err = validatePath(path); err != nil {
+		return "", nil, err
+	}
+
+	for _, varText := range splitOutside(text[varsStart+1:len(text)-1], ';') {
+		if varText = strings.TrimSpace(varText); len(varText) == 0 {
+			continue
+		}
+		name, value, _ := strings.Cut(varText, "=")
+		vars = append(vars, variable{
+			name:  strings.TrimSpace(name),
+			value: strings.TrimSpace(value),
+		})
+	}
+
+	return path, vars, nil
+}
+
+func validatePath(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("invalid type path: %s", path)
+	}
+	return nil
+}
+
+func cutSubtypes(path string) (string, bool) {
+	if strings.HasSuffix(path, "/"+kwSubtypes) {
+		return strings.TrimSuffix(path, "/"+kwSubtypes), true
+	}
+	return path, false
+}
+
+// Splits the text by the separator, which is not inside of quotes, braces or parentheses.
+func splitOutside(text string, sep rune) []string {
+	var (
+		parts    []string
+		depth    int
+		inQuote  bool
+		escaping bool
+		start    int
+	)
+
+	for idx, c := range text {
+		switch {
+		case escaping:
+			escaping = false
+		case inQuote && c == '\\':
+			escaping = true
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '{' || c == '(':
+			depth++
+		case c == '}' || c == ')':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, text[start:idx])
+			start = idx + 1
+		}
+	}
+
+	return append(parts, text[start:])
+}
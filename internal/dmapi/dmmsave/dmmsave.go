@@ -1,26 +1,29 @@
 package dmmsave
 
 import (
+	"fmt"
+
 	"sdmm/internal/dmapi/dmenv"
 
 	"sdmm/internal/dmapi/dmmap"
-	"sdmm/internal/util"
 
 	"github.com/rs/zerolog/log"
 )
 
-func Save(dme *dmenv.Dme, dmm *dmmap.Dmm, cfg Config) {
-	SaveV(dme, dmm, dmm.Path.Absolute, cfg)
+func Save(dme *dmenv.Dme, dmm *dmmap.Dmm, cfg Config) error {
+	return SaveV(dme, dmm, dmm.Path.Absolute, cfg)
 }
 
-func SaveV(dme *dmenv.Dme, dmm *dmmap.Dmm, path string, cfg Config) {
+// SaveV saves the map to the provided path.
+// Keys from the map backup are reused whenever possible to keep the output stable.
+// The environment is used only to sanitize variables, so it could be nil when the sanitizing is disabled.
+func SaveV(dme *dmenv.Dme, dmm *dmmap.Dmm, path string, cfg Config) error {
 	log.Printf("save started [%s]...", path)
 
 	sp, err := makeSaveProcess(cfg, dme, dmm, path)
 	if err != nil {
 		log.Print("unable to start save process")
-		util.ShowErrorDialog("Unable to start save process")
-		return
+		return fmt.Errorf("unable to start save process: %w", err)
 	}
 
 	if cfg.SanitizeVariables {
@@ -30,10 +33,13 @@ func SaveV(dme *dmenv.Dme, dmm *dmmap.Dmm, path string, cfg Config) {
 	sp.handleReusedKeys()
 	if err = sp.handleLocationsWithoutKeys(); err != nil {
 		log.Print("unable to handle locations without keys:", err)
-		util.ShowErrorDialog("Unable to save the map: " + err.Error())
-		return
+		return fmt.Errorf("unable to handle locations without keys: %w", err)
+	}
+	if err = sp.output.Save(); err != nil {
+		log.Print("unable to write the map:", err)
+		return fmt.Errorf("unable to write the map: %w", err)
 	}
-	sp.output.Save()
 
 	log.Print("save finished")
+	return nil
 }
@@ -82,30 +82,38 @@ func (sp *saveProcess) sanitizeVariables() {
 
 	for _, tile := range sp.dmm.Tiles {
 		for _, instance := range tile.Instances() {
-			prefab := instance.Prefab()
-			if prefab.Vars().Len() == 0 {
-				continue
+			if prefab := SanitizePrefab(sp.dme, instance.Prefab()); prefab != instance.Prefab() {
+				instance.SetPrefab(prefab)
+				log.Printf("instance sanitized: [%d#%s]", instance.Id(), prefab.Path())
 			}
+		}
+	}
+}
 
-			obj := sp.dme.Objects[prefab.Path()]
-			vars := prefab.Vars()
+// SanitizePrefab returns the prefab without variables equal to their defaults.
+// The same prefab is returned, if there is nothing to sanitize.
+func SanitizePrefab(dme *dmenv.Dme, prefab *dmmprefab.Prefab) *dmmprefab.Prefab {
+	if prefab.Vars().Len() == 0 {
+		return prefab
+	}
 
-			for _, varName := range prefab.Vars().Iterate() {
-				origValue, _ := obj.Vars.Value(varName)
-				prefValue, _ := prefab.Vars().Value(varName)
+	obj := dme.Objects[prefab.Path()]
+	vars := prefab.Vars()
 
-				if origValue == prefValue {
-					log.Print("delete variable:", varName)
-					vars = dmvars.Delete(vars, varName)
-				}
-			}
+	for _, varName := range prefab.Vars().Iterate() {
+		origValue, _ := obj.Vars.Value(varName)
+		prefValue, _ := prefab.Vars().Value(varName)
 
-			if prefab.Vars().Len() != vars.Len() {
-				instance.SetPrefab(dmmprefab.New(dmmprefab.IdNone, prefab.Path(), vars))
-				log.Printf("instance sanitized: [%d#%s]", instance.Id(), prefab.Path())
-			}
+		if origValue == prefValue {
+			log.Print("delete variable:", varName)
+			vars = dmvars.Delete(vars, varName)
 		}
 	}
+
+	if prefab.Vars().Len() != vars.Len() {
+		return dmmprefab.New(dmmprefab.IdNone, prefab.Path(), vars)
+	}
+	return prefab
 }
 
 // Go through the dmm tiles and try to find a key in the initial map with the same content.
@@ -0,0 +1,168 @@
+// Package dmmsmooth chooses icon states of atoms smoothed with their neighbors, like walls, windows and carpets.
+//
+// Every neighbor which the atom smooths with sets its bit in the junction bitmask. Diagonal neighbors are counted,
+// only when both adjacent cardinal neighbors are, the same way as the bitmask smoothing does in game.
+// The junction is then turned into the icon state of the atom.
+package dmmsmooth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"sdmm/internal/dmapi/dm"
+	"sdmm/internal/dmapi/dmvars"
+)
+
+// Config is a part of the project preferences, which describes how the project smooths icons.
+type Config struct {
+	// GroupsVar is a variable with smoothing groups of the atom.
+	// If it's empty, groups of the atom are its type and all parent types.
+	GroupsVar string `json:"groupsVar,omitempty"`
+	// WithVar is a variable with groups the atom smooths with. Only atoms with the variable set are smoothed.
+	WithVar string `json:"withVar"`
+	// Bits are junction bits of neighbors by their direction names: north, south, east, west, northeast etc.
+	// Directions without a bit aren't taken into account.
+	Bits map[string]int `json:"bits,omitempty"`
+	// StateFormat is a format of the smoothed icon state with the {state} and {junction} placeholders.
+	StateFormat string `json:"stateFormat,omitempty"`
+	// States are icon states for specific junctions. They take precedence over the StateFormat.
+	States map[int]string `json:"states,omitempty"`
+}
+
+const defaultStateFormat = "{state}-{junction}"
+
+// Junction bits used by default. The same as the bitmask smoothing of /tg/station codebases.
+var defaultBits = map[string]int{
+	"north":     1,
+	"south":     2,
+	"east":      4,
+	"west":      8,
+	"northeast": 16,
+	"southeast": 32,
+	"southwest": 64,
+	"northwest": 128,
+}
+
+var (
+	cardinalDirs = []int{dm.DirNorth, dm.DirSouth, dm.DirEast, dm.DirWest}
+	diagonalDirs = []int{dm.DirNortheast, dm.DirSoutheast, dm.DirSouthwest, dm.DirNorthwest}
+)
+
+var dirNames = map[int]string{
+	dm.DirNorth:     "north",
+	dm.DirSouth:     "south",
+	dm.DirEast:      "east",
+	dm.DirWest:      "west",
+	dm.DirNortheast: "northeast",
+	dm.DirSoutheast: "southeast",
+	dm.DirSouthwest: "southwest",
+	dm.DirNorthwest: "northwest",
+}
+
+// Validate returns an error, if the config can't be used.
+func (c *Config) Validate() error {
+	if c.WithVar == "" {
+		return errors.New("smoothing without the withVar")
+	}
+	for name := range c.Bits {
+		if _, ok := defaultBits[name]; !ok {
+			return fmt.Errorf("unknown smoothing direction [%s]", name)
+		}
+	}
+	return nil
+}
+
+// Enabled returns true, if atoms should be smoothed with the config.
+func (c Config) Enabled() bool {
+	return c.WithVar != ""
+}
+
+// With returns groups the atom smooths with. If there are no groups, the atom isn't smoothed.
+func (c Config) With(vars *dmvars.Variables) []string {
+	return Groups(vars.ValueV(c.WithVar, dmvars.NullValue))
+}
+
+// Groups returns smoothing groups of the atom.
+func (c Config) Groups(path string, vars *dmvars.Variables) []string {
+	if c.GroupsVar != "" {
+		return Groups(vars.ValueV(c.GroupsVar, dmvars.NullValue))
+	}
+
+	var groups []string
+	for parent := path; parent != ""; parent = parent[:strings.LastIndex(parent, "/")] {
+		groups = append(groups, parent)
+	}
+	return groups
+}
+
+// SmoothsWith returns true, if any of the atom groups is in the with groups.
+func SmoothsWith(with, groups []string) bool {
+	for _, group := range groups {
+		for _, w := range with {
+			if group == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Groups splits a variable value into groups.
+// Groups are type paths, numbers or identifiers, so lists, strings and sums of defines could be used.
+func Groups(value string) []string {
+	tokens := strings.FieldsFunc(value, func(r rune) bool {
+		return r != '/' && r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	groups := tokens[:0]
+	for _, token := range tokens {
+		if token != "list" && token != dmvars.NullValue {
+			groups = append(groups, token)
+		}
+	}
+	return groups
+}
+
+// Junction returns the bitmask of neighbors, which the atom smooths with.
+func (c Config) Junction(smoothsWith func(dir int) bool) int {
+	bits := c.Bits
+	if len(bits) == 0 {
+		bits = defaultBits
+	}
+
+	var junction int
+	connected := make(map[int]bool, len(cardinalDirs))
+
+	for _, dir := range cardinalDirs {
+		if smoothsWith(dir) {
+			connected[dir] = true
+			junction |= bits[dirNames[dir]]
+		}
+	}
+
+	for _, dir := range diagonalDirs {
+		// Diagonal directions are sums of cardinal ones.
+		vertical, horizontal := dir&(dm.DirNorth|dm.DirSouth), dir&(dm.DirEast|dm.DirWest)
+		if connected[vertical] && connected[horizontal] && smoothsWith(dir) {
+			junction |= bits[dirNames[dir]]
+		}
+	}
+
+	return junction
+}
+
+// IconState returns the icon state of the atom with the provided base state and junction.
+func (c Config) IconState(state string, junction int) string {
+	if s, ok := c.States[junction]; ok {
+		return s
+	}
+
+	format := c.StateFormat
+	if format == "" {
+		format = defaultStateFormat
+	}
+	return strings.NewReplacer("{state}", state, "{junction}", strconv.Itoa(junction)).Replace(format)
+}
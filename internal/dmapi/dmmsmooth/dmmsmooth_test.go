@@ -0,0 +1,60 @@
+package dmmsmooth
+
+import (
+	"testing"
+
+	"sdmm/internal/dmapi/dm"
+	"sdmm/internal/dmapi/dmvars"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroups(t *testing.T) {
+	assert.Equal(t, []string{"1", "2"}, Groups(`"1,2,"`))
+	assert.Equal(t, []string{"/turf/closed/wall", "/obj/structure/window"}, Groups("list(/turf/closed/wall, /obj/structure/window)"))
+	assert.Equal(t, []string{"SMOOTH_GROUP_WALLS", "SMOOTH_GROUP_WINDOWS"}, Groups("SMOOTH_GROUP_WALLS + SMOOTH_GROUP_WINDOWS"))
+	assert.Empty(t, Groups(dmvars.NullValue))
+	assert.Empty(t, Groups("list()"))
+}
+
+func TestConfig_Groups(t *testing.T) {
+	vars := dmvars.Set(&dmvars.Variables{}, "smoothing_groups", `"3,"`)
+
+	assert.Equal(t, []string{"3"}, Config{GroupsVar: "smoothing_groups"}.Groups("/turf/closed/wall", vars))
+	assert.Equal(t, []string{"/turf/closed/wall", "/turf/closed", "/turf"}, Config{}.Groups("/turf/closed/wall", vars))
+}
+
+func TestConfig_Junction(t *testing.T) {
+	neighbors := func(dirs ...int) func(int) bool {
+		return func(dir int) bool {
+			for _, d := range dirs {
+				if d == dir {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	c := Config{WithVar: "canSmoothWith"}
+	assert.Equal(t, 0, c.Junction(neighbors()))
+	assert.Equal(t, 1|4, c.Junction(neighbors(dm.DirNorth, dm.DirEast)))
+	assert.Equal(t, 1|4|16, c.Junction(neighbors(dm.DirNorth, dm.DirEast, dm.DirNortheast)))
+	// Diagonal neighbors without both cardinal ones are ignored.
+	assert.Equal(t, 1, c.Junction(neighbors(dm.DirNorth, dm.DirNortheast)))
+
+	c.Bits = map[string]int{"north": 1, "south": 2, "east": 4, "west": 8}
+	assert.Equal(t, 1|4, c.Junction(neighbors(dm.DirNorth, dm.DirEast, dm.DirNortheast)))
+}
+
+func TestConfig_IconState(t *testing.T) {
+	assert.Equal(t, "wall-5", Config{}.IconState("wall", 5))
+	assert.Equal(t, "wall_5", Config{StateFormat: "{state}_{junction}"}.IconState("wall", 5))
+	assert.Equal(t, "box", Config{States: map[int]string{0: "box"}}.IconState("wall", 0))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&Config{WithVar: "canSmoothWith"}).Validate())
+	assert.Error(t, (&Config{}).Validate())
+	assert.Error(t, (&Config{WithVar: "canSmoothWith", Bits: map[string]int{"up": 1}}).Validate())
+}
@@ -24,6 +24,8 @@ type DmmSnap struct {
 	stateId int
 
 	patches []dmmPatch
+
+	journal *journal
 }
 
 func New(current *dmmap.Dmm) *DmmSnap {
@@ -92,9 +94,13 @@ func (d *DmmSnap) Commit() (int, []util.Point) {
 
 		// Collect tiles to update from created tile patches
 		tilesToUpdate = make([]util.Point, 0, len(tilePatches))
+		forward := make([]dmmdata.Prefabs, 0, len(tilePatches))
 		for _, patch := range tilePatches {
 			tilesToUpdate = append(tilesToUpdate, patch.coord)
+			forward = append(forward, patch.forward)
 		}
+
+		d.writeJournal(tilesToUpdate, forward)
 	}
 
 	log.Print("snapshot state committed")
@@ -145,6 +151,12 @@ func (p patchType) String() string {
 
 func (d *DmmSnap) patchState(stateId int, isForward bool, patchType patchType) {
 	log.Printf("patching:[%d], forward:[%t], type:[%s]", stateId, isForward, patchType)
+
+	var (
+		journalCoords  []util.Point
+		journalPrefabs []dmmdata.Prefabs
+	)
+
 	for _, patch := range d.patches[stateId] {
 		var prefabs dmmdata.Prefabs
 		if isForward {
@@ -156,6 +168,8 @@ func (d *DmmSnap) patchState(stateId int, isForward bool, patchType patchType) {
 		// Update current map.
 		if patchType&patchCurrent != 0 {
 			d.current.GetTile(patch.coord).InstancesSet(prefabs)
+			journalCoords = append(journalCoords, patch.coord)
+			journalPrefabs = append(journalPrefabs, prefabs)
 		}
 
 		// Update initial map.
@@ -163,6 +177,10 @@ func (d *DmmSnap) patchState(stateId int, isForward bool, patchType patchType) {
 			d.initial.GetTile(patch.coord).InstancesSet(prefabs)
 		}
 	}
+
+	if len(journalCoords) != 0 {
+		d.writeJournal(journalCoords, journalPrefabs)
+	}
 }
 
 // Basically, does a full copy of the current state to the initial one.
@@ -0,0 +1,227 @@
+package dmmsnap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sdmm/internal/dmapi/dmenv"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/dmapi/dmmap/dmmdata"
+	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
+	"sdmm/internal/dmapi/dmvars"
+	"sdmm/internal/util"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrHistoryOutdated is returned when the stored history was made for a different state of the map file.
+var ErrHistoryOutdated = errors.New("map file was changed")
+
+// history is a serializable form of snapshot patches.
+// The map hash is a hash of the map file content at the moment when the history was written.
+// Patches are valid only for the same file, since they store absolute states of tiles.
+type history struct {
+	MapPath string           `json:"mapPath"`
+	MapHash uint64           `json:"mapHash"`
+	StateId int              `json:"stateId"`
+	Patches [][]historyPatch `json:"patches"`
+}
+
+type historyPatch struct {
+	Coord    util.Point      `json:"coord"`
+	Backward []historyPrefab `json:"backward"`
+	Forward  []historyPrefab `json:"forward"`
+}
+
+type historyPrefab struct {
+	Path string      `json:"path"`
+	Vars [][2]string `json:"vars,omitempty"`
+}
+
+// StatesCount returns the number of states stored in the snapshot, except the initial one.
+func (d *DmmSnap) StatesCount() int {
+	return len(d.patches)
+}
+
+// StateId returns the id of the current snapshot state.
+func (d *DmmSnap) StateId() int {
+	return d.stateId
+}
+
+// PatchCoords returns coordinates of tiles changed by the state with the provided id.
+func (d *DmmSnap) PatchCoords(stateId int) []util.Point {
+	coords := make([]util.Point, 0, len(d.patches[stateId-1]))
+	for _, patch := range d.patches[stateId-1] {
+		coords = append(coords, patch.coord)
+	}
+	return coords
+}
+
+// SaveHistory writes snapshot patches to the file.
+// The current state of the snapshot is expected to be the same as the map file on the disk.
+func (d *DmmSnap) SaveHistory(path string) error {
+	return d.SaveHistoryV(path, nil)
+}
+
+// SaveHistoryV is the same as SaveHistory, but prefabs of patches are written as the sanitize function returns them.
+// Used when the map was written with changed prefabs, like with sanitized variables,
+// so patches are applied on top of the same prefabs, which are in the map file. The function could be nil.
+func (d *DmmSnap) SaveHistoryV(path string, sanitize func(*dmmprefab.Prefab) *dmmprefab.Prefab) error {
+	mapHash, err := fileHash(d.current.Path.Absolute)
+	if err != nil {
+		return err
+	}
+
+	h := history{
+		MapPath: d.current.Path.Absolute,
+		MapHash: mapHash,
+		StateId: d.stateId,
+		Patches: make([][]historyPatch, 0, len(d.patches)),
+	}
+	for _, patch := range d.patches {
+		tilePatches := make([]historyPatch, 0, len(patch))
+		for _, tp := range patch {
+			tilePatches = append(tilePatches, historyPatch{
+				Coord:    tp.coord,
+				Backward: toHistoryPrefabs(sanitizePrefabs(tp.backward, sanitize)),
+				Forward:  toHistoryPrefabs(sanitizePrefabs(tp.forward, sanitize)),
+			})
+		}
+		h.Patches = append(h.Patches, tilePatches)
+	}
+
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	if err = os.WriteFile(path, data, os.ModePerm); err != nil {
+		return err
+	}
+
+	log.Printf("snapshot history saved: [%s], states: [%d]", path, len(h.Patches))
+	return nil
+}
+
+// LoadHistory reads snapshot patches from the file, written by the SaveHistory method.
+// The history is loaded only if the map file wasn't changed since the history was written,
+// otherwise the ErrHistoryOutdated is returned. The snapshot is expected to be just created.
+func (d *DmmSnap) LoadHistory(dme *dmenv.Dme, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var h history
+	if err = json.Unmarshal(data, &h); err != nil {
+		return err
+	}
+
+	mapHash, err := fileHash(d.current.Path.Absolute)
+	if err != nil {
+		return err
+	}
+	if h.MapPath != d.current.Path.Absolute || h.MapHash != mapHash {
+		return ErrHistoryOutdated
+	}
+	if h.StateId < 0 || h.StateId > len(h.Patches) {
+		return fmt.Errorf("invalid state id: %d", h.StateId)
+	}
+
+	patches := make([]dmmPatch, 0, len(h.Patches))
+	for _, hPatch := range h.Patches {
+		if len(hPatch) == 0 {
+			return errors.New("empty patch")
+		}
+
+		patch := make(dmmPatch, 0, len(hPatch))
+		for _, tp := range hPatch {
+			if !d.current.HasTile(tp.Coord) {
+				return fmt.Errorf("tile is out of the map bounds: %v", tp.Coord)
+			}
+
+			backward, err := fromHistoryPrefabs(dme, tp.Backward)
+			if err != nil {
+				return err
+			}
+			forward, err := fromHistoryPrefabs(dme, tp.Forward)
+			if err != nil {
+				return err
+			}
+
+			patch = append(patch, tilePatch{
+				coord:    tp.Coord,
+				backward: backward,
+				forward:  forward,
+			})
+		}
+		patches = append(patches, patch)
+	}
+
+	d.patches = patches
+	d.stateId = h.StateId
+
+	log.Printf("snapshot history loaded: [%s], states: [%d], state id: [%d]", path, len(d.patches), d.stateId)
+	return nil
+}
+
+func sanitizePrefabs(prefabs dmmdata.Prefabs, sanitize func(*dmmprefab.Prefab) *dmmprefab.Prefab) dmmdata.Prefabs {
+	if sanitize == nil {
+		return prefabs
+	}
+	result := make(dmmdata.Prefabs, 0, len(prefabs))
+	for _, prefab := range prefabs {
+		result = append(result, sanitize(prefab))
+	}
+	return result
+}
+
+func toHistoryPrefabs(prefabs dmmdata.Prefabs) []historyPrefab {
+	result := make([]historyPrefab, 0, len(prefabs))
+	for _, prefab := range prefabs {
+		hPrefab := historyPrefab{Path: prefab.Path()}
+		if prefab.Vars() != nil {
+			for _, name := range prefab.Vars().Iterate() {
+				value, _ := prefab.Vars().Value(name)
+				hPrefab.Vars = append(hPrefab.Vars, [2]string{name, value})
+			}
+		}
+		result = append(result, hPrefab)
+	}
+	return result
+}
+
+// Prefabs are taken from the prefab storage, so restored tiles are the same as tiles edited in the editor.
+func fromHistoryPrefabs(dme *dmenv.Dme, hPrefabs []historyPrefab) (dmmdata.Prefabs, error) {
+	prefabs := make(dmmdata.Prefabs, 0, len(hPrefabs))
+	for _, hPrefab := range hPrefabs {
+		obj, ok := dme.Objects[hPrefab.Path]
+		if !ok {
+			return nil, fmt.Errorf("unknown type: %s", hPrefab.Path)
+		}
+
+		vars := &dmvars.MutableVariables{}
+		for _, v := range hPrefab.Vars {
+			vars.Put(v[0], v[1])
+		}
+		immutableVars := vars.ToImmutable()
+		immutableVars.LinkParent(obj.Vars)
+
+		prefabs = append(prefabs, dmmap.PrefabStorage.Put(dmmprefab.New(dmmprefab.IdNone, hPrefab.Path, immutableVars)))
+	}
+	return prefabs, nil
+}
+
+func fileHash(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return util.Djb2(string(data)), nil
+}
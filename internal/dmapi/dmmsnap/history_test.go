@@ -0,0 +1,129 @@
+package dmmsnap
+
+import (
+	"path/filepath"
+	"testing"
+
+	"sdmm/internal/dmapi/dmenv"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/dmapi/dmmap/dmmdata/dmmprefab"
+	"sdmm/internal/dmapi/dmmsave"
+	"sdmm/internal/dmapi/dmmtest"
+	"sdmm/internal/dmapi/dmvars"
+	"sdmm/internal/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMapContent = `"a" = (/turf/floor,/area/space)
+(1,1,1) = {"
+aa
+aa
+"}`
+
+func newTestEnv(rootDir string) *dmenv.Dme {
+	return dmmtest.NewEnv(rootDir, map[string]*dmenv.Object{
+		"/area/space":   {},
+		"/turf/floor":   {},
+		"/obj/item/pen": {Vars: dmvars.Set(&dmvars.Variables{}, "name", `"pen"`)},
+	})
+}
+
+func addPen(env *dmenv.Dme, dmm *dmmap.Dmm, coord util.Point) {
+	pen := dmmap.PrefabStorage.Get("/obj/item/pen", dmvars.FromParent(env.Objects["/obj/item/pen"].Vars))
+	dmm.GetTile(coord).InstancesAdd(pen)
+}
+
+func TestHistory_SaveLoad(t *testing.T) {
+	env := newTestEnv(t.TempDir())
+	dmm := dmmtest.WriteMap(t, env, testMapContent)
+	snap := New(dmm)
+
+	first, second := util.Point{X: 1, Y: 1, Z: 1}, util.Point{X: 2, Y: 2, Z: 1}
+
+	addPen(env, dmm, first)
+	snap.Commit()
+	addPen(env, dmm, second)
+	snap.Commit()
+	snap.GoTo(1)
+
+	historyPath := filepath.Join(t.TempDir(), "history.json")
+	require.NoError(t, snap.SaveHistory(historyPath))
+
+	// The map file wasn't saved, so the loaded map is in the initial state.
+	loaded := dmmtest.LoadMap(t, env, dmm.Path.Absolute)
+	loadedSnap := New(loaded)
+	require.NoError(t, loadedSnap.LoadHistory(env, historyPath))
+
+	assert.Equal(t, 2, loadedSnap.StatesCount())
+	assert.Equal(t, 1, loadedSnap.StateId())
+	assert.Equal(t, []util.Point{first}, loadedSnap.PatchCoords(1))
+	assert.Equal(t, []util.Point{second}, loadedSnap.PatchCoords(2))
+
+	loadedSnap.GoTo(2)
+	assert.Equal(t, []string{"/turf/floor", "/area/space", "/obj/item/pen"}, dmmtest.TilePaths(loaded, second))
+	loadedSnap.GoTo(0)
+	assert.Equal(t, []string{"/turf/floor", "/area/space"}, dmmtest.TilePaths(loaded, first))
+}
+
+func TestHistory_Outdated(t *testing.T) {
+	env := newTestEnv(t.TempDir())
+	dmm := dmmtest.WriteMap(t, env, testMapContent)
+	snap := New(dmm)
+
+	addPen(env, dmm, util.Point{X: 1, Y: 1, Z: 1})
+	snap.Commit()
+
+	historyPath := filepath.Join(t.TempDir(), "history.json")
+	require.NoError(t, snap.SaveHistory(historyPath))
+
+	// The map is changed outside the editor.
+	loaded := dmmtest.WriteMap(t, env, testMapContent+"\n")
+	err := New(loaded).LoadHistory(env, historyPath)
+	assert.ErrorIs(t, err, ErrHistoryOutdated)
+}
+
+func TestHistory_SanitizedOnSave(t *testing.T) {
+	env := newTestEnv(t.TempDir())
+	dmm := dmmtest.WriteMap(t, env, testMapContent)
+	dmm.Backup = dmm.Path.Absolute // Keys of the map are taken from the backup on save.
+	snap := New(dmm)
+
+	first, second := util.Point{X: 1, Y: 1, Z: 1}, util.Point{X: 2, Y: 2, Z: 1}
+
+	// The name is equal to the default one, so it's removed from the file on save.
+	penVars := dmvars.Set(dmvars.FromParent(env.Objects["/obj/item/pen"].Vars), "name", `"pen"`)
+	dmm.GetTile(first).InstancesAdd(dmmap.PrefabStorage.Put(dmmprefab.New(dmmprefab.IdNone, "/obj/item/pen", penVars)))
+	snap.Commit()
+	addPen(env, dmm, second)
+	snap.Commit()
+
+	require.NoError(t, dmmsave.Save(env, dmm, dmmsave.Config{SanitizeVariables: true}))
+
+	historyPath := filepath.Join(t.TempDir(), "history.json")
+	require.NoError(t, snap.SaveHistoryV(historyPath, func(prefab *dmmprefab.Prefab) *dmmprefab.Prefab {
+		return dmmsave.SanitizePrefab(env, prefab)
+	}))
+
+	loaded := dmmtest.LoadMap(t, env, dmm.Path.Absolute)
+	loadedSnap := New(loaded)
+	require.NoError(t, loadedSnap.LoadHistory(env, historyPath))
+
+	// Patches are made for the sanitized file, so the redo doesn't bring removed variables back.
+	loadedSnap.GoTo(0)
+	assert.NotContains(t, dmmtest.TilePaths(loaded, first), "/obj/item/pen")
+	loadedSnap.GoTo(1)
+	var pen *dmmprefab.Prefab
+	for _, prefab := range loaded.GetTile(first).Instances().Prefabs() {
+		if prefab.Path() == "/obj/item/pen" {
+			pen = prefab
+		}
+	}
+	require.NotNil(t, pen)
+	assert.Zero(t, pen.Vars().Len())
+	assert.NotContains(t, dmmtest.TilePaths(loaded, second), "/obj/item/pen")
+
+	loadedSnap.GoTo(2)
+	assert.Contains(t, dmmtest.TilePaths(loaded, second), "/obj/item/pen")
+}
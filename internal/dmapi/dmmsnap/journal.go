@@ -0,0 +1,264 @@
+package dmmsnap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"sdmm/internal/dmapi/dmenv"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/dmapi/dmmap/dmmdata"
+	"sdmm/internal/util"
+
+	"github.com/rs/zerolog/log"
+)
+
+// journal is a file where every change of the current map state is appended.
+// It exists while the map is opened, so if the file is present on start, the editor wasn't closed properly.
+//
+// The file is locked while it's written, so other editors could tell if it's still in use.
+//
+// The first line is a header with the map file hash.
+// Every next line is a list of tiles with their new content.
+// Replaying all lines on top of the map file reproduces the last state of the map.
+type journal struct {
+	mu      sync.Mutex
+	path    string
+	mapPath string
+	file    *os.File
+}
+
+type journalHeader struct {
+	MapPath string `json:"mapPath"`
+	MapHash uint64 `json:"mapHash"`
+}
+
+type journalTile struct {
+	Coord   util.Point      `json:"coord"`
+	Prefabs []historyPrefab `json:"prefabs"`
+}
+
+// StartJournal creates a journal file where all changes of the map will be written.
+// The file shouldn't exist, so journals of other editors are never overwritten.
+func (d *DmmSnap) StartJournal(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if err = util.LockFile(file); err != nil {
+		_ = file.Close()
+		_ = os.Remove(path)
+		return fmt.Errorf("unable to lock journal: %w", err)
+	}
+
+	j := &journal{path: path, mapPath: d.current.Path.Absolute, file: file}
+	if err = j.reset(); err != nil {
+		j.close()
+		return err
+	}
+
+	d.journal = j
+	log.Print("journal started:", path)
+	return nil
+}
+
+// ResetJournal drops all written changes. Should be called when the map is saved.
+func (d *DmmSnap) ResetJournal() {
+	if d.journal == nil {
+		return
+	}
+	if err := d.journal.reset(); err != nil {
+		log.Printf("unable to reset journal [%s]: %v", d.journal.path, err)
+	}
+}
+
+// CloseJournal closes and deletes the journal file. Should be called when the map is closed properly.
+func (d *DmmSnap) CloseJournal() {
+	if d.journal == nil {
+		return
+	}
+	d.journal.close()
+	d.journal = nil
+}
+
+func (d *DmmSnap) writeJournal(coords []util.Point, prefabs []dmmdata.Prefabs) {
+	if d.journal == nil {
+		return
+	}
+
+	tiles := make([]journalTile, 0, len(coords))
+	for idx, coord := range coords {
+		tiles = append(tiles, journalTile{
+			Coord:   coord,
+			Prefabs: toHistoryPrefabs(prefabs[idx]),
+		})
+	}
+
+	if err := d.journal.write(tiles); err != nil {
+		log.Printf("unable to write journal [%s]: %v", d.journal.path, err)
+	}
+}
+
+func (j *journal) reset() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return errors.New("journal is closed")
+	}
+
+	mapHash, err := fileHash(j.mapPath)
+	if err != nil {
+		return err
+	}
+
+	// The file is truncated in place to keep the lock on it.
+	if err = j.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err = j.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return j.writeLine(journalHeader{MapPath: j.mapPath, MapHash: mapHash})
+}
+
+func (j *journal) write(tiles []journalTile) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return errors.New("journal is closed")
+	}
+	return j.writeLine(tiles)
+}
+
+// Every line is synced with the disk, since the journal is meant to survive a crash.
+func (j *journal) writeLine(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err = j.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+func (j *journal) close() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file != nil {
+		_ = j.file.Close()
+		j.file = nil
+	}
+	if err := os.Remove(j.path); err != nil {
+		log.Printf("unable to remove journal [%s]: %v", j.path, err)
+	} else {
+		log.Print("journal closed:", j.path)
+	}
+}
+
+// JournalInfo returns the path of the map and the number of changes written to the journal file.
+func JournalInfo(path string) (mapPath string, changes int, err error) {
+	header, lines, err := readJournal(path)
+	if err != nil {
+		return "", 0, err
+	}
+	return header.MapPath, len(lines), nil
+}
+
+// JournalInUse returns true, if the journal file is locked by the running editor.
+func JournalInUse(path string) bool {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	// The lock is released on close, so it's fine to take it just for the check.
+	return util.LockFile(f) != nil
+}
+
+// ReplayJournal applies changes from the journal file to the map.
+// The map file should be the same as it was when the journal was started.
+// Returns coordinates of changed tiles.
+func ReplayJournal(dme *dmenv.Dme, path string, dmm *dmmap.Dmm) ([]util.Point, error) {
+	header, lines, err := readJournal(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mapHash, err := fileHash(dmm.Path.Absolute)
+	if err != nil {
+		return nil, err
+	}
+	if header.MapPath != dmm.Path.Absolute || header.MapHash != mapHash {
+		return nil, ErrHistoryOutdated
+	}
+
+	changed := make(map[util.Point]bool)
+	for _, tiles := range lines {
+		for _, tile := range tiles {
+			// The map size could be changed, such tiles are just ignored.
+			if !dmm.HasTile(tile.Coord) {
+				continue
+			}
+
+			prefabs, err := fromHistoryPrefabs(dme, tile.Prefabs)
+			if err != nil {
+				return nil, err
+			}
+
+			dmm.GetTile(tile.Coord).InstancesSet(prefabs)
+			changed[tile.Coord] = true
+		}
+	}
+
+	coords := make([]util.Point, 0, len(changed))
+	for coord := range changed {
+		coords = append(coords, coord)
+	}
+
+	log.Printf("journal replayed: [%s], changes: [%d], tiles: [%d]", path, len(lines), len(coords))
+	return coords, nil
+}
+
+func readJournal(path string) (header journalHeader, lines [][]journalTile, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return header, nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64*1024*1024) // A single change could be huge, like a filled area.
+
+	if !scanner.Scan() {
+		return header, nil, errors.New("journal without a header")
+	}
+	if err = json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return header, nil, fmt.Errorf("invalid journal header: %w", err)
+	}
+
+	for scanner.Scan() {
+		var tiles []journalTile
+		// The last line could be written partially, if the editor was closed in the middle of writing.
+		if err := json.Unmarshal(scanner.Bytes(), &tiles); err != nil {
+			log.Printf("broken journal line [%s]: %v", path, err)
+			break
+		}
+		lines = append(lines, tiles)
+	}
+
+	return header, lines, scanner.Err()
+}
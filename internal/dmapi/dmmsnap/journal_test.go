@@ -0,0 +1,107 @@
+package dmmsnap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sdmm/internal/dmapi/dmenv"
+	"sdmm/internal/dmapi/dmmtest"
+	"sdmm/internal/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Starts the journal and writes two changes to it. The journal is left as is, like the editor crashed.
+func writeTestJournal(t *testing.T, env *dmenv.Dme, snap *DmmSnap) string {
+	path := filepath.Join(t.TempDir(), "journal", "test.jsonl")
+	require.NoError(t, snap.StartJournal(path))
+
+	addPen(env, snap.Current(), util.Point{X: 1, Y: 1, Z: 1})
+	snap.Commit()
+	addPen(env, snap.Current(), util.Point{X: 2, Y: 1, Z: 1})
+	snap.Commit()
+
+	return path
+}
+
+func TestJournal_Replay(t *testing.T) {
+	env := newTestEnv(t.TempDir())
+	dmm := dmmtest.WriteMap(t, env, testMapContent)
+	snap := New(dmm)
+	path := writeTestJournal(t, env, snap)
+
+	// The undo is a change of the map too.
+	snap.GoTo(1)
+
+	mapPath, changes, err := JournalInfo(path)
+	require.NoError(t, err)
+	assert.Equal(t, dmm.Path.Absolute, mapPath)
+	assert.Equal(t, 3, changes)
+
+	loaded := dmmtest.LoadMap(t, env, dmm.Path.Absolute)
+	coords, err := ReplayJournal(env, path, loaded)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []util.Point{{X: 1, Y: 1, Z: 1}, {X: 2, Y: 1, Z: 1}}, coords)
+	assert.Equal(t, []string{"/turf/floor", "/area/space", "/obj/item/pen"}, dmmtest.TilePaths(loaded, util.Point{X: 1, Y: 1, Z: 1}))
+	assert.Equal(t, []string{"/turf/floor", "/area/space"}, dmmtest.TilePaths(loaded, util.Point{X: 2, Y: 1, Z: 1}))
+}
+
+func TestJournal_MapChanged(t *testing.T) {
+	env := newTestEnv(t.TempDir())
+	path := writeTestJournal(t, env, New(dmmtest.WriteMap(t, env, testMapContent)))
+
+	// The map is changed outside the editor.
+	loaded := dmmtest.WriteMap(t, env, testMapContent+"\n")
+	_, err := ReplayJournal(env, path, loaded)
+	assert.ErrorIs(t, err, ErrHistoryOutdated)
+}
+
+func TestJournal_TruncatedLine(t *testing.T) {
+	env := newTestEnv(t.TempDir())
+	dmm := dmmtest.WriteMap(t, env, testMapContent)
+	path := writeTestJournal(t, env, New(dmm))
+
+	// The editor crashed in the middle of writing the last change.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, os.ModePerm)
+	require.NoError(t, err)
+	_, err = f.WriteString(`[{"coord":{"x":2,"y":2,"z":1},"prefabs":[{"pa`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, changes, err := JournalInfo(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, changes)
+
+	loaded := dmmtest.LoadMap(t, env, dmm.Path.Absolute)
+	coords, err := ReplayJournal(env, path, loaded)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []util.Point{{X: 1, Y: 1, Z: 1}, {X: 2, Y: 1, Z: 1}}, coords)
+	assert.Equal(t, []string{"/turf/floor", "/area/space"}, dmmtest.TilePaths(loaded, util.Point{X: 2, Y: 2, Z: 1}))
+}
+
+func TestJournal_InUse(t *testing.T) {
+	env := newTestEnv(t.TempDir())
+	snap := New(dmmtest.WriteMap(t, env, testMapContent))
+	path := writeTestJournal(t, env, snap)
+
+	assert.True(t, JournalInUse(path))
+
+	// Another editor with the same journal path can't take it.
+	assert.Error(t, snap.StartJournal(path))
+	assert.FileExists(t, path)
+
+	// The lock is released with the file, like the editor crashed.
+	require.NoError(t, snap.journal.file.Close())
+	assert.False(t, JournalInUse(path))
+}
+
+func TestJournal_Close(t *testing.T) {
+	env := newTestEnv(t.TempDir())
+	snap := New(dmmtest.WriteMap(t, env, testMapContent))
+	path := writeTestJournal(t, env, snap)
+
+	snap.CloseJournal()
+	assert.NoFileExists(t, path)
+}
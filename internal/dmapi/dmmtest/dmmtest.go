@@ -0,0 +1,62 @@
+// Package dmmtest provides environments and maps for tests of packages, which work with maps.
+package dmmtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sdmm/internal/dmapi/dmenv"
+	"sdmm/internal/dmapi/dmmap"
+	"sdmm/internal/dmapi/dmmap/dmmdata"
+	"sdmm/internal/dmapi/dmvars"
+	"sdmm/internal/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+// NewEnv creates the environment with provided objects by their paths. Objects without variables get empty ones.
+func NewEnv(rootDir string, objects map[string]*dmenv.Object) *dmenv.Dme {
+	for path, object := range objects {
+		object.Path = path
+		if object.Vars == nil {
+			object.Vars = &dmvars.Variables{}
+		}
+	}
+	return &dmenv.Dme{RootDir: rootDir, Objects: objects}
+}
+
+// ParseMap parses the map content and makes the dmmap.Dmm of it without writing the map file.
+func ParseMap(t testing.TB, env *dmenv.Dme, content string, obsolete dmmap.ObsoleteConfig) (*dmmdata.DmmData, *dmmap.Dmm) {
+	t.Helper()
+	data, err := dmmdata.Parse(filepath.Join(env.RootDir, "test.dmm"), []byte(content))
+	require.NoError(t, err)
+	dmm, _, _ := dmmap.NewWithObsoleteConfig(env, data, "", obsolete)
+	return data, dmm
+}
+
+// WriteMap writes the map file to the root directory of the environment and loads the map from it.
+func WriteMap(t testing.TB, env *dmenv.Dme, content string) *dmmap.Dmm {
+	t.Helper()
+	path := filepath.Join(env.RootDir, "test.dmm")
+	require.NoError(t, os.WriteFile(path, []byte(content), os.ModePerm))
+	return LoadMap(t, env, path)
+}
+
+// LoadMap loads the map from the file.
+func LoadMap(t testing.TB, env *dmenv.Dme, path string) *dmmap.Dmm {
+	t.Helper()
+	data, err := dmmdata.New(path)
+	require.NoError(t, err)
+	dmm, _ := dmmap.New(env, data, "")
+	return dmm
+}
+
+// TilePaths returns paths of instances on the tile in their order.
+func TilePaths(dmm *dmmap.Dmm, coord util.Point) []string {
+	var paths []string
+	for _, prefab := range dmm.GetTile(coord).Instances().Prefabs() {
+		paths = append(paths, prefab.Path())
+	}
+	return paths
+}
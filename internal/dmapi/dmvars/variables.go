@@ -98,6 +98,13 @@ func (v *Variables) LinkParent(parent *Variables) {
 	v.parent = parent
 }
 
+// RelinkParent replaces the parent of variables.
+// Unlike the LinkParent, it's allowed to replace an existing parent. Needed when the environment is reloaded,
+// so prefabs should point to variables of new environment objects.
+func (v *Variables) RelinkParent(parent *Variables) {
+	v.parent = parent
+}
+
 func (v *Variables) Iterate() []string {
 	return v.names
 }
@@ -26,3 +26,55 @@ func CreateTexture(img *image.NRGBA) uint32 {
 
 	return handle
 }
+
+// CreateEmptyTexture creates a transparent texture of the provided size, to be filled with the UpdateTexture later.
+// Mipmaps aren't generated, use the GenerateMipmap when the texture is filled.
+func CreateEmptyTexture(width, height int) uint32 {
+	var lastTexture int32
+	var handle uint32
+
+	gl.GetIntegerv(gl.TEXTURE_BINDING_2D, &lastTexture)
+	gl.GenTextures(1, &handle)
+	gl.BindTexture(gl.TEXTURE_2D, handle)
+	defer gl.BindTexture(gl.TEXTURE_2D, uint32(lastTexture))
+
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST_MIPMAP_LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+
+	// The content of a texture created without data is undefined, so it's cleared explicitly.
+	pix := make([]uint8, width*height*4)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(width), int32(height), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pix))
+
+	return handle
+}
+
+// UpdateTexture writes the image into the texture at the provided position.
+func UpdateTexture(handle uint32, x, y int, img *image.NRGBA) {
+	var lastTexture int32
+
+	gl.GetIntegerv(gl.TEXTURE_BINDING_2D, &lastTexture)
+	gl.BindTexture(gl.TEXTURE_2D, handle)
+	defer gl.BindTexture(gl.TEXTURE_2D, uint32(lastTexture))
+
+	gl.TexSubImage2D(gl.TEXTURE_2D, 0, int32(x), int32(y), int32(img.Bounds().Dx()), int32(img.Bounds().Dy()), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+}
+
+func GenerateMipmap(handle uint32) {
+	var lastTexture int32
+
+	gl.GetIntegerv(gl.TEXTURE_BINDING_2D, &lastTexture)
+	gl.BindTexture(gl.TEXTURE_2D, handle)
+	defer gl.BindTexture(gl.TEXTURE_2D, uint32(lastTexture))
+
+	gl.GenerateMipmap(gl.TEXTURE_2D)
+}
+
+// MaxTextureSize returns the maximum width and height of a texture supported by the GPU.
+func MaxTextureSize() int {
+	var size int32
+	gl.GetIntegerv(gl.MAX_TEXTURE_SIZE, &size)
+	return int(size)
+}
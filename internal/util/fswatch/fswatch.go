@@ -0,0 +1,130 @@
+// Package fswatch watches files for changes by polling their sizes and modification times.
+// Polling is used instead of system notifications, since it works the same way on every platform
+// and doesn't depend on limits of watched files, which are easily exceeded by big codebases.
+package fswatch
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FilesFunc returns paths of files to watch. It's called on every poll, so the list of files could be changed.
+type FilesFunc func() []string
+
+// ChangeFunc is called with paths of files which were added, removed or modified since the previous poll.
+// Called from the watcher goroutine.
+type ChangeFunc func(changed []string)
+
+// Absent files have their own state, so a file which appears after being absent is reported as changed.
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+type Watcher struct {
+	interval    time.Duration
+	files       FilesFunc
+	onChange    ChangeFunc
+	reportAdded bool
+
+	mu     sync.Mutex
+	states map[string]fileState
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// New creates a watcher and starts polling files in the background.
+// Files state at the moment of creation is considered as initial, so it's not reported.
+func New(interval time.Duration, files FilesFunc, onChange ChangeFunc) *Watcher {
+	return NewV(interval, files, onChange, true)
+}
+
+// NewV is the same as New, but allows to not report files added to or removed from the list of watched files.
+// Such files are reported only when they are created, modified or removed while they are in the list.
+func NewV(interval time.Duration, files FilesFunc, onChange ChangeFunc, reportAdded bool) *Watcher {
+	w := &Watcher{
+		interval:    interval,
+		files:       files,
+		onChange:    onChange,
+		reportAdded: reportAdded,
+		stop:        make(chan struct{}),
+	}
+	w.states = w.collectStates()
+	go w.run()
+	return w
+}
+
+// Stop stops polling. The change callback won't be called after the method returns,
+// unless it's already in progress.
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+}
+
+func (w *Watcher) run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			if changed := w.Poll(); len(changed) != 0 {
+				select {
+				case <-w.stop:
+					return
+				default:
+					w.onChange(changed)
+				}
+			}
+		}
+	}
+}
+
+// Poll compares the current state of files with the previous one and returns paths of changed files.
+// It's done by the watcher automatically, but could be called manually to check changes immediately.
+func (w *Watcher) Poll() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	states := w.collectStates()
+
+	var changed []string
+	for path, state := range states {
+		if prev, ok := w.states[path]; ok && prev != state {
+			changed = append(changed, path)
+		} else if !ok && w.reportAdded && state.exists {
+			changed = append(changed, path)
+		}
+	}
+	for path, prev := range w.states {
+		if _, ok := states[path]; !ok && w.reportAdded && prev.exists {
+			changed = append(changed, path)
+		}
+	}
+
+	w.states = states
+
+	sort.Strings(changed)
+	return changed
+}
+
+// Files which can't be accessed are considered as absent.
+func (w *Watcher) collectStates() map[string]fileState {
+	paths := w.files()
+	states := make(map[string]fileState, len(paths))
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			states[path] = fileState{exists: true, size: info.Size(), modTime: info.ModTime()}
+		} else {
+			states[path] = fileState{}
+		}
+	}
+	return states
+}
@@ -0,0 +1,100 @@
+package fswatch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoll(t *testing.T) {
+	dir := t.TempDir()
+	modified := filepath.Join(dir, "modified.dm")
+	removed := filepath.Join(dir, "removed.dm")
+	added := filepath.Join(dir, "added.dm")
+	same := filepath.Join(dir, "same.dm")
+
+	for _, path := range []string{modified, removed, same} {
+		require.NoError(t, os.WriteFile(path, []byte("a"), os.ModePerm))
+	}
+
+	files := func() []string {
+		paths, _ := filepath.Glob(filepath.Join(dir, "*.dm"))
+		return paths
+	}
+
+	// A big interval to do polls manually.
+	w := New(time.Hour, files, func([]string) {})
+	defer w.Stop()
+
+	assert.Empty(t, w.Poll())
+
+	require.NoError(t, os.WriteFile(modified, []byte("ab"), os.ModePerm))
+	require.NoError(t, os.Remove(removed))
+	require.NoError(t, os.WriteFile(added, []byte("a"), os.ModePerm))
+
+	assert.Equal(t, []string{added, modified, removed}, w.Poll())
+	assert.Empty(t, w.Poll())
+}
+
+func TestPollNotReportAdded(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.dmi")
+	require.NoError(t, os.WriteFile(path, []byte("a"), os.ModePerm))
+
+	var paths []string
+	w := NewV(time.Hour, func() []string { return paths }, func([]string) {}, false)
+	defer w.Stop()
+
+	paths = []string{path}
+	assert.Empty(t, w.Poll())
+
+	require.NoError(t, os.WriteFile(path, []byte("ab"), os.ModePerm))
+	assert.Equal(t, []string{path}, w.Poll())
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.dm")
+
+	changes := make(chan []string, 1)
+	w := New(10*time.Millisecond, func() []string { return []string{path} }, func(changed []string) {
+		changes <- changed
+	})
+	defer w.Stop()
+
+	require.NoError(t, os.WriteFile(path, []byte("a"), os.ModePerm))
+
+	select {
+	case changed := <-changes:
+		assert.Equal(t, []string{path}, changed)
+	case <-time.After(time.Second):
+		t.Fatal("change wasn't reported")
+	}
+}
+
+func TestPollCreatedAfterMissing(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "missing.dmi")
+
+	var paths []string
+	w := NewV(time.Hour, func() []string { return paths }, func([]string) {}, false)
+	defer w.Stop()
+
+	// The missing file is added to the list, which isn't reported.
+	paths = []string{path}
+	assert.Empty(t, w.Poll())
+
+	require.NoError(t, os.WriteFile(path, []byte("a"), os.ModePerm))
+	assert.Equal(t, []string{path}, w.Poll())
+
+	require.NoError(t, os.Remove(path))
+	assert.Equal(t, []string{path}, w.Poll())
+
+	// The file is removed from the list, which isn't reported too.
+	paths = nil
+	assert.Empty(t, w.Poll())
+}
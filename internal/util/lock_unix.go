@@ -0,0 +1,15 @@
+//go:build !windows
+
+package util
+
+import (
+	"os"
+	"syscall"
+)
+
+// LockFile puts an exclusive lock on the file without waiting for it.
+// Returns an error, if the file is already locked by someone else.
+// The lock is released when the file is closed or the process is terminated.
+func LockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+}
@@ -0,0 +1,35 @@
+package util
+
+import (
+	"math"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+)
+
+var procLockFileEx = syscall.NewLazyDLL("kernel32.dll").NewProc("LockFileEx")
+
+// LockFile puts an exclusive lock on the file without waiting for it.
+// Returns an error, if the file is already locked by someone else.
+// The lock is released when the file is closed or the process is terminated.
+func LockFile(f *os.File) error {
+	// Locks on Windows are mandatory, so the locked byte is placed far beyond the content to keep the file readable.
+	ol := syscall.Overlapped{OffsetHigh: math.MaxInt32}
+	r, _, err := procLockFileEx.Call(
+		f.Fd(),
+		lockfileExclusiveLock|lockfileFailImmediately,
+		0,
+		1,
+		0,
+		uintptr(unsafe.Pointer(&ol)),
+	)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
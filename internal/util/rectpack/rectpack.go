@@ -0,0 +1,142 @@
+// Package rectpack places rectangles into a fixed size area.
+//
+// Rectangles are placed with the shelf algorithm: the area is split into horizontal shelves,
+// and every rectangle is placed on the lowest shelf, which wastes the least of its height.
+// It's fast and works well for rectangles of similar heights, like sheets of icons.
+// Freed rectangles leave gaps on their shelves, which are reused by next rectangles of the fitting size.
+package rectpack
+
+type Packer struct {
+	width, height int
+	padding       int // Free space around every rectangle.
+
+	shelves []shelf
+}
+
+type shelf struct {
+	y, height int
+	x         int    // The start of the free space on the shelf.
+	gaps      []span // Free spaces left by freed rectangles before the x, sorted by their positions.
+}
+
+type span struct {
+	x, width int
+}
+
+// New creates a packer for the area of the provided size.
+// The padding is a free space kept between packed rectangles.
+func New(width, height, padding int) *Packer {
+	return &Packer{width: width, height: height, padding: padding}
+}
+
+// Pack finds a place for the rectangle and returns its top left corner.
+// The last value is false, if there is no free space for the rectangle.
+func (p *Packer) Pack(width, height int) (x, y int, ok bool) {
+	width += p.padding
+	height += p.padding
+
+	if x, y, ok = p.packGap(width, height); ok {
+		return x, y, true
+	}
+
+	best := -1
+	for idx, s := range p.shelves {
+		if s.height < height || s.x+width > p.width {
+			continue
+		}
+		if best == -1 || s.height < p.shelves[best].height {
+			best = idx
+		}
+	}
+
+	if best == -1 {
+		var top int
+		if len(p.shelves) > 0 {
+			last := p.shelves[len(p.shelves)-1]
+			top = last.y + last.height
+		}
+		if top+height > p.height || width > p.width {
+			return 0, 0, false
+		}
+		p.shelves = append(p.shelves, shelf{y: top, height: height})
+		best = len(p.shelves) - 1
+	}
+
+	s := &p.shelves[best]
+	x, y = s.x, s.y
+	s.x += width
+	return x, y, true
+}
+
+// Places the rectangle into a gap of the shelf, which wastes the least of its height.
+func (p *Packer) packGap(width, height int) (x, y int, ok bool) {
+	bestShelf, bestGap := -1, -1
+	for shelfIdx, s := range p.shelves {
+		if s.height < height || (bestShelf != -1 && s.height >= p.shelves[bestShelf].height) {
+			continue
+		}
+		for gapIdx, g := range s.gaps {
+			if g.width >= width {
+				bestShelf, bestGap = shelfIdx, gapIdx
+				break
+			}
+		}
+	}
+
+	if bestShelf == -1 {
+		return 0, 0, false
+	}
+
+	s := &p.shelves[bestShelf]
+	g := &s.gaps[bestGap]
+	x, y = g.x, s.y
+	if g.x, g.width = g.x+width, g.width-width; g.width == 0 {
+		s.gaps = append(s.gaps[:bestGap], s.gaps[bestGap+1:]...)
+	}
+	return x, y, true
+}
+
+// Free releases the space of the rectangle packed at the position, so it could be used by next rectangles.
+// The size should be the same as the one the rectangle was packed with.
+func (p *Packer) Free(x, y, width, height int) {
+	width += p.padding
+
+	shelfIdx := -1
+	for idx, s := range p.shelves {
+		if s.y == y {
+			shelfIdx = idx
+			break
+		}
+	}
+	if shelfIdx == -1 {
+		return
+	}
+
+	s := &p.shelves[shelfIdx]
+
+	// Neighbour gaps are merged, so wide rectangles could fit into them.
+	pos := 0
+	for pos < len(s.gaps) && s.gaps[pos].x < x {
+		pos++
+	}
+	s.gaps = append(s.gaps[:pos], append([]span{{x: x, width: width}}, s.gaps[pos:]...)...)
+	if pos+1 < len(s.gaps) && s.gaps[pos].x+s.gaps[pos].width == s.gaps[pos+1].x {
+		s.gaps[pos].width += s.gaps[pos+1].width
+		s.gaps = append(s.gaps[:pos+1], s.gaps[pos+2:]...)
+	}
+	if pos > 0 && s.gaps[pos-1].x+s.gaps[pos-1].width == s.gaps[pos].x {
+		s.gaps[pos-1].width += s.gaps[pos].width
+		s.gaps = append(s.gaps[:pos], s.gaps[pos+1:]...)
+	}
+
+	// The gap at the end of the shelf is the free space of the shelf.
+	if last := s.gaps[len(s.gaps)-1]; last.x+last.width == s.x {
+		s.x = last.x
+		s.gaps = s.gaps[:len(s.gaps)-1]
+	}
+
+	// Empty shelves on the top are removed, so a shelf of a different height could be placed instead.
+	for len(p.shelves) > 0 && p.shelves[len(p.shelves)-1].x == 0 {
+		p.shelves = p.shelves[:len(p.shelves)-1]
+	}
+}
@@ -0,0 +1,102 @@
+package rectpack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type rect struct {
+	x, y, w, h int
+}
+
+func (r rect) overlaps(o rect) bool {
+	return r.x < o.x+o.w && o.x < r.x+r.w && r.y < o.y+o.h && o.y < r.y+r.h
+}
+
+func TestPack(t *testing.T) {
+	p := New(128, 128, 2)
+
+	var packed []rect
+	for _, size := range [][2]int{{32, 32}, {64, 32}, {32, 48}, {16, 16}, {32, 32}, {96, 32}} {
+		x, y, ok := p.Pack(size[0], size[1])
+		assert.True(t, ok, "size: %v", size)
+
+		r := rect{x, y, size[0], size[1]}
+		assert.LessOrEqual(t, r.x+r.w, 128)
+		assert.LessOrEqual(t, r.y+r.h, 128)
+		for _, o := range packed {
+			assert.False(t, r.overlaps(o), "%v overlaps %v", r, o)
+		}
+		packed = append(packed, r)
+	}
+}
+
+func TestPackLowestShelf(t *testing.T) {
+	p := New(128, 128, 0)
+
+	_, _, _ = p.Pack(64, 48)
+	_, _, _ = p.Pack(128, 32)
+
+	// The second shelf has less height, so it's used for the small rectangle, but it has no space.
+	// The first shelf is used instead.
+	x, y, ok := p.Pack(32, 32)
+	assert.True(t, ok)
+	assert.Equal(t, 64, x)
+	assert.Equal(t, 0, y)
+}
+
+func TestPackNoSpace(t *testing.T) {
+	p := New(64, 64, 0)
+
+	_, _, ok := p.Pack(65, 10)
+	assert.False(t, ok)
+
+	_, _, ok = p.Pack(64, 40)
+	assert.True(t, ok)
+	_, _, ok = p.Pack(10, 30)
+	assert.False(t, ok)
+	_, _, ok = p.Pack(10, 24)
+	assert.True(t, ok)
+}
+
+func TestFree(t *testing.T) {
+	p := New(128, 128, 0)
+
+	x1, y1, _ := p.Pack(32, 32)
+	x2, y2, _ := p.Pack(32, 32)
+	_, _, _ = p.Pack(32, 32)
+
+	// Freed rectangles are merged, so a wider one fits into their space.
+	p.Free(x1, y1, 32, 32)
+	p.Free(x2, y2, 32, 32)
+	x, y, ok := p.Pack(64, 16)
+	assert.True(t, ok)
+	assert.Equal(t, 0, x)
+	assert.Equal(t, 0, y)
+
+	// The rectangle doesn't fit into the shelf gap by its height.
+	x, y, ok = p.Pack(16, 48)
+	assert.True(t, ok)
+	assert.Equal(t, 0, x)
+	assert.Equal(t, 32, y)
+}
+
+func TestFreeShelf(t *testing.T) {
+	p := New(64, 64, 2)
+
+	_, _, _ = p.Pack(30, 30)
+	x, y, _ := p.Pack(30, 30)
+	_, _, ok := p.Pack(60, 40)
+	assert.False(t, ok)
+
+	// The top shelf is removed, when it's empty, so the space is used by a taller rectangle.
+	p.Free(x, y, 30, 30)
+	_, _, ok = p.Pack(10, 40)
+	assert.False(t, ok)
+	p.Free(0, 0, 30, 30)
+	x, y, ok = p.Pack(60, 40)
+	assert.True(t, ok)
+	assert.Equal(t, 0, x)
+	assert.Equal(t, 0, y)
+}
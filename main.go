@@ -4,9 +4,13 @@ import (
 	"os"
 
 	"sdmm/internal/app"
+	"sdmm/internal/cli"
 )
 
 func main() {
+	if cli.IsCommand(os.Args) {
+		os.Exit(cli.Run(os.Args))
+	}
 	app.Start()
 	os.Exit(0)
 }
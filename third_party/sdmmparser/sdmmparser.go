@@ -84,8 +84,14 @@ type IconMetadata struct {
 type IconState struct {
 	Name         string
 	Dirs, Frames int
+	Delays       []float32 // Delays of frames in deciseconds. Empty, if the state has the only frame.
+	Loop         int       // Number of animation loops, zero means an infinite animation.
+	Rewind       bool
 }
 
+// ParseIconMetadata reads metadata of the DMI file with the Rust library.
+//
+// Deprecated: icons are read with the dmimeta package, which doesn't need cgo.
 func ParseIconMetadata(iconPath string) (*IconMetadata, error) {
 	nativePath := C.CString(iconPath)
 	defer C.free(unsafe.Pointer(nativePath))
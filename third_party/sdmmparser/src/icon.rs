@@ -14,6 +14,10 @@ struct IconState {
     name: String,
     dirs: u32,
     frames: u32,
+    delays: Vec<f32>,
+    #[serde(rename = "loop")]
+    loop_: u32,
+    rewind: bool,
 }
 
 pub fn parse_icon_metadata(path: String) -> String {
@@ -60,6 +64,12 @@ fn meta2json(metadata: Metadata) -> String {
                 Dirs::Eight => 8,
             },
             frames: state.frames.count() as u32,
+            delays: match &state.frames {
+                Frames::Delays(delays) => delays.clone(),
+                _ => Vec::new(),
+            },
+            loop_: state.loop_.map_or(0, |n| n.get()),
+            rewind: state.rewind,
         });
     }
 